@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// IssueActionToken (Одноразовая ссылка)
+// Выпускает одноразовый токен для действия приложения (подтверждение
+// удаления, принятие приглашения и т.п.). purpose - произвольное имя
+// действия, кроме назначений пакета (PurposeVerifyEmail и др.), subject -
+// то, к чему относится действие (ID пользователя, приглашения и т.д.).
+// Токену нужен черный список (WithTokenBlacklist): без него повтор нельзя
+// предотвратить, и возвращается ErrBlacklistUnsupported.
+func (s *AuthService) IssueActionToken(ctx context.Context, purpose, subject string, ttl time.Duration) (string, error) {
+	if s.blacklist == nil {
+		return "", ErrBlacklistUnsupported
+	}
+	if isReservedPurpose(purpose) {
+		return "", ErrReservedPurpose
+	}
+	if ttl <= 0 {
+		return "", ErrInvalidTokenOptions
+	}
+	return s.issuePurposeToken(ctx, purpose, subject, ttl)
+}
+
+// ConsumeActionToken проверяет токен IssueActionToken, гасит его и
+// возвращает subject. Токен другого назначения отклоняется с
+// ErrWrongTokenPurpose, повторное предъявление - с ErrTokenAlreadyUsed.
+func (s *AuthService) ConsumeActionToken(ctx context.Context, tokenString, expectedPurpose string) (string, error) {
+	if s.blacklist == nil {
+		return "", ErrBlacklistUnsupported
+	}
+	if isReservedPurpose(expectedPurpose) {
+		return "", ErrReservedPurpose
+	}
+	claims, err := s.consumePurposeToken(ctx, tokenString, expectedPurpose)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// isReservedPurpose сообщает, что назначение нельзя использовать для
+// IssueActionToken: пустое или принадлежащее служебным токенам пакета
+// (иначе через него можно было бы выпустить, например, токен подтверждения email).
+func isReservedPurpose(purpose string) bool {
+	switch purpose {
+	case "", PurposeVerifyEmail, PurposePasswordReset, PurposeDeviceTrust, PurposeMagicLink:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// LoginMultiAudience (Логин для нескольких аудиторий)
+// Как Login, но записывает в aud токена все audiences (вместо WithAudience):
+// один токен принимается веб-приложением, мобильным клиентом и внутренним
+// API, каждый из которых проверяет свою аудиторию через
+// ValidateTokenForAudience. Пустой список или пустая аудитория -
+// ErrInvalidTokenOptions (до проверки пароля).
+func (s *AuthService) LoginMultiAudience(ctx context.Context, email, password string, audiences []string) (string, error) {
+	if len(audiences) == 0 || slices.Contains(audiences, "") {
+		return "", fmt.Errorf("%w: нужна хотя бы одна непустая аудитория", ErrInvalidTokenOptions)
+	}
+
+	user, err := s.authenticate(ctx, email, password, secondFactor{})
+	if err != nil {
+		return "", err
+	}
+	return s.issueAccessToken(ctx, user, TokenOptions{
+		authTime: s.clock.Now(),
+		audience: slices.Clone(audiences),
+	})
+}
+
+// ValidateTokenForAudience (Проверка аудитории)
+// Как ParseAndValidateToken, но дополнительно требует, чтобы requiredAud
+// входила в aud токена (строка или массив). Так один токен, выпущенный
+// для нескольких сервисов, принимается каждым из них, а сервис
+// проверяет именно свою принадлежность. Иначе - ErrInvalidAudience.
+func (s *AuthService) ValidateTokenForAudience(tokenString, requiredAud string) (*JWTClaims, error) {
+	return s.ValidateTokenForAudienceContext(context.Background(), tokenString, requiredAud)
+}
+
+// ValidateTokenForAudienceContext - ValidateTokenForAudience с контекстом.
+func (s *AuthService) ValidateTokenForAudienceContext(ctx context.Context, tokenString, requiredAud string) (*JWTClaims, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.validateWith(ctx, s.parser, tokenString)
+	if err == nil && (requiredAud == "" || !slices.Contains(claims.Audience, requiredAud)) {
+		err = ErrInvalidAudience
+	}
+	s.observeValidation(err)
+	s.logValidation(ctx, claims, err)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// AuditAction - вид изменения, попадающего в журнал аудита.
+type AuditAction string
+
+const (
+	AuditPasswordChange AuditAction = "password_change"
+	AuditPasswordReset  AuditAction = "password_reset"
+	AuditTOTPEnroll     AuditAction = "totp_enroll"
+	// AuditTokensRevoke - увеличена версия токенов пользователя (RevokeAllTokens).
+	AuditTokensRevoke AuditAction = "tokens_revoke"
+	// AuditLogoutAll - завершены все сессии пользователя (LogoutAll).
+	AuditLogoutAll AuditAction = "logout_all"
+)
+
+// AuditRecord - запись журнала аудита об успешном изменении,
+// влияющем на безопасность учетной записи.
+// Записи связаны в цепочку: Hash покрывает содержимое записи и PrevHash,
+// поэтому изменение или удаление записи в середине журнала обнаруживается
+// через VerifyAuditChain. Цепочка ведется в памяти процесса и после
+// перезапуска начинается заново (PrevHash пустой).
+type AuditRecord struct {
+	Action AuditAction `json:"action"`
+	// ActorID - кто выполнил действие: пользователь из ClaimsFromContext
+	// (при имперсонации - администратор), для смены и сброса пароля -
+	// сам пользователь; 0, если не определен.
+	ActorID  int64             `json:"actor_id"`
+	TargetID int64             `json:"target_id"` // чья учетная запись изменена
+	Time     time.Time         `json:"time"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Сведения о клиенте из WithRequestMeta; пусто, если не переданы
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// AuditSink сохраняет записи аудита (БД, WORM-хранилище, SIEM).
+// В отличие от EventHook вызывается синхронно, по одной записи за раз
+// в порядке цепочки, и только после успешного изменения. Ошибка Record
+// не отменяет уже выполненное изменение: она пишется в журнал (WithLogger)
+// на уровне Error, поэтому надежность доставки - задача реализации.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// ComputeHash (Хэш записи аудита)
+// Возвращает hex(SHA-256) от PrevHash и содержимого записи без поля Hash.
+// Для записи из журнала совпадает с ее Hash, если запись не изменялась.
+func (r AuditRecord) ComputeHash() string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain (Проверка цепочки аудита)
+// Проверяет записи в порядке записи: хэш каждой записи и ее связь
+// с предыдущей. Возвращает ErrAuditChainBroken с номером первой
+// поврежденной записи. Первая запись может продолжать более ранний журнал.
+func VerifyAuditChain(records []AuditRecord) error {
+	for i, record := range records {
+		if record.ComputeHash() != record.Hash {
+			return fmt.Errorf("%w: запись %d изменена", ErrAuditChainBroken, i)
+		}
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			return fmt.Errorf("%w: запись %d не связана с предыдущей", ErrAuditChainBroken, i)
+		}
+	}
+	return nil
+}
+
+// audit добавляет запись в цепочку и передает ее в AuditSink, если он задан.
+// Вызывается только после успешного изменения.
+func (s *AuthService) audit(ctx context.Context, action AuditAction, actorID, targetID int64, metadata map[string]string) {
+	if s.auditSink == nil {
+		return
+	}
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		actorID = auditActor(claims)
+	}
+	record := AuditRecord{
+		Action:   action,
+		ActorID:  actorID,
+		TargetID: targetID,
+		// Точность БД обычно не выше микросекунд: иначе хэш не сойдется
+		Time:     s.clock.Now().UTC().Truncate(time.Microsecond),
+		Metadata: metadata,
+	}
+	if meta, ok := RequestMetaFromContext(ctx); ok {
+		record.IP, record.UserAgent = meta.IP, meta.UserAgent
+	}
+
+	// Запись не должна теряться из-за отмены запроса после изменения
+	ctx = context.WithoutCancel(ctx)
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	record.PrevHash = s.auditHead
+	record.Hash = record.ComputeHash()
+	if err := s.auditSink.Record(ctx, record); err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "auth: запись аудита не сохранена",
+			slog.String("action", string(action)), slog.Int64("user_id", targetID),
+			slog.String("error", err.Error()))
+		return
+	}
+	s.auditHead = record.Hash
+}
+
+// auditActor - ID того, кто действует по claims: при имперсонации -
+// администратор из act, иначе владелец токена.
+func auditActor(claims *JWTClaims) int64 {
+	if claims.Actor != nil {
+		if id, err := strconv.ParseInt(claims.Actor.Subject, 10, 64); err == nil {
+			return id
+		}
+	}
+	return claims.UserID
+}
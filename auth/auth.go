@@ -2,91 +2,1057 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthService - главный сервис аутентификации.
+// Безопасен для конкурентного использования, включая изменение
+// параметров на лету (SetTokenTTL, SetBcryptCost, SetPasswordPolicy).
 type AuthService struct {
-	storage   Storage
-	secretKey []byte
-	tokenTTL  time.Duration
+	storage       Storage
+	signingMethod jwt.SigningMethod
+	signKey       interface{}       // []byte для HS256, *rsa.PrivateKey для RS256
+	verifyKey     interface{}       // []byte для HS256, *rsa.PublicKey для RS256
+	signer        Signer            // опционально, подпись вне процесса (KMS)
+	sigVerifier   SignatureVerifier // опционально, проверка вне процесса
+	tokenTTL      time.Duration
+	maxTokenTTL   time.Duration // предел TokenOptions.TTL
+	ttlResolver   TTLResolver   // опционально, срок жизни по пользователю
+	bcryptCost    int
+	hasher        PasswordHasher // nil - bcrypt с bcryptCost
+	longPasswords LongPasswordMode
+	verifyHashers []hasherEntry // WithVerifyHasher
+
+	pepper            []byte // WithPepper
+	previousPepper    []byte // WithPreviousPepper; nil - хэши без перца
+	hasPreviousPepper bool
+	dummyHash         string     // для выравнивания времени входа (compareDummyHash)
+	dummyMu           sync.Mutex // защищает dummyHash
+
+	// mu защищает параметры, меняемые на лету (SetTokenTTL и т.д.)
+	mu        sync.RWMutex
+	blacklist TokenBlacklist // опционально
+	// blacklistFailureMode - реакция на ошибку blacklist (WithBlacklistFailureMode)
+	blacklistFailureMode BlacklistFailureMode
+	refresh              RefreshStore // опционально
+	refreshTTL           time.Duration
+	// refreshCacheTTL - срок записей локального кэша RefreshStore
+	// (WithRefreshCache); ноль - без кэша.
+	refreshCacheTTL time.Duration
+	// refreshBinding - привязка refresh-токенов к клиенту (WithRefreshBinding)
+	refreshBinding RefreshBindingMode
+	// refreshCookie - параметры cookie refresh-токена (WithRefreshCookie);
+	// nil - токен передается в теле ответа.
+	refreshCookie *RefreshCookie
+	clock         Clock
+
+	refreshThreshold time.Duration // ValidateAndRefreshIfNeeded
+
+	sessions      SessionStore // опционально
+	maxSessions   int
+	singleSession bool // вход завершает прежние сессии (WithSingleSession)
+	strictLogout  bool // WithStrictLogout
+
+	passwordMaxAge     time.Duration // WithPasswordMaxAge; ноль - без срока
+	passwordExpiryMode PasswordExpiryMode
+
+	tokenVersioning bool // проверять версию токенов пользователя
+	storageTimeout  time.Duration
+
+	userStatus UserStatusResolver // опционально
+
+	dpop         bool // WithDPoP
+	dpopProofAge time.Duration
+
+	subjectMode SubjectMode
+	ipBinding   bool
+
+	cookieName      string // пусто - токен читается только из заголовка
+	queryTokenParam string // пусто - токен из URL не принимается
+	csrfHeader      string // пусто - CSRF-заголовок не требуется
+	csrfKey         []byte // ключ IssueCSRFToken
+
+	emailNormalizer EmailNormalizer // nil - email передается в Storage как есть
+	errorFormatter  ErrorFormatter  // nil - тексты ошибок пакета как есть
+
+	metrics Metrics
+	logger  *slog.Logger
+	// redactedClaims - ключи claims, скрываемые в журнале (WithRedactedClaims)
+	redactedClaims []string
+
+	revokeRefreshOnPasswordChange bool
+	rehashOnLogin                 bool
+	legacyVerifier                LegacyHashVerifier // опционально
+
+	attempts          LoginAttemptStore // опционально
+	maxFailedAttempts int
+	attemptWindow     time.Duration
+	lockoutDuration   time.Duration
+	backoffBase       time.Duration // 0 - без задержек между попытками
+	backoffMax        time.Duration
+
+	patchablePrivileges map[string]bool // WithPatchablePrivileges
+
+	rateLimiter  RateLimiter // опционально
+	rateLimitKey RateLimitKeyFunc
+
+	enricher ClaimsEnricher // опционально
+	audience []string       // пусто - проверка aud отключена
+	issuer   string         // пусто - проверка iss отключена
+	leeway   time.Duration  // допуск рассинхронизации часов для exp/nbf
+
+	keyID string                 // kid активного ключа подписи
+	keys  map[string]interface{} // kid -> ключ проверки (WithKeySet, WithPublicKey)
+	jwks  *jwksCache             // ключи из JWKS издателя (NewVerifierFromJWKS)
+
+	allowedMethods []string               // дополнительные алгоритмы (WithAllowedMethods)
+	methodKeys     map[string]interface{} // alg -> ключ проверки дополнительного алгоритма
+
+	secretProvider SecretProvider // опционально, вместо статического HMAC-секрета
+	previousSecret []byte         // прежний HMAC-секрет (WithPreviousSecret)
+	previousUntil  time.Time
+
+	tokenHeaders map[string]interface{} // дополнительные заголовки JWT (WithTokenHeaders)
+	tokenEncoder TokenEncoder           // nil - компактный токен как есть
+	tokenStore   TokenStore             // опционально, непрозрачные токены
+	idGenerator  IDGenerator            // jti токенов, nil - случайный
+	randReader   io.Reader              // источник случайности (WithRandReader)
+
+	tokenSizeBudget int // предельный размер выпускаемого JWT, 0 - без ограничения
+	maxTokenBytes   int // предельный размер проверяемого токена
+
+	tenantResolver TenantKeyResolver // опционально, ключи арендаторов
+	riskEvaluator  RiskEvaluator     // опционально, пошаговая 2FA
+	totpIssuerName string            // название сервиса в otpauth:// (EnrollTOTP)
+
+	// trustedIssuers - ключи сторонних издателей по iss (WithTrustedIssuers)
+	trustedIssuerConfigs map[string]IssuerConfig
+	trustedIssuers       map[string]trustedIssuer
+
+	webauthnConfig *webauthn.Config // опционально, вход по passkey
+	webauthnStore  WebAuthnStore
+	webauthn       *webauthn.WebAuthn // создается из webauthnConfig
+
+	claimsValidator ClaimsValidator // опционально
+	expiredFastPath bool            // отклонять истекшие токены до проверки подписи
+	rejectFutureIAT bool            // отклонять токены с iat в будущем
+	parser          *jwt.Parser     // собирается в конструкторе (newParser)
+	validator       *jwt.Validator  // те же проверки полей для SignatureVerifier
+	legacyTokenType bool            // принимать токены без typ (WithLegacyTokenType)
+
+	passwordPolicy PasswordPolicy
+
+	passwordHistory      PasswordHistoryStore // опционально
+	passwordHistoryDepth int
+
+	breachChecker          BreachChecker // опционально
+	breachCheckFailureMode BreachCheckFailureMode
+
+	verificationTTL  time.Duration
+	passwordResetTTL time.Duration
+	deviceTrustTTL   time.Duration
+	magicLinkTTL     time.Duration
+	requireVerified  bool
+	// emailVerifiedClaim - записывать email_verified (WithEmailVerifiedClaim)
+	emailVerifiedClaim bool
+
+	impersonationRole string
+	impersonationTTL  time.Duration
+
+	// activeTokens - учет jti для WithMaxActiveTokensPerUser (опционально)
+	activeTokens    ActiveTokenStore
+	maxActiveTokens int
+	tokenLimitMode  TokenLimitMode
+
+	// exchangeServiceID - act.sub токенов ExchangeToken (WithTokenExchange)
+	exchangeServiceID string
+	exchangeTTL       time.Duration
+
+	eventHook EventHook      // опционально
+	hooks     sync.WaitGroup // незавершенные вызовы eventHook
+
+	auditSink AuditSink  // опционально
+	auditMu   sync.Mutex // упорядочивает записи цепочки аудита
+	auditHead string     // Hash последней сохраненной записи
+}
+
+// minSecretLength - минимальная длина HMAC-секрета: стойкость HS256
+// определяется длиной ключа (не короче выхода SHA-256).
+const minSecretLength = 32
+
+// Сроки жизни по умолчанию.
+const (
+	defaultRefreshTTL       = 30 * 24 * time.Hour
+	defaultRefreshThreshold = 5 * time.Minute
+	defaultVerificationTTL  = 24 * time.Hour
+	defaultPasswordResetTTL = time.Hour
+	defaultDeviceTrustTTL   = 30 * 24 * time.Hour
+	defaultMagicLinkTTL     = 15 * time.Minute
+	defaultImpersonationTTL = 15 * time.Minute
+)
+
+// NewAuthService создает новый экземпляр AuthService с подписью HS256.
+// secretKey должен быть не короче 32 байт (иначе ErrWeakSecret); надежный
+// ключ дают GenerateSecretKey и GenerateSecretKeyBase64.
+// Дополнительные параметры задаются через опции (WithBcryptCost и т.д.).
+func NewAuthService(storage Storage, secretKey []byte, ttl time.Duration, opts ...Option) (*AuthService, error) {
+	return newAuthService(storage, jwt.SigningMethodHS256, secretKey, secretKey, ttl, opts)
 }
 
-// NewAuthService создает новый экземпляр AuthService.
-func NewAuthService(storage Storage, secretKey []byte, ttl time.Duration) *AuthService {
-	return &AuthService{
-		storage:   storage,
-		secretKey: secretKey,
-		tokenTTL:  ttl,
+// MustNewAuthService - NewAuthService, паникующий при ошибке конфигурации.
+// Удобен для инициализации при старте приложения.
+func MustNewAuthService(storage Storage, secretKey []byte, ttl time.Duration, opts ...Option) *AuthService {
+	s, err := NewAuthService(storage, secretKey, ttl, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewAuthServiceRS256 создает AuthService с асимметричной подписью RS256.
+// Сервисам, которые только проверяют токены, достаточно publicKey:
+// privateKey можно передать nil, тогда выпуск токенов будет недоступен.
+// Если publicKey равен nil, он берется из privateKey.
+func NewAuthServiceRS256(storage Storage, privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, ttl time.Duration, opts ...Option) (*AuthService, error) {
+	if publicKey == nil && privateKey != nil {
+		publicKey = &privateKey.PublicKey
+	}
+	if publicKey == nil {
+		return nil, errors.New("не задан публичный ключ RSA")
+	}
+
+	var signKey interface{}
+	if privateKey != nil {
+		signKey = privateKey
+	}
+	return newAuthService(storage, jwt.SigningMethodRS256, signKey, publicKey, ttl, opts)
+}
+
+// checkSecretLength отклоняет слишком короткий статический HMAC-секрет.
+func (s *AuthService) checkSecretLength() error {
+	if _, isHMAC := s.signingMethod.(*jwt.SigningMethodHMAC); !isHMAC || s.secretProvider != nil {
+		return nil
+	}
+	// Секрет целиком во внешнем хранилище (KMS)
+	if s.signer != nil && s.sigVerifier != nil {
+		return nil
+	}
+	key, _ := s.verifyKey.([]byte)
+	if len(key) < minSecretLength {
+		return fmt.Errorf("%w: нужно не меньше %d байт, передано %d (создайте ключ через GenerateSecretKey)",
+			ErrWeakSecret, minSecretLength, len(key))
+	}
+	return nil
+}
+
+func newAuthService(storage Storage, method jwt.SigningMethod, signKey, verifyKey interface{}, ttl time.Duration, opts []Option) (*AuthService, error) {
+	s := &AuthService{
+		storage:          storage,
+		signingMethod:    method,
+		signKey:          signKey,
+		verifyKey:        verifyKey,
+		tokenTTL:         ttl,
+		refreshTTL:       defaultRefreshTTL,
+		refreshThreshold: defaultRefreshThreshold,
+		clock:            realClock{},
+		metrics:          noopMetrics{},
+		logger:           slog.New(slog.DiscardHandler),
+		emailNormalizer:  NormalizeEmail,
+		randReader:       rand.Reader,
+		maxTokenBytes:    defaultMaxTokenBytes,
+
+		maxFailedAttempts: defaultMaxFailedAttempts,
+		attemptWindow:     defaultAttemptWindow,
+		lockoutDuration:   defaultLockoutDuration,
+		passwordPolicy:    DefaultPasswordPolicy,
+		verificationTTL:   defaultVerificationTTL,
+		passwordResetTTL:  defaultPasswordResetTTL,
+		deviceTrustTTL:    defaultDeviceTrustTTL,
+		magicLinkTTL:      defaultMagicLinkTTL,
+		impersonationRole: defaultImpersonationRole,
+		impersonationTTL:  defaultImpersonationTTL,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.signingMethod == nil || s.signingMethod.Alg() == jwt.SigningMethodNone.Alg() {
+		return nil, errors.New("недопустимый алгоритм подписи: none")
+	}
+
+	if s.metrics == nil {
+		s.metrics = noopMetrics{}
+	}
+	if s.logger == nil {
+		s.logger = slog.New(slog.DiscardHandler)
+	}
+	if s.blacklistFailureMode == BlacklistFailOpen && s.blacklist != nil {
+		s.logger.Warn("auth: включен BlacklistFailOpen - при сбое черного списка отозванные токены принимаются")
+	}
+
+	if s.secretProvider != nil {
+		if _, isHMAC := s.signingMethod.(*jwt.SigningMethodHMAC); !isHMAC {
+			return nil, errors.New("SecretProvider поддерживается только для HMAC")
+		}
+	}
+
+	if s.previousSecret != nil {
+		if _, isHMAC := s.signingMethod.(*jwt.SigningMethodHMAC); !isHMAC {
+			return nil, errors.New("WithPreviousSecret поддерживается только для HMAC")
+		}
+		// Прежний секрет проверяет подписи до until - требования те же
+		if len(s.previousSecret) < minSecretLength {
+			return nil, fmt.Errorf("%w: WithPreviousSecret: нужно не меньше %d байт, передано %d",
+				ErrWeakSecret, minSecretLength, len(s.previousSecret))
+		}
+	}
+
+	if err := s.initAllowedMethods(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkExternalSigning(); err != nil {
+		return nil, err
+	}
+
+	if err := s.initTrustedIssuers(s.trustedIssuerConfigs); err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.tokenHeaders["alg"]; ok {
+		return nil, errors.New("заголовок alg задается алгоритмом подписи и не может быть переопределен")
+	}
+
+	if s.keyID != "" {
+		if _, isHMAC := s.signingMethod.(*jwt.SigningMethodHMAC); isHMAC {
+			key, ok := s.keys[s.keyID]
+			if !ok {
+				return nil, fmt.Errorf("активный ключ %q отсутствует в наборе ключей", s.keyID)
+			}
+			s.signKey, s.verifyKey = key, key
+		} else {
+			// Асимметричный ключ: активный публичный ключ тоже ищется по kid
+			if s.keys == nil {
+				s.keys = make(map[string]interface{})
+			}
+			s.keys[s.keyID] = s.verifyKey
+		}
+	}
+
+	if err := s.checkSecretLength(); err != nil {
+		return nil, err
+	}
+
+	if err := s.initCSRFKey(); err != nil {
+		return nil, err
 	}
+	if s.refreshCookie != nil {
+		cookie := s.refreshCookie.withDefaults(s.refreshTTL)
+		s.refreshCookie = &cookie
+	}
+
+	if s.tokenVersioning {
+		if _, ok := s.storage.(TokenVersionStorage); !ok {
+			return nil, fmt.Errorf("%w: Storage не реализует TokenVersionStorage", ErrTokenVersioningUnsupported)
+		}
+	}
+
+	if s.singleSession && !s.tokenVersioning && s.sessions == nil {
+		return nil, fmt.Errorf("%w: WithSingleSession требует WithTokenVersioning или WithSessionStore", ErrLogoutAllUnsupported)
+	}
+
+	if s.activeTokens != nil && s.tokenLimitMode == TokenLimitEvictOldest && s.blacklist == nil {
+		return nil, fmt.Errorf("%w: TokenLimitEvictOldest требует WithTokenBlacklist", ErrBlacklistUnsupported)
+	}
+
+	if s.webauthnConfig != nil {
+		if s.webauthnStore == nil {
+			return nil, errors.New("WithWebAuthn: не задано хранилище WebAuthnStore")
+		}
+		w, err := webauthn.New(s.webauthnConfig)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная конфигурация WebAuthn: %w", err)
+		}
+		s.webauthn = w
+	}
+
+	// Оборачивается после проверок необязательных интерфейсов Storage
+	if s.storageTimeout > 0 {
+		s.storage = &timeoutStorage{inner: s.storage, timeout: s.storageTimeout}
+	}
+
+	if s.refreshCacheTTL > 0 && s.refresh != nil {
+		s.refresh = newCachedRefreshStore(s.refresh, s.refreshCacheTTL, s.clock)
+	}
+
+	if s.bcryptCost == 0 {
+		s.bcryptCost = bcrypt.DefaultCost
+	}
+	if s.bcryptCost < bcrypt.MinCost || s.bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("стоимость bcrypt должна быть в диапазоне [%d, %d]: %d",
+			bcrypt.MinCost, bcrypt.MaxCost, s.bcryptCost)
+	}
+
+	s.parser = s.newParser()
+	s.validator = jwt.NewValidator(s.parserOptions()...)
+	return s, nil
 }
 
 // Login (Логин)
 // Проверяет учетные данные и генерирует JWT-токен.
+// Отмена ctx прерывает вход до bcrypt и до подписи токена.
 func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+	return s.LoginWithOptions(ctx, email, password, TokenOptions{})
+}
+
+// LoginWithExpiry (Логин со сроком действия)
+// Как Login, но дополнительно возвращает время истечения токена,
+// вычисленное по тем же часам, что и при подписи.
+func (s *AuthService) LoginWithExpiry(ctx context.Context, email, password string) (string, time.Time, error) {
+	user, err := s.authenticate(ctx, email, password, secondFactor{})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return s.mintAccessToken(ctx, user, TokenOptions{authTime: s.clock.Now()})
+}
+
+// TokenOptions - параметры выпускаемого access-токена.
+// Нулевые значения означают поведение по умолчанию.
+type TokenOptions struct {
+	NotBefore time.Time // токен начинает действовать с этого момента (nbf)
+	ExpiresAt time.Time // переопределяет now + TTL
+	// TTL - срок жизни токена для этого входа (например, "запомнить меня").
+	// Ограничивается сверху WithMaxTokenTTL.
+	TTL time.Duration
+	// DPoPThumbprint - отпечаток JWK клиента (RFC 7638, см. JWK.Thumbprint):
+	// токен привязывается к ключу (cnf.jkt), и ValidateRequest требует
+	// DPoP-доказательство, подписанное этим ключом (см. WithDPoP).
+	DPoPThumbprint string
+
+	sessionID string    // существующая сессия (при обновлении токена)
+	actor     *Actor    // администратор при имперсонации
+	tokenID   string    // сохраняемый jti (ReissueToken)
+	issuedAt  time.Time // сохраняемый iat (ReissueToken)
+	authTime  time.Time // время входа (auth_time); пусто - не записывается
+	audience  []string  // aud вместо WithAudience (LoginMultiAudience)
+}
+
+// LoginWithOptions (Логин с параметрами токена)
+// Как Login, но позволяет задать отложенную активацию (NotBefore)
+// и собственный срок действия токена.
+func (s *AuthService) LoginWithOptions(ctx context.Context, email, password string, opts TokenOptions) (string, error) {
+	user, err := s.authenticate(ctx, email, password, secondFactor{})
+	if err != nil {
+		return "", err
+	}
+
+	opts.authTime = s.clock.Now()
+	return s.issueAccessToken(ctx, user, opts)
+}
+
+// authenticate проверяет email, пароль и (если у пользователя включена 2FA)
+// второй фактор, и возвращает пользователя. Email предварительно нормализуется
+// (WithEmailNormalizer), в т.ч. для лимитов и блокировки.
+// Заблокированный аккаунт отклоняется даже при верном пароле.
+func (s *AuthService) authenticate(ctx context.Context, email, password string, factor secondFactor) (UserIn, error) {
+	email = s.normalizeEmail(email)
+	user, err := s.verifyCredentials(ctx, email, password, factor)
+	if err != nil {
+		s.metrics.IncLoginFailure(loginFailureReason(err))
+		s.emitLoginFailure(ctx, email, err)
+		return nil, err
+	}
+
+	s.metrics.IncLoginSuccess()
+	s.emit(ctx, Event{Type: EventLoginSuccess, UserID: user.GetID(), Email: user.GetEmail()})
+	return user, nil
+}
+
+// verifyCredentials выполняет проверки authenticate без отправки событий.
+func (s *AuthService) verifyCredentials(ctx context.Context, email, password string, factor secondFactor) (UserIn, error) {
+	if err := s.checkRateLimit(ctx, email); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, err
+	}
+
 	user, err := s.storage.GetUserByEmail(ctx, email)
+	if errors.Is(err, ErrStorageTimeout) {
+		return nil, err
+	}
 	if err != nil {
+		// Сравнение с фиктивным хэшем выравнивает время ответа:
+		// по нему нельзя отличить несуществующий email от неверного пароля
+		s.compareDummyHash(password)
 		// Обычно возвращают универсальную ошибку для безопасности
-		return "", errors.New("неверные учетные данные")
+		return nil, s.failLogin(ctx, email)
 	}
 
-	// Сравнение хэша пароля
-	err = bcrypt.CompareHashAndPassword([]byte(user.GetPasswordHash()), []byte(password))
-	if err != nil {
-		return "", errors.New("неверные учетные данные")
+	// bcrypt дорогой: не тратим CPU, если клиент уже ушел
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Сравнение хэша пароля (bcrypt или устаревший формат)
+	if !s.checkPassword(ctx, user, password) {
+		return nil, s.failLogin(ctx, email)
+	}
+
+	if err := s.checkVerified(user); err != nil {
+		return nil, err
+	}
+
+	// Второй фактор; счетчик неудач сбрасывается только после него
+	if err := s.checkMFA(ctx, user, email, factor); err != nil {
+		return nil, err
+	}
+
+	if err := s.resetLoginFailures(ctx, email); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPasswordExpiry(ctx, user); err != nil {
+		return nil, err
 	}
 
-	// Генерация JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
-		UserID: user.GetID(),
-		Email:  user.GetEmail(),
+	s.rehashIfNeeded(ctx, user, password)
+
+	return s.endPreviousSessions(ctx, user)
+}
+
+// compareDummyHash выполняет сравнение с фиктивным хэшем текущего алгоритма
+// и параметров, чтобы время ответа не зависело от наличия пользователя.
+func (s *AuthService) compareDummyHash(password string) {
+	hasher := s.passwordHasher()
+
+	s.dummyMu.Lock()
+	if s.dummyHash == "" || hasher.NeedsRehash(s.dummyHash) {
+		s.dummyHash, _ = hasher.Hash("dummy-password")
+	}
+	hash := s.dummyHash
+	s.dummyMu.Unlock()
+
+	defer s.observeHash(time.Now())
+	_ = hasher.Compare(s.pepperedInput(password), hash)
+	if s.hasPreviousPepper {
+		// Неверный пароль существующего пользователя проверяется дважды
+		_ = hasher.Compare(password, hash)
+	}
+}
+
+// failLogin учитывает неудачную попытку и возвращает ErrInvalidCredentials.
+func (s *AuthService) failLogin(ctx context.Context, email string) error {
+	if err := s.recordLoginFailure(ctx, email); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// issueAccessToken генерирует и подписывает access-токен для пользователя.
+func (s *AuthService) issueAccessToken(ctx context.Context, user UserIn, opts TokenOptions) (string, error) {
+	token, _, err := s.mintAccessToken(ctx, user, opts)
+	return token, err
+}
+
+// mintAccessToken - issueAccessToken, дополнительно возвращающий срок действия токена.
+// Если подключен SessionStore и сессия не передана, начинается новая сессия.
+func (s *AuthService) mintAccessToken(ctx context.Context, user UserIn, opts TokenOptions) (string, time.Time, error) {
+	if opts.sessionID == "" {
+		sid, err := s.startSession(ctx, user.GetID())
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		opts.sessionID = sid
+	}
+
+	// Уникальный идентификатор токена нужен для черного списка
+	jti := opts.tokenID
+	if jti == "" {
+		var err error
+		if jti, err = s.newTokenID(); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	ttl := opts.TTL
+	if ttl <= 0 && s.ttlResolver != nil {
+		ttl = s.ttlResolver(user)
+	}
+
+	now := s.clock.Now()
+	issuedAt := now
+	if !opts.issuedAt.IsZero() {
+		issuedAt = opts.issuedAt
+	}
+	expiresAt := now.Add(s.clampTTL(ttl))
+	if !opts.ExpiresAt.IsZero() {
+		expiresAt = opts.ExpiresAt
+	}
+	if !expiresAt.After(now) || (!opts.NotBefore.IsZero() && !expiresAt.After(opts.NotBefore)) {
+		return "", time.Time{}, ErrInvalidTokenOptions
+	}
+
+	claims := JWTClaims{
+		UserID:    user.GetID(),
+		Email:     user.GetEmail(),
+		SessionID: opts.sessionID,
+		Actor:     opts.actor,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenTTL)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 		},
-	})
+	}
+	if !opts.NotBefore.IsZero() {
+		claims.NotBefore = jwt.NewNumericDate(opts.NotBefore)
+	}
+	if !opts.authTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(opts.authTime)
+	}
+	if opts.DPoPThumbprint != "" {
+		claims.Confirmation = &Confirmation{JKT: opts.DPoPThumbprint}
+	}
+	if len(opts.audience) > 0 {
+		claims.Audience = append(jwt.ClaimStrings(nil), opts.audience...)
+	} else if len(s.audience) > 0 {
+		claims.Audience = append(jwt.ClaimStrings(nil), s.audience...)
+	}
+	claims.Issuer = s.issuer
+	if s.subjectMode != SubjectOmit {
+		claims.Subject = strconv.FormatInt(user.GetID(), 10)
+		if s.subjectMode == SubjectOnly {
+			claims.UserID = 0
+		}
+	}
+	if rp, ok := user.(RoleProvider); ok {
+		claims.Roles = rp.GetRoles()
+	}
+	s.bindIP(ctx, &claims)
+	if tenantID, ok := TenantFromContext(ctx); ok && s.tenantResolver != nil {
+		claims.TenantID = tenantID
+	}
+	if sp, ok := user.(ScopeProvider); ok {
+		claims.Scopes = sp.GetScopes()
+	}
+	if vp, ok := user.(TokenVersionProvider); ok {
+		claims.TokenVersion = vp.GetTokenVersion()
+	}
+	if vp, ok := user.(VerificationProvider); ok && s.emailVerifiedClaim {
+		claims.EmailVerified = vp.IsVerified()
+	}
+	claims.PasswordExpired = s.passwordExpired(user)
+	if s.enricher != nil {
+		extra, err := s.enricher(ctx, user)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		claims.Extra = extra
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", time.Time{}, err
+	}
 
-	tokenString, err := token.SignedString(s.secretKey)
+	// jti перевыпуска (ReissueToken) уже учтен
+	if opts.tokenID == "" {
+		if err := s.reserveTokenSlot(ctx, user.GetID()); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	var tokenString string
+	var err error
+	if s.tokenStore != nil {
+		// Непрозрачный токен: claims остаются на сервере
+		tokenString, err = s.issueOpaqueToken(ctx, &claims)
+	} else {
+		// Генерация JWT
+		tokenString, err = s.sign(ctx, claims)
+	}
 	if err != nil {
-		return "", errors.New("ошибка подписи токена")
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	if opts.tokenID == "" {
+		if err := s.trackToken(ctx, user.GetID(), jti, issuedAt, expiresAt); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	if tokenString, err = s.encodeToken(tokenString, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// clampTTL возвращает срок жизни токена: запрошенный, но не больше
+// maxTokenTTL (без WithMaxTokenTTL - не больше tokenTTL).
+func (s *AuthService) clampTTL(ttl time.Duration) time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if ttl <= 0 {
+		return s.tokenTTL
+	}
+	limit := s.maxTokenTTL
+	if limit == 0 {
+		limit = s.tokenTTL
+	}
+	if ttl > limit {
+		return limit
+	}
+	return ttl
 }
 
 // ParseAndValidateToken (Проверка JWT)
 // Парсит токен, проверяет подпись и срок действия.
 func (s *AuthService) ParseAndValidateToken(tokenString string) (*JWTClaims, error) {
-	claims := &JWTClaims{}
+	return s.ParseAndValidateTokenContext(context.Background(), tokenString)
+}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Убеждаемся, что используется ожидаемый алгоритм
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("неожиданный метод подписи")
-		}
-		return s.secretKey, nil
-	})
+// ParseAndValidateTokenContext - то же, что ParseAndValidateToken,
+// но с контекстом для обращений к черному списку.
+// Причину отказа в виде константы можно получить через ValidateDetailed.
+func (s *AuthService) ParseAndValidateTokenContext(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claims, result := s.ValidateDetailedContext(ctx, tokenString)
+	return claims, result.Err
+}
+
+// ValidationResult - итог проверки токена (ValidateDetailed).
+type ValidationResult struct {
+	// Reason - причина отказа (константа Reason*, та же метка, что
+	// в Metrics.IncValidationFailure); пусто для действительного токена.
+	Reason string
+	// Err - ошибка, которую вернул бы ParseAndValidateToken.
+	Err error
+}
+
+// Valid сообщает, что токен прошел проверку.
+func (r ValidationResult) Valid() bool {
+	return r.Err == nil
+}
+
+// ValidateDetailed (Проверка с причиной отказа)
+// Проверяет токен так же, как ParseAndValidateToken, но вместо одной
+// ошибки возвращает причину отказа (истек, неверная подпись, отозван и т.д.)
+// для журналов и дашбордов. claims возвращаются только для действительного токена.
+func (s *AuthService) ValidateDetailed(tokenString string) (*JWTClaims, ValidationResult) {
+	return s.ValidateDetailedContext(context.Background(), tokenString)
+}
+
+// ValidateDetailedContext - то же, что ValidateDetailed, с контекстом.
+func (s *AuthService) ValidateDetailedContext(ctx context.Context, tokenString string) (*JWTClaims, ValidationResult) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, ValidationResult{Reason: validationReason(err), Err: err}
+	}
+	claims, err := s.validate(ctx, tokenString)
+	if err != nil {
+		return nil, ValidationResult{Reason: validationReason(err), Err: err}
+	}
+	return claims, ValidationResult{}
+}
+
+// validate выполняет полную проверку токена. При ошибке claims тоже
+// возвращаются, если подпись верна и отказ вызван только проверкой полей
+// (истек срок, отозван и т.д.), иначе - nil.
+func (s *AuthService) validate(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claims, err := s.validateWith(ctx, s.parser, tokenString)
+	s.observeValidation(err)
+	s.logValidation(ctx, claims, err)
+	return claims, err
+}
+
+// validateWith - validate с заранее собранным парсером.
+func (s *AuthService) validateWith(ctx context.Context, parser *jwt.Parser, tokenString string) (*JWTClaims, error) {
+	if err := s.checkTokenSize(tokenString); err != nil {
+		return nil, err
+	}
 
+	var claims *JWTClaims
+	var err error
+	if s.isOpaqueToken(tokenString) {
+		claims, err = s.lookupOpaqueToken(ctx, tokenString)
+	} else {
+		claims, err = s.parseJWT(ctx, parser, tokenString)
+	}
 	if err != nil {
+		return claims, err
+	}
+
+	// Служебные токены (подтверждение email и т.п.) не являются access-токенами
+	if isPurposeToken(claims) {
+		return nil, ErrWrongTokenPurpose
+	}
+
+	// Проверка черного списка
+	if err := s.checkBlacklist(ctx, claims, tokenString); err != nil {
+		if errors.Is(err, ErrTokenRevoked) {
+			return claims, err
+		}
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, errors.New("токен недействителен")
+	if err := s.checkSession(ctx, claims); err != nil {
+		return claims, err
+	}
+
+	if err := s.checkTokenVersion(ctx, claims); err != nil {
+		return claims, err
+	}
+
+	if err := s.checkUserStatus(ctx, claims); err != nil {
+		return claims, err
+	}
+
+	if err := s.checkIPBinding(ctx, claims); err != nil {
+		return claims, err
+	}
+
+	// Бизнес-правила вызывающего (WithClaimsValidator)
+	if s.claimsValidator != nil {
+		if err := s.claimsValidator(ctx, claims); err != nil {
+			return claims, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+		}
 	}
 
 	// Возвращаем полезную нагрузку
 	return claims, nil
 }
 
+// parseJWT разбирает JWT и проверяет подпись и стандартные поля.
+// При ошибке проверки полей claims возвращаются вместе с ошибкой.
+func (s *AuthService) parseJWT(ctx context.Context, parser *jwt.Parser, tokenString string) (*JWTClaims, error) {
+	if s.expiredFastPath && s.unverifiedExpired(tokenString) {
+		// Подпись не проверена: claims такого токена не возвращаются
+		return nil, ErrTokenExpired
+	}
+	claims := &JWTClaims{}
+
+	token, err := s.parseToken(ctx, parser, s.validator, tokenString, claims)
+	backfillUserID(claims)
+
+	if err != nil {
+		// Ошибки проверки полей возникают только после проверки подписи
+		if errors.Is(err, jwt.ErrTokenInvalidClaims) {
+			return claims, mapParseError(err)
+		}
+		return nil, mapParseError(err)
+	}
+
+	if !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+	if err := s.checkTokenType(token, s.accessTokenType()); err != nil {
+		return nil, err
+	}
+	if err := s.checkTrustedAudience(claims); err != nil {
+		return claims, err
+	}
+	return claims, nil
+}
+
 // Logout (Логаут)
 // В stateless JWT логаут означает удаление токена клиентом.
-// Этот метод можно оставить для будущего функционала (например, черный список токенов).
+// Если подключен черный список (WithTokenBlacklist), jti токена
+// (у токена без jti - TokenFingerprint) добавляется в него до истечения
+// срока действия токена.
+// Непрозрачный токен (WithOpaqueTokens) удаляется из TokenStore.
+// С WithStrictLogout без черного списка завершается сессия токена,
+// а если отозвать токен нечем - возвращается ErrLogoutUnsupported.
 func (s *AuthService) Logout(ctx context.Context, tokenString string) error {
-	// В stateless JWT это NO-OP (не требует действий)
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return err
+	}
+	opaque := s.isOpaqueToken(tokenString)
+
+	if s.blacklist == nil && !opaque && s.strictLogout {
+		return s.logoutSession(ctx, tokenString)
+	}
+
+	// Без черного списка это NO-OP (не требует действий)
+	if s.blacklist == nil && !opaque {
+		if claims, err := s.validate(ctx, tokenString); err == nil {
+			s.emit(ctx, Event{Type: EventLogout, UserID: claims.UserID, Email: claims.Email})
+		}
+		return nil
+	}
+
+	claims, err := s.validate(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+	if opaque {
+		if err := s.revokeOpaqueToken(ctx, tokenString); err != nil {
+			return err
+		}
+		if err := s.untrackToken(ctx, claims); err != nil {
+			return err
+		}
+		s.emit(ctx, Event{Type: EventLogout, UserID: claims.UserID, Email: claims.Email})
+		return nil
+	}
+
+	var exp time.Time
+	if claims.ExpiresAt != nil {
+		exp = claims.ExpiresAt.Time
+	}
+	if err := s.blacklist.Add(ctx, blacklistKey(claims, tokenString), exp); err != nil {
+		return err
+	}
+	if err := s.untrackToken(ctx, claims); err != nil {
+		return err
+	}
+
+	s.emit(ctx, Event{Type: EventLogout, UserID: claims.UserID, Email: claims.Email})
 	return nil
 }
+
+// logoutSession - Logout без черного списка при WithStrictLogout:
+// токен отзывается удалением его сессии.
+func (s *AuthService) logoutSession(ctx context.Context, tokenString string) error {
+	claims, err := s.validate(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+	if s.sessions == nil {
+		return fmt.Errorf("%w: нужен WithTokenBlacklist или WithSessionStore", ErrLogoutUnsupported)
+	}
+	if claims.SessionID == "" {
+		return fmt.Errorf("%w: у токена нет сессии", ErrLogoutUnsupported)
+	}
+	if err := s.sessions.Delete(ctx, claims.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	if err := s.untrackToken(ctx, claims); err != nil {
+		return err
+	}
+
+	s.emit(ctx, Event{Type: EventLogout, UserID: claims.UserID, Email: claims.Email})
+	return nil
+}
+
+// initAllowedMethods проверяет, что у каждого разрешенного алгоритма есть ключ,
+// и оставляет в methodKeys только разрешенные алгоритмы.
+func (s *AuthService) initAllowedMethods() error {
+	keys := make(map[string]interface{}, len(s.allowedMethods))
+	for _, alg := range s.allowedMethods {
+		if alg == s.signingMethod.Alg() {
+			continue
+		}
+		if alg == jwt.SigningMethodNone.Alg() || jwt.GetSigningMethod(alg) == nil {
+			return fmt.Errorf("недопустимый алгоритм подписи: %q", alg)
+		}
+		key, ok := s.methodKeys[alg]
+		if !ok {
+			return fmt.Errorf("не задан ключ проверки для алгоритма %q (WithMethodKey)", alg)
+		}
+		keys[alg] = key
+	}
+	s.methodKeys = keys
+	return nil
+}
+
+// newParser собирает парсер jwt с параметрами проверки сервиса.
+// Вызывается один раз в конструкторе: параметры проверки не меняются
+// на лету, а *jwt.Parser безопасен для одновременного использования.
+func (s *AuthService) newParser() *jwt.Parser {
+	return jwt.NewParser(s.parserOptions()...)
+}
+
+// parserOptions собирает параметры проверки для библиотеки jwt.
+func (s *AuthService) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{jwt.WithTimeFunc(s.clock.Now)}
+	// С WithTrustedIssuers iss проверяет выбор ключа, а aud - checkTrustedAudience
+	if len(s.audience) > 0 && s.trustedIssuers == nil {
+		opts = append(opts, jwt.WithAudience(s.audience...))
+	}
+	if s.issuer != "" && s.trustedIssuers == nil {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(s.leeway))
+	}
+	if s.rejectFutureIAT {
+		opts = append(opts, jwt.WithIssuedAt())
+	}
+	return opts
+}
+
+// SubjectMode задает, куда записывается ID пользователя (WithSubjectClaim).
+type SubjectMode int
+
+const (
+	// SubjectOmit - только user_id (по умолчанию, как раньше).
+	SubjectOmit SubjectMode = iota
+	// SubjectAlso - user_id и стандартный sub.
+	SubjectAlso
+	// SubjectOnly - только sub; user_id не записывается.
+	SubjectOnly
+)
+
+// backfillUserID заполняет UserID из sub для токенов без user_id.
+func backfillUserID(claims *JWTClaims) {
+	if claims.UserID != 0 || claims.Subject == "" {
+		return
+	}
+	if id, err := strconv.ParseInt(claims.Subject, 10, 64); err == nil {
+		claims.UserID = id
+	}
+}
+
+// defaultMaxTokenBytes - предельный размер токена по умолчанию
+// (WithMaxTokenBytes). Токен приходит от клиента, поэтому огромные
+// сегменты отклоняются до base64- и JSON-разбора.
+const defaultMaxTokenBytes = 8 << 10
+
+// checkTokenSize отклоняет токен больше maxTokenBytes.
+func (s *AuthService) checkTokenSize(tokenString string) error {
+	if len(tokenString) > s.maxTokenBytes {
+		return fmt.Errorf("%w: %w: размер %d байт превышает %d",
+			ErrTokenInvalid, ErrTokenTooLarge, len(tokenString), s.maxTokenBytes)
+	}
+	return nil
+}
+
+// mapParseError переводит ошибки библиотеки jwt в ошибки пакета.
+func mapParseError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return ErrTokenNotYetValid
+	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenIssuedInFuture
+	case errors.Is(err, ErrUnexpectedSigningMethod):
+		return ErrUnexpectedSigningMethod
+	case errors.Is(err, ErrSigningKeyUnavailable):
+		return ErrSigningKeyUnavailable
+	case errors.Is(err, ErrUnknownKeyID):
+		return ErrUnknownKeyID
+	case errors.Is(err, ErrUnknownTenant):
+		return ErrUnknownTenant
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return ErrInvalidAudience
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer), errors.Is(err, ErrInvalidIssuer):
+		return ErrInvalidIssuer
+	default:
+		return fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+}
@@ -0,0 +1,256 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+	"go_auth_pkg/auth/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginTTLClampedToMax(t *testing.T) {
+	clock := newFakeClock()
+	svc, _, _ := newTestService(t, auth.WithClock(clock), auth.WithMaxTokenTTL(24*time.Hour))
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{"по умолчанию", 0, time.Hour},
+		{"запомнить меня", 7 * time.Hour, 7 * time.Hour},
+		{"сверх предела", 10 * 365 * 24 * time.Hour, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := svc.LoginWithOptions(context.Background(), testEmail, testPassword, auth.TokenOptions{TTL: tt.ttl})
+			if err != nil {
+				t.Fatalf("LoginWithOptions: %v", err)
+			}
+			claims, err := svc.ParseAndValidateToken(token)
+			if err != nil {
+				t.Fatalf("ParseAndValidateToken: %v", err)
+			}
+			if got := claims.ExpiresAt.Sub(clock.Now()); got != tt.want {
+				t.Fatalf("срок токена = %s, ожидался %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingHasher - auth.BcryptHasher, запоминающий хэши сравнений.
+type countingHasher struct {
+	auth.BcryptHasher
+
+	mu       sync.Mutex
+	hashed   []string
+	compared []string
+}
+
+func (h *countingHasher) Hash(password string) (string, error) {
+	hash, err := h.BcryptHasher.Hash(password)
+	h.mu.Lock()
+	h.hashed = append(h.hashed, hash)
+	h.mu.Unlock()
+	return hash, err
+}
+
+func (h *countingHasher) Compare(password, hash string) error {
+	h.mu.Lock()
+	h.compared = append(h.compared, hash)
+	h.mu.Unlock()
+	return h.BcryptHasher.Compare(password, hash)
+}
+
+func TestLoginUnknownEmailComparesDummyHash(t *testing.T) {
+	hasher := &countingHasher{BcryptHasher: auth.BcryptHasher{Cost: bcrypt.MinCost}}
+	svc, _, _ := newTestService(t, auth.WithPasswordHasher(hasher))
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Login(context.Background(), "nobody@example.com", testPassword)
+		if !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login: err = %v, ожидался ErrInvalidCredentials", err)
+		}
+	}
+
+	// Фиктивный хэш создается один раз и сравнивается при каждом входе
+	if len(hasher.hashed) != 1 {
+		t.Fatalf("фиктивный хэш создан %d раз, ожидался 1", len(hasher.hashed))
+	}
+	if len(hasher.compared) != 2 {
+		t.Fatalf("сравнений с хэшем: %d, ожидалось 2", len(hasher.compared))
+	}
+	for _, hash := range hasher.compared {
+		if hash != hasher.hashed[0] {
+			t.Fatalf("сравнение с %q вместо фиктивного хэша", hash)
+		}
+	}
+}
+
+func TestSecretKeyLength(t *testing.T) {
+	storage := memory.NewInMemoryStorage()
+	for _, key := range [][]byte{nil, []byte("short"), []byte(strings.Repeat("k", 31))} {
+		if _, err := auth.NewAuthService(storage, key, time.Hour); !errors.Is(err, auth.ErrWeakSecret) {
+			t.Fatalf("ключ %d байт: err = %v, ожидался ErrWeakSecret", len(key), err)
+		}
+	}
+	if _, err := auth.NewAuthService(storage, []byte(strings.Repeat("k", 32)), time.Hour); err != nil {
+		t.Fatalf("ключ 32 байта: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustNewAuthService не запаниковал на коротком ключе")
+		}
+	}()
+	auth.MustNewAuthService(storage, []byte(strings.Repeat("k", 31)), time.Hour)
+}
+
+func FuzzParseAndValidateToken(f *testing.F) {
+	svc, _, userID := newTestService(f)
+	valid := authtest.NewToken(auth.JWTClaims{UserID: userID, Email: testEmail}, testSecret)
+
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add(valid[:strings.LastIndex(valid, ".")+1])
+	f.Add(valid + valid)
+	f.Add(strings.Repeat("A", 1<<16) + "." + strings.Repeat("B", 1<<16) + ".c")
+	f.Add(authtest.NoneAlgToken(auth.JWTClaims{UserID: userID}))
+	f.Add("")
+	f.Add("..")
+	f.Add("eyJ.eyJ.")
+	f.Add(`{"token":"` + valid + `"}`)
+
+	f.Fuzz(func(t *testing.T, token string) {
+		claims, err := svc.ParseAndValidateToken(token)
+		if claims == nil && err == nil {
+			t.Fatal("nil claims без ошибки")
+		}
+		if claims != nil && err != nil {
+			t.Fatalf("claims вместе с ошибкой %v", err)
+		}
+	})
+}
+
+func TestRejectFutureIssuedAtBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		reject  bool
+		leeway  time.Duration
+		iat     time.Duration // iat относительно часов сервиса
+		wantErr error
+	}{
+		{"выключено", false, 0, time.Hour, nil},
+		{"сейчас", true, 0, 0, nil},
+		{"на секунду вперед", true, 0, time.Second, auth.ErrTokenIssuedInFuture},
+		{"в прошлом", true, 0, -time.Minute, nil},
+		{"на границе допуска", true, 30 * time.Second, 30 * time.Second, nil},
+		{"за границей допуска", true, 30 * time.Second, 31 * time.Second, auth.ErrTokenIssuedInFuture},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			svc, _, userID := newTestService(t, auth.WithClock(clock),
+				auth.WithRejectFutureIssuedAt(tt.reject), auth.WithLeeway(tt.leeway))
+
+			now := clock.Now()
+			token := authtest.NewToken(auth.JWTClaims{
+				UserID: userID,
+				Email:  testEmail,
+				RegisteredClaims: jwt.RegisteredClaims{
+					IssuedAt:  jwt.NewNumericDate(now.Add(tt.iat)),
+					ExpiresAt: jwt.NewNumericDate(now.Add(2 * time.Hour)),
+				},
+			}, testSecret)
+
+			_, err := svc.ParseAndValidateToken(token)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ParseAndValidateToken: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ожидался %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOversizedTokenRejected(t *testing.T) {
+	svc, _, userID := newTestService(t)
+	valid := authtest.NewToken(auth.JWTClaims{UserID: userID, Email: testEmail}, testSecret)
+
+	// Размер по умолчанию - 8192 байт
+	oversized := valid + strings.Repeat("A", 8192)
+	if _, err := svc.ParseAndValidateToken(oversized); !errors.Is(err, auth.ErrTokenTooLarge) || !errors.Is(err, auth.ErrTokenInvalid) {
+		t.Fatalf("токен %d байт: err = %v, ожидался ErrTokenTooLarge", len(oversized), err)
+	}
+	if _, err := svc.ParseAndValidateTokenBytes([]byte(oversized)); !errors.Is(err, auth.ErrTokenTooLarge) {
+		t.Fatalf("ParseAndValidateTokenBytes: err = %v, ожидался ErrTokenTooLarge", err)
+	}
+
+	limited, _, _ := newTestService(t, auth.WithMaxTokenBytes(len(valid)))
+	if _, err := limited.ParseAndValidateToken(valid); err != nil {
+		t.Fatalf("токен ровно на пределе: %v", err)
+	}
+	if _, err := limited.ParseAndValidateToken(valid + "A"); !errors.Is(err, auth.ErrTokenTooLarge) {
+		t.Fatalf("токен на байт больше предела: err = %v, ожидался ErrTokenTooLarge", err)
+	}
+}
+
+func TestTTLResolverPerUser(t *testing.T) {
+	clock := newFakeClock()
+	storage := memory.NewInMemoryStorage()
+	users := map[string]time.Duration{
+		"admin@example.com": 15 * time.Minute,
+		"user@example.com":  24 * time.Hour,
+		"vip@example.com":   24 * time.Hour, // запросил 48h, ограничен WithMaxTokenTTL
+		"plain@example.com": time.Hour,      // резолвер вернул 0 - ttl конструктора
+	}
+	roles := map[string]string{"admin@example.com": "admin", "user@example.com": "user", "vip@example.com": "vip"}
+	for email := range users {
+		if _, err := storage.SeedUser(email, testPassword, roles[email]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolver := func(user auth.UserIn) time.Duration {
+		rp, _ := user.(auth.RoleProvider)
+		switch {
+		case rp == nil:
+			return 0
+		case slices.Contains(rp.GetRoles(), "admin"):
+			return 15 * time.Minute
+		case slices.Contains(rp.GetRoles(), "vip"):
+			return 48 * time.Hour
+		case slices.Contains(rp.GetRoles(), "user"):
+			return 24 * time.Hour
+		}
+		return 0
+	}
+	svc, err := auth.NewAuthService(storage, testSecret, time.Hour,
+		auth.WithClock(clock), auth.WithTTLResolver(resolver), auth.WithMaxTokenTTL(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for email, want := range users {
+		token, err := svc.Login(context.Background(), email, testPassword)
+		if err != nil {
+			t.Fatalf("Login %s: %v", email, err)
+		}
+		claims, err := svc.ParseAndValidateToken(token)
+		if err != nil {
+			t.Fatalf("ParseAndValidateToken %s: %v", email, err)
+		}
+		if got := claims.ExpiresAt.Sub(clock.Now()); got != want {
+			t.Fatalf("%s: срок токена = %s, ожидался %s", email, got, want)
+		}
+	}
+}
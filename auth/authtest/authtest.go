@@ -0,0 +1,95 @@
+// Package authtest помогает тестировать обработчики, защищенные пакетом auth:
+// выпускает действующие и заведомо негодные access-токены HS256 так же,
+// как их подписывает auth.NewAuthService.
+//
+//	token := authtest.NewToken(auth.JWTClaims{UserID: 1, Email: "a@b.c"}, secret)
+//	req.Header.Set("Authorization", "Bearer "+token)
+package authtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go_auth_pkg/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultTTL - срок жизни токена NewToken, если в claims не задан ExpiresAt.
+const DefaultTTL = time.Hour
+
+// NewToken возвращает access-токен, который примет сервис с тем же секретом.
+// Пустые IssuedAt и ExpiresAt заполняются: сейчас и сейчас + DefaultTTL;
+// пустой ID (jti) - случайным, как у Login, чтобы токен работал с Logout,
+// черным списком и CSRF-привязкой.
+// Issuer, Audience и прочие проверяемые поля задаются в claims так же,
+// как их ожидает сервис (WithIssuer, WithAudience).
+func NewToken(claims auth.JWTClaims, secret []byte) string {
+	return sign(jwt.SigningMethodHS256, withDefaults(claims), secret)
+}
+
+// ExpiredToken возвращает токен, истекший минуту назад (auth.ErrTokenExpired).
+func ExpiredToken(claims auth.JWTClaims, secret []byte) string {
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now.Add(-DefaultTTL))
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+	return sign(jwt.SigningMethodHS256, withDefaults(claims), secret)
+}
+
+// WrongSignatureToken возвращает токен, подписанный другим ключом
+// (auth.ErrTokenInvalid): payload корректен, подпись - нет.
+func WrongSignatureToken(claims auth.JWTClaims, secret []byte) string {
+	other := make([]byte, len(secret))
+	for i, b := range secret {
+		other[i] = ^b
+	}
+	if len(other) == 0 {
+		other = []byte("authtest-wrong-signature-key")
+	}
+	return sign(jwt.SigningMethodHS256, withDefaults(claims), other)
+}
+
+// NoneAlgToken возвращает неподписанный токен с alg "none", которым
+// пытаются обойти проверку подписи (auth.ErrUnexpectedSigningMethod).
+func NoneAlgToken(claims auth.JWTClaims) string {
+	return sign(jwt.SigningMethodNone, withDefaults(claims), jwt.UnsafeAllowNoneSignatureType)
+}
+
+// withDefaults заполняет пустые iat, exp и jti.
+func withDefaults(claims auth.JWTClaims) auth.JWTClaims {
+	now := time.Now()
+	if claims.ID == "" {
+		claims.ID = newTokenID()
+	}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(DefaultTTL))
+	}
+	return claims
+}
+
+// newTokenID возвращает jti того же вида, что и у Login: 16 случайных
+// байт в hex.
+func newTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("authtest: ошибка источника случайности: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// sign подписывает claims с теми же заголовками, что и auth.
+// Ошибка возможна только при некорректных claims, поэтому - panic:
+// в тесте это сразу видно.
+func sign(method jwt.SigningMethod, claims auth.JWTClaims, key interface{}) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["typ"] = auth.TokenTypeAccess
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		panic("authtest: ошибка подписи токена: " + err.Error())
+	}
+	return tokenString
+}
@@ -0,0 +1,112 @@
+package authtest_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+	"go_auth_pkg/auth/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var secret = []byte(strings.Repeat("k", 32))
+
+// blacklist - auth.TokenBlacklist в памяти.
+type blacklist struct {
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (b *blacklist) Add(ctx context.Context, jti string, exp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.revoked[jti] = true
+	return nil
+}
+
+func (b *blacklist) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.revoked[jti], nil
+}
+
+func newService(t *testing.T, opts ...auth.Option) *auth.AuthService {
+	t.Helper()
+	svc, err := auth.NewAuthService(memory.NewInMemoryStorage(), secret, time.Hour, opts...)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	return svc
+}
+
+func TestTokensAgainstService(t *testing.T) {
+	svc := newService(t)
+	claims := auth.JWTClaims{UserID: 7, Email: "a@example.com"}
+
+	tests := []struct {
+		name  string
+		token string
+		check func(error) bool
+	}{
+		{"NewToken", authtest.NewToken(claims, secret), func(err error) bool { return err == nil }},
+		{"ExpiredToken", authtest.ExpiredToken(claims, secret), func(err error) bool {
+			return errors.Is(err, auth.ErrTokenExpired)
+		}},
+		{"WrongSignatureToken", authtest.WrongSignatureToken(claims, secret), func(err error) bool {
+			return errors.Is(err, auth.ErrTokenInvalid) && errors.Is(err, jwt.ErrTokenSignatureInvalid)
+		}},
+		{"NoneAlgToken", authtest.NoneAlgToken(claims), func(err error) bool {
+			return errors.Is(err, auth.ErrUnexpectedSigningMethod)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ParseAndValidateToken(tt.token)
+			if !tt.check(err) {
+				t.Fatalf("ParseAndValidateToken: err = %v", err)
+			}
+			if err == nil && (got.UserID != claims.UserID || got.Email != claims.Email) {
+				t.Fatalf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestNewTokenHasID(t *testing.T) {
+	svc := newService(t, auth.WithTokenBlacklist(&blacklist{revoked: make(map[string]bool)}))
+	claims := auth.JWTClaims{UserID: 7}
+
+	first, err := svc.ParseAndValidateToken(authtest.NewToken(claims, secret))
+	if err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+	second, err := svc.ParseAndValidateToken(authtest.NewToken(claims, secret))
+	if err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Fatalf("jti = %q и %q, ожидались разные непустые", first.ID, second.ID)
+	}
+
+	// Заданный ID сохраняется
+	claims.ID = "fixed-id"
+	fixed, err := svc.ParseAndValidateToken(authtest.NewToken(claims, secret))
+	if err != nil || fixed.ID != "fixed-id" {
+		t.Fatalf("jti = %q, err = %v, ожидался fixed-id", fixed.ID, err)
+	}
+
+	// Как и токен Login, он отзывается через черный список по jti
+	token := authtest.NewToken(auth.JWTClaims{UserID: 7}, secret)
+	if err := svc.Logout(context.Background(), token); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := svc.ParseAndValidateToken(token); !errors.Is(err, auth.ErrTokenRevoked) {
+		t.Fatalf("после Logout: err = %v, ожидался ErrTokenRevoked", err)
+	}
+}
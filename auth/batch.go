@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ParseAndValidateTokens (Пакетная проверка)
+// Проверяет несколько токенов параллельно (не больше GOMAXPROCS воркеров)
+// с общим парсером. Результаты и ошибки идут в порядке входных токенов:
+// для недействительного токена claims[i] == nil, а errs[i] содержит причину.
+func (s *AuthService) ParseAndValidateTokens(tokens []string) ([]*JWTClaims, []error) {
+	return s.ParseAndValidateTokensContext(context.Background(), tokens)
+}
+
+// ParseAndValidateTokensContext - то же, что ParseAndValidateTokens, с контекстом.
+func (s *AuthService) ParseAndValidateTokensContext(ctx context.Context, tokens []string) ([]*JWTClaims, []error) {
+	claims := make([]*JWTClaims, len(tokens))
+	errs := make([]error, len(tokens))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(tokens) {
+		workers = len(tokens)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var c *JWTClaims
+				tokenString, err := s.DecodeToken(tokens[i])
+				if err == nil {
+					c, err = s.validateWith(ctx, s.parser, tokenString)
+				}
+				s.observeValidation(err)
+				s.logValidation(ctx, c, err)
+				if err != nil {
+					c = nil
+				}
+				claims[i], errs[i] = c, err
+			}
+		}()
+	}
+
+	for i := range tokens {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return claims, errs
+}
@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+)
+
+// batchTokens возвращает n действующих токенов разных пользователей.
+func batchTokens(n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = authtest.NewToken(auth.JWTClaims{UserID: int64(i + 1), Email: "u" + strconv.Itoa(i) + "@example.com"}, testSecret)
+	}
+	return tokens
+}
+
+func TestParseAndValidateTokensKeepsOrder(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	tokens := batchTokens(16)
+	tokens[5] = authtest.WrongSignatureToken(auth.JWTClaims{UserID: 6}, testSecret)
+
+	claims, errs := svc.ParseAndValidateTokens(tokens)
+	for i := range tokens {
+		if i == 5 {
+			if claims[i] != nil || !errors.Is(errs[i], auth.ErrTokenInvalid) {
+				t.Fatalf("токен %d: claims = %v, err = %v, ожидался ErrTokenInvalid", i, claims[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Fatalf("токен %d: %v", i, errs[i])
+		}
+		if claims[i].UserID != int64(i+1) {
+			t.Fatalf("токен %d: UserID = %d, порядок результатов нарушен", i, claims[i].UserID)
+		}
+	}
+}
+
+func BenchmarkParseAndValidateTokens(b *testing.B) {
+	svc, _, _ := newTestService(b)
+	tokens := batchTokens(256)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, errs := svc.ParseAndValidateTokens(tokens); errs[0] != nil {
+			b.Fatal(errs[0])
+		}
+	}
+}
+
+// BenchmarkParseAndValidateTokenLoop - та же пачка по одному токену,
+// база для сравнения с BenchmarkParseAndValidateTokens.
+func BenchmarkParseAndValidateTokenLoop(b *testing.B) {
+	svc, _, _ := newTestService(b)
+	tokens := batchTokens(256)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, token := range tokens {
+			if _, err := svc.ParseAndValidateToken(token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// BlacklistFailureMode - поведение проверки access-токена, когда
+// TokenBlacklist возвращает ошибку (см. WithBlacklistFailureMode).
+type BlacklistFailureMode int
+
+const (
+	// BlacklistFailClosed - токен отклоняется (по умолчанию): отозванный
+	// токен никогда не пройдет, но сбой хранилища останавливает все запросы.
+	BlacklistFailClosed BlacklistFailureMode = iota
+	// BlacklistFailOpen - проверка черного списка пропускается: API остается
+	// доступным, но на время сбоя отозванные (Logout) токены снова действуют
+	// до истечения срока. Каждый такой пропуск пишется в журнал (Warn).
+	BlacklistFailOpen
+)
+
+// BlacklistMetrics - необязательное расширение Metrics: если Metrics его
+// реализует, при BlacklistFailOpen каждый пропуск проверки черного списка
+// из-за ошибки хранилища передается в IncBlacklistFailOpen.
+type BlacklistMetrics interface {
+	IncBlacklistFailOpen()
+}
+
+// checkBlacklist отклоняет отозванный access-токен (по jti, а без него -
+// по отпечатку). Ошибка хранилища обрабатывается по WithBlacklistFailureMode.
+// Одноразовые служебные токены и DPoP-доказательства проверяются всегда
+// строго, без этого режима.
+func (s *AuthService) checkBlacklist(ctx context.Context, claims *JWTClaims, tokenString string) error {
+	if s.blacklist == nil {
+		return nil
+	}
+	revoked, err := s.blacklist.IsBlacklisted(ctx, blacklistKey(claims, tokenString))
+	if err != nil {
+		if s.blacklistFailureMode != BlacklistFailOpen {
+			return fmt.Errorf("ошибка проверки черного списка: %w", err)
+		}
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "auth: черный список недоступен, проверка отзыва пропущена",
+			slog.Int64("user_id", claims.UserID), slog.String("error", err.Error()))
+		if bm, ok := s.metrics.(BlacklistMetrics); ok {
+			bm.IncBlacklistFailOpen()
+		}
+		return nil
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+)
+
+var errBlacklistDown = errors.New("blacklist недоступен")
+
+// failingBlacklist - TokenBlacklist, хранилище которого недоступно.
+type failingBlacklist struct{}
+
+func (failingBlacklist) Add(ctx context.Context, jti string, exp time.Time) error {
+	return errBlacklistDown
+}
+
+func (failingBlacklist) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	return false, errBlacklistDown
+}
+
+// failOpenMetrics считает пропуски проверки черного списка.
+type failOpenMetrics struct {
+	failOpen atomic.Int64
+}
+
+func (*failOpenMetrics) IncLoginSuccess()            {}
+func (*failOpenMetrics) IncLoginFailure(string)      {}
+func (*failOpenMetrics) ObserveValidation(bool)      {}
+func (*failOpenMetrics) IncValidationFailure(string) {}
+func (m *failOpenMetrics) IncBlacklistFailOpen()     { m.failOpen.Add(1) }
+
+func TestBlacklistFailureModes(t *testing.T) {
+	t.Run("FailClosed", func(t *testing.T) {
+		svc, _, _ := newTestService(t, auth.WithTokenBlacklist(failingBlacklist{}))
+		token, err := svc.Login(context.Background(), testEmail, testPassword)
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if _, err := svc.ParseAndValidateToken(token); !errors.Is(err, errBlacklistDown) {
+			t.Fatalf("err = %v, ожидалась ошибка хранилища", err)
+		}
+	})
+
+	t.Run("FailOpen", func(t *testing.T) {
+		var logs bytes.Buffer
+		metrics := &failOpenMetrics{}
+		svc, _, _ := newTestService(t,
+			auth.WithTokenBlacklist(failingBlacklist{}),
+			auth.WithBlacklistFailureMode(auth.BlacklistFailOpen),
+			auth.WithMetrics(metrics),
+			auth.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		// Режим объявляется в журнале уже при создании сервиса
+		if !strings.Contains(logs.String(), "BlacklistFailOpen") {
+			t.Fatalf("нет предупреждения о BlacklistFailOpen при создании: %q", logs.String())
+		}
+
+		token, err := svc.Login(context.Background(), testEmail, testPassword)
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		logs.Reset()
+		if _, err := svc.ParseAndValidateToken(token); err != nil {
+			t.Fatalf("ParseAndValidateToken: %v", err)
+		}
+		if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), errBlacklistDown.Error()) {
+			t.Fatalf("пропуск проверки не записан в журнал: %q", logs.String())
+		}
+		if n := metrics.failOpen.Load(); n != 1 {
+			t.Fatalf("IncBlacklistFailOpen вызван %d раз, ожидался 1", n)
+		}
+
+		// Logout записывает в черный список и при FailOpen сообщает об ошибке
+		if err := svc.Logout(context.Background(), token); !errors.Is(err, errBlacklistDown) {
+			t.Fatalf("Logout: err = %v, ожидалась ошибка хранилища", err)
+		}
+	})
+}
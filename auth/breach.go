@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// BreachChecker сообщает, что пароль найден в известных утечках
+// (WithBreachChecker). Эталонная реализация - пакет auth/hibp.
+type BreachChecker func(ctx context.Context, password string) (breached bool, err error)
+
+// BreachCheckFailureMode - поведение при ошибке BreachChecker.
+type BreachCheckFailureMode int
+
+const (
+	// BreachCheckFailOpen - пароль принимается (по умолчанию): недоступность
+	// внешнего сервиса не мешает пользователям. Ошибка пишется в журнал (Warn).
+	BreachCheckFailOpen BreachCheckFailureMode = iota
+	// BreachCheckFailClosed - операция прерывается с ошибкой проверки.
+	BreachCheckFailClosed
+)
+
+// checkBreached отклоняет пароль из утечек (ErrPasswordBreached).
+// Ошибка BreachChecker обрабатывается по BreachCheckFailureMode.
+func (s *AuthService) checkBreached(ctx context.Context, password string) error {
+	if s.breachChecker == nil {
+		return nil
+	}
+	breached, err := s.breachChecker(ctx, password)
+	if err != nil {
+		if s.breachCheckFailureMode == BreachCheckFailClosed {
+			return fmt.Errorf("ошибка проверки пароля на утечки: %w", err)
+		}
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "auth: проверка пароля на утечки недоступна, пароль принят",
+			slog.String("error", err.Error()))
+		return nil
+	}
+	if breached {
+		return ErrPasswordBreached
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go_auth_pkg/auth"
+)
+
+const (
+	breachedPassword = "Leaked#Passw0rd"
+	freshPassword    = "Fresh#Passw0rd2"
+)
+
+// fakeBreachChecker считает вызовы и сообщает об утечке для breached
+// или возвращает err.
+type fakeBreachChecker struct {
+	breached map[string]bool
+	err      error
+	calls    atomic.Int64
+}
+
+func newFakeBreachChecker(breached ...string) *fakeBreachChecker {
+	c := &fakeBreachChecker{breached: make(map[string]bool)}
+	for _, p := range breached {
+		c.breached[p] = true
+	}
+	return c
+}
+
+func (c *fakeBreachChecker) Check(ctx context.Context, password string) (bool, error) {
+	c.calls.Add(1)
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.breached[password], nil
+}
+
+// breachOps - операции, устанавливающие пароль пользователю testEmail
+// (Register - новому пользователю); run возвращает ошибку установки password.
+var breachOps = []struct {
+	name string
+	run  func(t *testing.T, svc *auth.AuthService, userID int64, password string) error
+}{
+	{"Register", func(t *testing.T, svc *auth.AuthService, userID int64, password string) error {
+		_, err := svc.Register(context.Background(), "new@example.com", password)
+		return err
+	}},
+	{"ChangePassword", func(t *testing.T, svc *auth.AuthService, userID int64, password string) error {
+		return svc.ChangePassword(context.Background(), userID, testPassword, password)
+	}},
+	{"ResetPassword", func(t *testing.T, svc *auth.AuthService, userID int64, password string) error {
+		token, err := svc.GeneratePasswordResetToken(context.Background(), testEmail)
+		if err != nil {
+			t.Fatalf("GeneratePasswordResetToken: %v", err)
+		}
+		return svc.ResetPassword(context.Background(), token, password)
+	}},
+}
+
+func TestBreachCheckerRejectsBreachedPassword(t *testing.T) {
+	for _, op := range breachOps {
+		t.Run(op.name, func(t *testing.T) {
+			checker := newFakeBreachChecker(breachedPassword)
+			svc, _, userID := newTestService(t, auth.WithBreachChecker(checker.Check, auth.BreachCheckFailClosed))
+
+			if err := op.run(t, svc, userID, breachedPassword); !errors.Is(err, auth.ErrPasswordBreached) {
+				t.Fatalf("пароль из утечки: err = %v, ожидался ErrPasswordBreached", err)
+			}
+			if err := op.run(t, svc, userID, freshPassword); err != nil {
+				t.Fatalf("пароль не из утечки: %v", err)
+			}
+		})
+	}
+}
+
+func TestBreachCheckerFailureModes(t *testing.T) {
+	errDown := errors.New("сервис утечек недоступен")
+	for _, op := range breachOps {
+		t.Run(op.name+"/FailClosed", func(t *testing.T) {
+			checker := newFakeBreachChecker()
+			checker.err = errDown
+			svc, _, userID := newTestService(t, auth.WithBreachChecker(checker.Check, auth.BreachCheckFailClosed))
+
+			err := op.run(t, svc, userID, freshPassword)
+			if !errors.Is(err, errDown) {
+				t.Fatalf("err = %v, ожидалась ошибка проверки", err)
+			}
+			if errors.Is(err, auth.ErrPasswordBreached) {
+				t.Fatal("сбой проверки выдан за утечку")
+			}
+		})
+
+		t.Run(op.name+"/FailOpen", func(t *testing.T) {
+			var logs bytes.Buffer
+			checker := newFakeBreachChecker()
+			checker.err = errDown
+			svc, _, userID := newTestService(t,
+				auth.WithBreachChecker(checker.Check, auth.BreachCheckFailOpen),
+				auth.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+			if err := op.run(t, svc, userID, freshPassword); err != nil {
+				t.Fatalf("пароль не принят при недоступной проверке: %v", err)
+			}
+			if checker.calls.Load() != 1 {
+				t.Fatalf("проверка вызвана %d раз", checker.calls.Load())
+			}
+			if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), errDown.Error()) {
+				t.Fatalf("сбой проверки не записан в журнал: %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestBreachCheckerNotCalledBeforeCredentialCheck(t *testing.T) {
+	checker := newFakeBreachChecker(breachedPassword)
+	svc, _, userID := newTestService(t, auth.WithBreachChecker(checker.Check, auth.BreachCheckFailClosed))
+	ctx := context.Background()
+
+	if err := svc.ChangePassword(ctx, userID, "wrong-old-password", breachedPassword); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("ChangePassword с неверным старым паролем: err = %v", err)
+	}
+	if err := svc.ResetPassword(ctx, "not-a-reset-token", breachedPassword); err == nil || errors.Is(err, auth.ErrPasswordBreached) {
+		t.Fatalf("ResetPassword с неверным токеном: err = %v", err)
+	}
+	// Без подтверждения личности внешний сервис не должен узнавать пароль
+	if n := checker.calls.Load(); n != 0 {
+		t.Fatalf("проверка на утечки вызвана %d раз до проверки учетных данных", n)
+	}
+}
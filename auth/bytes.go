@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"context"
+	"unsafe"
+)
+
+// ParseAndValidateTokenBytes (Проверка JWT из []byte)
+// То же, что ParseAndValidateToken, но принимает токен байтами (например,
+// прочитанный из заголовка без преобразования в string) и не копирует его.
+// Срез нельзя изменять до возврата из метода; после возврата claims
+// от него не зависят.
+func (s *AuthService) ParseAndValidateTokenBytes(token []byte) (*JWTClaims, error) {
+	return s.ParseAndValidateTokenBytesContext(context.Background(), token)
+}
+
+// ParseAndValidateTokenBytesContext - то же, что ParseAndValidateTokenBytes, с контекстом.
+func (s *AuthService) ParseAndValidateTokenBytesContext(ctx context.Context, token []byte) (*JWTClaims, error) {
+	tokenString := unsafe.String(unsafe.SliceData(token), len(token))
+	// Непрозрачный токен уходит в TokenStore, который может сохранить строку:
+	// для него делаем копию (поход в хранилище все равно дороже)
+	if s.isOpaqueToken(tokenString) {
+		tokenString = string(token)
+	}
+	return s.ParseAndValidateTokenContext(ctx, tokenString)
+}
@@ -0,0 +1,32 @@
+package auth_test
+
+import (
+	"testing"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+)
+
+// Токен приходит байтами (как из заголовка запроса): строковой версии
+// нужна копия string(token), ParseAndValidateTokenBytes обходится без нее.
+func BenchmarkParseAndValidateTokenBytes(b *testing.B) {
+	svc, _, _ := newTestService(b)
+	token := []byte(authtest.NewToken(auth.JWTClaims{UserID: 1, Email: testEmail}, testSecret))
+
+	b.Run("Bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := svc.ParseAndValidateTokenBytes(token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("String", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := svc.ParseAndValidateToken(string(token)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
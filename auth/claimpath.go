@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// Get (Значение дополнительного claim)
+// Возвращает значение из Extra по пути через точку: "org.plan" - поле plan
+// объекта org. false - поля нет или промежуточное значение не объект.
+// Стандартные claims (user_id, roles и т.д.) в Extra не попадают:
+// они читаются из полей JWTClaims.
+func (c *JWTClaims) Get(path string) (interface{}, bool) {
+	if c == nil || path == "" {
+		return nil, false
+	}
+	var current interface{} = c.Extra
+	for _, key := range strings.Split(path, ".") {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			value, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case map[string]string:
+			value, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// GetString - строка из Extra по пути (см. Get). Для отсутствующего поля
+// и значения другого типа - "" и false.
+func (c *JWTClaims) GetString(path string) (string, bool) {
+	value, _ := c.Get(path)
+	s, ok := value.(string)
+	return s, ok
+}
+
+// GetInt - целое из Extra по пути (см. Get). Числа из JSON (float64)
+// принимаются, только если у них нет дробной части и они помещаются в int64;
+// иначе, как и для отсутствующего поля, - 0 и false.
+func (c *JWTClaims) GetInt(path string) (int64, bool) {
+	value, _ := c.Get(path)
+	switch n := value.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// GetBool - логическое значение из Extra по пути (см. Get). Для
+// отсутствующего поля и значения другого типа - false и false.
+func (c *JWTClaims) GetBool(path string) (bool, bool) {
+	value, _ := c.Get(path)
+	b, ok := value.(bool)
+	return b, ok
+}
@@ -0,0 +1,84 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"go_auth_pkg/auth"
+)
+
+func TestClaimPathAccessors(t *testing.T) {
+	enricher := func(ctx context.Context, user auth.UserIn) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"org": map[string]interface{}{
+				"plan":  "pro",
+				"seats": 25,
+				"trial": false,
+				"ratio": 1.5,
+				"limits": map[string]interface{}{
+					"api": 1000,
+				},
+			},
+			"tenant": "acme",
+		}, nil
+	}
+	svc, _, _ := newTestService(t, auth.WithClaimsEnricher(enricher))
+	token, err := svc.Login(context.Background(), testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	// Значения после разбора JSON: числа - float64, объекты - map
+	claims, err := svc.ParseAndValidateToken(token)
+	if err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+
+	if v, ok := claims.GetString("org.plan"); !ok || v != "pro" {
+		t.Errorf("GetString(org.plan) = %q, %v", v, ok)
+	}
+	if v, ok := claims.GetString("tenant"); !ok || v != "acme" {
+		t.Errorf("GetString(tenant) = %q, %v", v, ok)
+	}
+	if v, ok := claims.GetInt("org.seats"); !ok || v != 25 {
+		t.Errorf("GetInt(org.seats) = %d, %v", v, ok)
+	}
+	if v, ok := claims.GetInt("org.limits.api"); !ok || v != 1000 {
+		t.Errorf("GetInt(org.limits.api) = %d, %v", v, ok)
+	}
+	if v, ok := claims.GetBool("org.trial"); !ok || v {
+		t.Errorf("GetBool(org.trial) = %v, %v", v, ok)
+	}
+
+	// Отсутствующие ключи и несовпадение типов - нулевое значение и false
+	missing := []string{"", "org.missing", "missing.plan", "org.plan.name", "tenant.id", ".", "org."}
+	for _, path := range missing {
+		if v, ok := claims.GetString(path); ok || v != "" {
+			t.Errorf("GetString(%q) = %q, %v, ожидалось \"\", false", path, v, ok)
+		}
+	}
+	mismatch := []struct {
+		path string
+		get  func(string) bool
+	}{
+		{"org.seats", func(p string) bool { v, ok := claims.GetString(p); return ok || v != "" }},
+		{"org.plan", func(p string) bool { v, ok := claims.GetInt(p); return ok || v != 0 }},
+		{"org.ratio", func(p string) bool { v, ok := claims.GetInt(p); return ok || v != 0 }},
+		{"org", func(p string) bool { v, ok := claims.GetInt(p); return ok || v != 0 }},
+		{"org.plan", func(p string) bool { v, ok := claims.GetBool(p); return ok || v }},
+		{"org.limits", func(p string) bool { v, ok := claims.GetString(p); return ok || v != "" }},
+	}
+	for _, m := range mismatch {
+		if m.get(m.path) {
+			t.Errorf("%q: значение другого типа не дало нулевое значение и false", m.path)
+		}
+	}
+
+	var nilClaims *auth.JWTClaims
+	if _, ok := nilClaims.GetString("org.plan"); ok {
+		t.Error("GetString на nil claims вернул ok")
+	}
+	empty := &auth.JWTClaims{}
+	if _, ok := empty.GetInt("org.seats"); ok {
+		t.Error("GetInt без Extra вернул ok")
+	}
+}
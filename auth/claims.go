@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jwtClaimsJSON - JWTClaims без методов, чтобы избежать рекурсии в (un)marshal.
+type jwtClaimsJSON JWTClaims
+
+// reservedClaims - имена полей JWTClaims (включая RegisteredClaims).
+// Extra не может их переопределить.
+var reservedClaims = claimNames(reflect.TypeOf(JWTClaims{}))
+
+// MarshalJSON кладет Extra на верхний уровень payload рядом со стандартными полями.
+func (c JWTClaims) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(jwtClaimsJSON(c))
+	if err != nil || len(c.Extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]interface{}, len(c.Extra)+8)
+	for k, v := range c.Extra {
+		if !reservedClaims[k] {
+			merged[k] = v
+		}
+	}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON раскладывает payload: известные поля - в структуру,
+// остальные - в Extra.
+func (c *JWTClaims) UnmarshalJSON(data []byte) error {
+	var base jwtClaimsJSON
+	if err := json.Unmarshal(data, &base); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for k := range all {
+		if reservedClaims[k] {
+			delete(all, k)
+		}
+	}
+	base.Extra = nil
+	if len(all) > 0 {
+		base.Extra = all
+	}
+
+	*c = JWTClaims(base)
+	return nil
+}
+
+// claimNames собирает json-имена полей структуры, включая встроенные.
+func claimNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			for k := range claimNames(f.Type) {
+				names[k] = true
+			}
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// HasExpiry сообщает, что в токене задан срок действия (exp).
+func (c *JWTClaims) HasExpiry() bool {
+	return c.ExpiresAt != nil
+}
+
+// TimeUntilExpiry возвращает время до истечения токена относительно now.
+// Для истекшего токена - отрицательное значение, без exp - ноль
+// (отличайте этот случай через HasExpiry).
+func (c *JWTClaims) TimeUntilExpiry(now time.Time) time.Duration {
+	if c.ExpiresAt == nil {
+		return 0
+	}
+	return c.ExpiresAt.Sub(now)
+}
+
+// IsExpired сообщает, что срок действия токена истек к моменту now.
+// Токен без exp не истекает.
+func (c *JWTClaims) IsExpired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(c.ExpiresAt.Time)
+}
+
+// TimeUntilExpiry - JWTClaims.TimeUntilExpiry по часам сервиса (WithClock).
+func (s *AuthService) TimeUntilExpiry(claims *JWTClaims) time.Duration {
+	return claims.TimeUntilExpiry(s.clock.Now())
+}
+
+// IsExpired - JWTClaims.IsExpired по часам сервиса (WithClock).
+func (s *AuthService) IsExpired(claims *JWTClaims) bool {
+	return claims.IsExpired(s.clock.Now())
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Изменение параметров на лету.
+// AuthService безопасен для одновременного вызова сеттеров и операций
+// (Login, ParseAndValidateToken и т.д.): новые значения применяются
+// к операциям, начавшимся после вызова сеттера. Хранилища и прочие
+// зависимости при этом сохраняются.
+
+// SetTokenTTL меняет срок жизни новых access-токенов.
+func (s *AuthService) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	s.tokenTTL = ttl
+	s.mu.Unlock()
+}
+
+// SetMaxTokenTTL меняет предел TokenOptions.TTL (см. WithMaxTokenTTL).
+func (s *AuthService) SetMaxTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	s.maxTokenTTL = ttl
+	s.mu.Unlock()
+}
+
+// SetBcryptCost меняет стоимость bcrypt для новых хэшей.
+func (s *AuthService) SetBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("стоимость bcrypt должна быть в диапазоне [%d, %d]: %d",
+			bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+
+	s.mu.Lock()
+	s.bcryptCost = cost
+	s.mu.Unlock()
+	return nil
+}
+
+// SetPasswordPolicy меняет политику паролей.
+func (s *AuthService) SetPasswordPolicy(policy PasswordPolicy) {
+	s.mu.Lock()
+	s.passwordPolicy = policy
+	s.mu.Unlock()
+}
+
+// currentBcryptCost возвращает текущую стоимость bcrypt.
+func (s *AuthService) currentBcryptCost() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bcryptCost
+}
+
+// hashPassword хэширует пароль текущим алгоритмом (см. passwordHasher)
+// с текущим перцем (WithPepper).
+func (s *AuthService) hashPassword(password string) (string, error) {
+	defer s.observeHash(time.Now())
+	return s.passwordHasher().Hash(s.pepperedInput(password))
+}
@@ -0,0 +1,84 @@
+package auth_test
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Запускайте с -race: сеттеры меняют параметры во время входов и проверок.
+func TestSettersConcurrentWithLogin(t *testing.T) {
+	svc, storage, userID := newTestService(t)
+	ctx := context.Background()
+	// Дешевый хэш: под -race bcrypt со стоимостью по умолчанию очень медленный
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.UpdatePasswordHash(ctx, userID, string(hash)); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var setters sync.WaitGroup
+	setters.Add(1)
+	go func() {
+		defer setters.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			svc.SetTokenTTL(time.Duration(i%60+1) * time.Minute)
+			svc.SetMaxTokenTTL(2 * time.Hour)
+			svc.SetPasswordPolicy(auth.DefaultPasswordPolicy)
+			runtime.Gosched()
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := 0; i < 3; i++ {
+				token, err := svc.Login(ctx, testEmail, testPassword)
+				if err != nil {
+					t.Errorf("Login: %v", err)
+					return
+				}
+				claims, err := svc.ParseAndValidateToken(token)
+				if err != nil {
+					t.Errorf("ParseAndValidateToken: %v", err)
+					return
+				}
+				if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl < time.Minute || ttl > time.Hour {
+					t.Errorf("срок токена %s вне значений SetTokenTTL", ttl)
+				}
+			}
+		}()
+	}
+	workers.Wait()
+	close(stop)
+	setters.Wait()
+
+	svc.SetTokenTTL(5 * time.Minute)
+	token, err := svc.Login(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	claims, err := svc.ParseAndValidateToken(token)
+	if err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != 5*time.Minute {
+		t.Fatalf("срок токена после SetTokenTTL = %s, ожидалось 5m", ttl)
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// csrfKeyLabel отделяет ключ CSRF от секрета подписи при выводе из него.
+const csrfKeyLabel = "go-auth csrf v1"
+
+// IssueCSRFToken (CSRF-токен)
+// Возвращает CSRF-токен для схемы double-submit при хранении токена
+// в cookie (WithCookieName): клиент получает его в теле ответа или
+// читаемой cookie и присылает в заголовке (WithCSRFHeader).
+// Токен - HMAC сессии (sid), без SessionStore - идентификатора токена,
+// поэтому не хранится на сервере. Ключ - WithCSRFKey.
+func (s *AuthService) IssueCSRFToken(claims *JWTClaims) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(csrfBinding(claims)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateCSRF проверяет присланный клиентом CSRF-токен; при несовпадении
+// (или пустом токене) возвращает ErrCSRFMismatch.
+func (s *AuthService) ValidateCSRF(claims *JWTClaims, submitted string) error {
+	if claims == nil || submitted == "" {
+		return ErrCSRFMismatch
+	}
+	if !hmac.Equal([]byte(s.IssueCSRFToken(claims)), []byte(submitted)) {
+		return ErrCSRFMismatch
+	}
+	return nil
+}
+
+// csrfBinding - значение, к которому привязывается CSRF-токен.
+func csrfBinding(claims *JWTClaims) string {
+	if claims == nil {
+		return ""
+	}
+	switch {
+	case claims.SessionID != "":
+		return "sid:" + claims.SessionID
+	case claims.ID != "":
+		return "jti:" + claims.ID
+	default:
+		return "uid:" + strconv.FormatInt(claims.UserID, 10)
+	}
+}
+
+// checkCSRF требует CSRF-заголовок у изменяющих запросов, токен которых
+// взят из cookie. Без WithCSRFHeader проверка выключена.
+func (s *AuthService) checkCSRF(r *http.Request, claims *JWTClaims, source tokenSource) error {
+	if s.csrfHeader == "" || source != sourceCookie {
+		return nil
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	return s.ValidateCSRF(claims, r.Header.Get(s.csrfHeader))
+}
+
+// initCSRFKey выбирает ключ CSRF: WithCSRFKey, иначе выводится из
+// HMAC-секрета, иначе (RSA, SecretProvider) - случайный на время жизни процесса.
+func (s *AuthService) initCSRFKey() error {
+	if len(s.csrfKey) > 0 {
+		return nil
+	}
+	if secret, ok := s.signKey.([]byte); ok && len(secret) > 0 {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(csrfKeyLabel))
+		s.csrfKey = mac.Sum(nil)
+		return nil
+	}
+	key, err := s.randomBytes(32)
+	if err != nil {
+		return fmt.Errorf("ошибка генерации ключа CSRF: %w", err)
+	}
+	s.csrfKey = key
+	return nil
+}
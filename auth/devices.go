@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IssueDeviceToken (Запомнить устройство)
+// Выпускает долгоживущий токен доверенного устройства (срок -
+// WithDeviceTrustTTL). Вызывайте после успешного входа со вторым фактором,
+// если пользователь выбрал "запомнить это устройство"; при следующих входах
+// токен передается в LoginWith2FA вместо кода.
+// Токен отзывается RevokeDeviceToken (нужен черный список) или вместе
+// со всеми токенами пользователя через RevokeAllTokens (WithTokenVersioning).
+func (s *AuthService) IssueDeviceToken(ctx context.Context, userID int64) (string, error) {
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	claims := purposeClaims{
+		Purpose:          PurposeDeviceTrust,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: strconv.FormatInt(userID, 10)},
+	}
+	if vp, ok := user.(TokenVersionProvider); ok && s.tokenVersioning {
+		claims.TokenVersion = vp.GetTokenVersion()
+	}
+	return s.signPurposeToken(ctx, claims, s.deviceTrustTTL)
+}
+
+// RevokeDeviceToken отзывает токен доверенного устройства: его jti
+// заносится в черный список до истечения срока токена.
+func (s *AuthService) RevokeDeviceToken(ctx context.Context, tokenString string) error {
+	if s.blacklist == nil {
+		return ErrBlacklistUnsupported
+	}
+
+	claims, err := s.parsePurposeToken(ctx, tokenString, PurposeDeviceTrust)
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return ErrMissingTokenID
+	}
+
+	var exp time.Time
+	if claims.ExpiresAt != nil {
+		exp = claims.ExpiresAt.Time
+	}
+	return s.blacklist.Add(ctx, claims.ID, exp)
+}
+
+// trustedDevice сообщает, что токен устройства действителен для user:
+// подпись, срок, назначение, владелец, черный список и версия токенов.
+// Любая ошибка (в т.ч. хранилища) означает "не доверено" - тогда
+// запрашивается обычный второй фактор.
+func (s *AuthService) trustedDevice(ctx context.Context, user UserIn, tokenString string) bool {
+	claims, err := s.parsePurposeToken(ctx, tokenString, PurposeDeviceTrust)
+	if err != nil {
+		return false
+	}
+	if userID, err := claims.userID(); err != nil || userID != user.GetID() {
+		return false
+	}
+
+	if s.blacklist != nil && claims.ID != "" {
+		revoked, err := s.blacklist.IsBlacklisted(ctx, claims.ID)
+		if err != nil || revoked {
+			return false
+		}
+	}
+
+	if s.tokenVersioning {
+		var current int64
+		if vp, ok := user.(TokenVersionProvider); ok {
+			current = vp.GetTokenVersion()
+		}
+		if claims.TokenVersion < current {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go_auth_pkg/auth"
+)
+
+func TestTrustedDeviceSkipsTOTP(t *testing.T) {
+	svc, storage, userID := newTestService(t,
+		auth.WithTokenBlacklist(newMemBlacklist()),
+		auth.WithTokenVersioning(true))
+	ctx := context.Background()
+
+	otherID, err := storage.SeedUser("other@example.com", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{userID, otherID} {
+		if err := storage.SetTOTP(ctx, id, rfc6238Secret, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	login := func(deviceToken string) error {
+		_, err := svc.LoginWith2FA(ctx, testEmail, testPassword, "", deviceToken)
+		return err
+	}
+
+	device, err := svc.IssueDeviceToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("IssueDeviceToken: %v", err)
+	}
+	if err := login(device); err != nil {
+		t.Fatalf("вход с токеном устройства без кода: %v", err)
+	}
+	// Токен устройства не заменяет пароль
+	if _, err := svc.LoginWith2FA(ctx, testEmail, "wrong-password", "", device); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("неверный пароль: err = %v, ожидался ErrInvalidCredentials", err)
+	}
+
+	otherDevice, err := svc.IssueDeviceToken(ctx, otherID)
+	if err != nil {
+		t.Fatalf("IssueDeviceToken: %v", err)
+	}
+	accessToken, err := svc.LoginWith2FA(ctx, "other@example.com", testPassword, "", otherDevice)
+	if err != nil {
+		t.Fatalf("вход второго пользователя: %v", err)
+	}
+
+	ignored := []struct {
+		name  string
+		token string
+	}{
+		{"чужой токен устройства", otherDevice},
+		// Access-токен подписан тем же ключом, но назначение другое
+		{"access-токен", accessToken},
+		{"мусор", "not-a-device-token"},
+	}
+	for _, tt := range ignored {
+		if err := login(tt.token); !errors.Is(err, auth.ErrTOTPRequired) {
+			t.Errorf("%s: err = %v, ожидался ErrTOTPRequired", tt.name, err)
+		}
+	}
+
+	t.Run("RevokeDeviceToken", func(t *testing.T) {
+		revoked, err := svc.IssueDeviceToken(ctx, userID)
+		if err != nil {
+			t.Fatalf("IssueDeviceToken: %v", err)
+		}
+		if err := svc.RevokeDeviceToken(ctx, revoked); err != nil {
+			t.Fatalf("RevokeDeviceToken: %v", err)
+		}
+		if err := login(revoked); !errors.Is(err, auth.ErrTOTPRequired) {
+			t.Fatalf("отозванный токен: err = %v, ожидался ErrTOTPRequired", err)
+		}
+		// Отзыв одного устройства не трогает другие
+		if err := login(device); err != nil {
+			t.Fatalf("другое устройство после отзыва: %v", err)
+		}
+	})
+
+	t.Run("RevokeAllTokens", func(t *testing.T) {
+		if err := svc.RevokeAllTokens(ctx, userID); err != nil {
+			t.Fatalf("RevokeAllTokens: %v", err)
+		}
+		if err := login(device); !errors.Is(err, auth.ErrTOTPRequired) {
+			t.Fatalf("токен до смены версии: err = %v, ожидался ErrTOTPRequired", err)
+		}
+		fresh, err := svc.IssueDeviceToken(ctx, userID)
+		if err != nil {
+			t.Fatalf("IssueDeviceToken: %v", err)
+		}
+		if err := login(fresh); err != nil {
+			t.Fatalf("новый токен устройства: %v", err)
+		}
+	})
+}
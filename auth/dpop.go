@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultDPoPProofAge - срок годности DPoP-доказательства по умолчанию.
+const defaultDPoPProofAge = time.Minute
+
+// dpopProofType - typ заголовка DPoP-доказательства (RFC 9449).
+const dpopProofType = "dpop+jwt"
+
+// dpopMethods - алгоритмы DPoP-доказательств: только асимметричные.
+var dpopMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Confirmation - claim cnf (RFC 7800): ключ, которым владелец токена
+// должен подтверждать каждый запрос.
+type Confirmation struct {
+	// JKT - отпечаток JWK клиента (RFC 7638, SHA-256, base64url).
+	JKT string `json:"jkt,omitempty"`
+}
+
+// dpopClaims - payload DPoP-доказательства.
+type dpopClaims struct {
+	HTM string `json:"htm"`           // метод запроса
+	HTU string `json:"htu"`           // адрес запроса без query и fragment
+	ATH string `json:"ath,omitempty"` // SHA-256 access-токена, base64url
+	jwt.RegisteredClaims
+}
+
+// Thumbprint возвращает отпечаток открытого ключа по RFC 7638 (SHA-256,
+// base64url без дополнения) - значение для TokenOptions.DPoPThumbprint.
+func (k JWK) Thumbprint() (string, error) {
+	var members map[string]string
+	switch k.Kty {
+	case "RSA":
+		members = map[string]string{"e": k.E, "kty": k.Kty, "n": k.N}
+	case "EC":
+		members = map[string]string{"crv": k.Crv, "kty": k.Kty, "x": k.X, "y": k.Y}
+	case "OKP":
+		members = map[string]string{"crv": k.Crv, "kty": k.Kty, "x": k.X}
+	default:
+		return "", fmt.Errorf("неподдерживаемый тип ключа %q", k.Kty)
+	}
+	for name, value := range members {
+		if value == "" {
+			return "", fmt.Errorf("в JWK не хватает поля %q", name)
+		}
+	}
+
+	// encoding/json сортирует ключи map - это и есть каноничный порядок RFC 7638
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// checkDPoP требует DPoP-доказательство для токена, привязанного к ключу
+// (cnf.jkt). Токены без привязки проверяются как обычные bearer-токены.
+// accessToken - компактный JWT (см. DecodeToken), от него считается ath.
+func (s *AuthService) checkDPoP(r *http.Request, claims *JWTClaims, accessToken string) error {
+	if claims.Confirmation == nil || claims.Confirmation.JKT == "" {
+		return nil
+	}
+
+	jkt, err := s.verifyDPoPProof(r, accessToken)
+	if err != nil {
+		return err
+	}
+	if jkt != claims.Confirmation.JKT {
+		return fmt.Errorf("%w: ключ доказательства не совпадает с ключом токена", ErrInvalidDPoPProof)
+	}
+	return nil
+}
+
+// verifyDPoPProof проверяет заголовок DPoP запроса: подпись ключом
+// из заголовка jwk, метод и адрес запроса, свежесть iat и (если передан
+// accessToken) хэш токена ath. Возвращает отпечаток ключа.
+func (s *AuthService) verifyDPoPProof(r *http.Request, accessToken string) (string, error) {
+	values := r.Header.Values("DPoP")
+	if len(values) != 1 || strings.TrimSpace(values[0]) == "" {
+		return "", fmt.Errorf("%w: нужен один заголовок DPoP", ErrInvalidDPoPProof)
+	}
+
+	var jwk JWK
+	claims := &dpopClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(dpopMethods))
+	_, err := parser.ParseWithClaims(strings.TrimSpace(values[0]), claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, dpopProofType) {
+			return nil, errors.New("typ должен быть dpop+jwt")
+		}
+		raw, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("нет заголовка jwk")
+		}
+		if _, private := raw["d"]; private {
+			return nil, errors.New("jwk содержит закрытый ключ")
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &jwk); err != nil {
+			return nil, err
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		if !algMatchesKey(token.Method.Alg(), jwksKey{key: key}) {
+			return nil, errors.New("алгоритм не соответствует ключу")
+		}
+		return key, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDPoPProof, err)
+	}
+
+	if err := s.checkDPoPClaims(r, claims, accessToken); err != nil {
+		return "", err
+	}
+	if err := s.consumeDPoPProof(r, claims); err != nil {
+		return "", err
+	}
+	return jwk.Thumbprint()
+}
+
+// checkDPoPClaims сверяет payload доказательства с запросом.
+func (s *AuthService) checkDPoPClaims(r *http.Request, claims *dpopClaims, accessToken string) error {
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return fmt.Errorf("%w: нужны jti и iat", ErrInvalidDPoPProof)
+	}
+	now := s.clock.Now()
+	iat := claims.IssuedAt.Time
+	if iat.After(now.Add(s.leeway)) || now.Sub(iat) > s.dpopMaxAge()+s.leeway {
+		return fmt.Errorf("%w: доказательство устарело или выписано в будущем", ErrInvalidDPoPProof)
+	}
+	if !strings.EqualFold(claims.HTM, r.Method) {
+		return fmt.Errorf("%w: htm не совпадает с методом запроса", ErrInvalidDPoPProof)
+	}
+	if !sameRequestURL(claims.HTU, r) {
+		return fmt.Errorf("%w: htu не совпадает с адресом запроса", ErrInvalidDPoPProof)
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			return fmt.Errorf("%w: ath не совпадает с токеном", ErrInvalidDPoPProof)
+		}
+	}
+	return nil
+}
+
+// consumeDPoPProof не дает повторить доказательство: при подключенном
+// черном списке его jti запоминается на срок годности доказательства.
+func (s *AuthService) consumeDPoPProof(r *http.Request, claims *dpopClaims) error {
+	if s.blacklist == nil {
+		return nil
+	}
+	ctx := requestContext(r)
+	key := "dpop:" + claims.ID
+	used, err := s.blacklist.IsBlacklisted(ctx, key)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки черного списка: %w", err)
+	}
+	if used {
+		return fmt.Errorf("%w: доказательство уже использовано", ErrInvalidDPoPProof)
+	}
+	return s.blacklist.Add(ctx, key, claims.IssuedAt.Add(s.dpopMaxAge()+s.leeway))
+}
+
+// dpopMaxAge - срок годности доказательства (WithDPoP).
+func (s *AuthService) dpopMaxAge() time.Duration {
+	if s.dpopProofAge > 0 {
+		return s.dpopProofAge
+	}
+	return defaultDPoPProofAge
+}
+
+// sameRequestURL сравнивает htu с адресом запроса без query и fragment.
+// Схема берется из TLS соединения или X-Forwarded-Proto (за прокси).
+func sameRequestURL(htu string, r *http.Request) bool {
+	if i := strings.IndexAny(htu, "?#"); i >= 0 {
+		htu = htu[:i]
+	}
+	scheme := "http"
+	switch {
+	case r.TLS != nil:
+		scheme = "https"
+	case r.URL.Scheme != "":
+		scheme = r.URL.Scheme
+	case r.Header.Get("X-Forwarded-Proto") != "":
+		scheme = strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0])
+	}
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// Регистр схемы и хоста не важен, пути - важен
+	origin, ok := strings.CutSuffix(htu, path)
+	return ok && strings.EqualFold(origin, scheme+"://"+host)
+}
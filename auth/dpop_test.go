@@ -0,0 +1,136 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// dpopKey - ключ клиента DPoP.
+type dpopKey struct {
+	priv ed25519.PrivateKey
+	jwk  auth.JWK
+}
+
+func newDPoPKey(t *testing.T) dpopKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dpopKey{priv: priv, jwk: auth.JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)}}
+}
+
+func (k dpopKey) thumbprint(t *testing.T) string {
+	t.Helper()
+	jkt, err := k.jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jkt
+}
+
+// proof подписывает DPoP-доказательство для запроса с ath от accessToken.
+func (k dpopKey) proof(t *testing.T, method, url, accessToken string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(accessToken))
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"htm": method,
+		"htu": url,
+		"ath": base64.RawURLEncoding.EncodeToString(sum[:]),
+		"jti": rand.Text(),
+		"iat": time.Now().Unix(),
+	})
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = map[string]string{"kty": k.jwk.Kty, "crv": k.jwk.Crv, "x": k.jwk.X}
+	signed, err := token.SignedString(k.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidateRequestDPoP(t *testing.T) {
+	const url = "http://api.example.com/orders"
+	encoders := []struct {
+		name    string
+		encoder auth.TokenEncoder
+	}{
+		{"Compact", nil},
+		{"JSONEnvelope", auth.JSONEnvelopeEncoder{}},
+	}
+	for _, enc := range encoders {
+		t.Run(enc.name, func(t *testing.T) {
+			opts := []auth.Option{auth.WithDPoP(0), auth.WithTokenBlacklist(newMemBlacklist())}
+			if enc.encoder != nil {
+				opts = append(opts, auth.WithTokenEncoder(enc.encoder))
+			}
+			svc, _, userID := newTestService(t, opts...)
+			key := newDPoPKey(t)
+
+			issued, err := svc.LoginWithOptions(context.Background(), testEmail, testPassword,
+				auth.TokenOptions{DPoPThumbprint: key.thumbprint(t)})
+			if err != nil {
+				t.Fatalf("LoginWithOptions: %v", err)
+			}
+			compact, err := svc.DecodeToken(issued)
+			if err != nil {
+				t.Fatalf("DecodeToken: %v", err)
+			}
+
+			request := func(proof string) *http.Request {
+				r := httptest.NewRequest(http.MethodGet, url, nil)
+				r.Header.Set("Authorization", "DPoP "+issued)
+				if proof != "" {
+					r.Header.Set("DPoP", proof)
+				}
+				return r
+			}
+
+			// ath считается от компактного JWT, даже если клиент получил конверт
+			claims, err := svc.ValidateRequest(request(key.proof(t, http.MethodGet, url, compact)))
+			if err != nil {
+				t.Fatalf("ValidateRequest с доказательством: %v", err)
+			}
+			if claims.UserID != userID {
+				t.Fatalf("UserID = %d, ожидался %d", claims.UserID, userID)
+			}
+
+			type rejectCase struct{ name, proof string }
+			rejected := []rejectCase{
+				{"без доказательства", ""},
+				{"чужой ключ", newDPoPKey(t).proof(t, http.MethodGet, url, compact)},
+				{"другой метод", key.proof(t, http.MethodPost, url, compact)},
+				{"ath другого токена", key.proof(t, http.MethodGet, url, compact+"x")},
+			}
+			if enc.encoder != nil {
+				rejected = append(rejected, rejectCase{"ath от конверта", key.proof(t, http.MethodGet, url, issued)})
+			}
+			for _, tt := range rejected {
+				if _, err := svc.ValidateRequest(request(tt.proof)); !errors.Is(err, auth.ErrInvalidDPoPProof) {
+					t.Errorf("%s: err = %v, ожидался ErrInvalidDPoPProof", tt.name, err)
+				}
+			}
+
+			// Повтор того же доказательства отклоняется
+			replayed := key.proof(t, http.MethodGet, url, compact)
+			if _, err := svc.ValidateRequest(request(replayed)); err != nil {
+				t.Fatalf("ValidateRequest: %v", err)
+			}
+			if _, err := svc.ValidateRequest(request(replayed)); !errors.Is(err, auth.ErrInvalidDPoPProof) {
+				t.Fatalf("повтор доказательства: err = %v, ожидался ErrInvalidDPoPProof", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// EmailNormalizer приводит email к канонической форме перед обращением
+// к Storage (Login, Register, UserExists, сброс пароля).
+type EmailNormalizer func(email string) string
+
+// NormalizeEmail - нормализация по умолчанию: обрезает пробелы
+// и переводит email в нижний регистр.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeEmailNFC - NormalizeEmail с приведением к Unicode NFC:
+// визуально одинаковые адреса с разной записью диакритики совпадают.
+func NormalizeEmailNFC(email string) string {
+	return NormalizeEmail(norm.NFC.String(email))
+}
+
+// normalizeEmail применяет настроенный EmailNormalizer.
+func (s *AuthService) normalizeEmail(email string) string {
+	if s.emailNormalizer == nil {
+		return email
+	}
+	return s.emailNormalizer(email)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenEncoder задает представление access-токена для клиента
+// (WithTokenEncoder). Encode получает компактный токен и его срок действия,
+// Decode восстанавливает компактный токен из представления. Все токены,
+// которые выдает сервис (Login, ReissueToken, ExchangeToken и т.д.), идут
+// в представлении Encode, а все методы, принимающие access-токен
+// (ParseAndValidateToken, Logout, ValidateAndRefreshIfNeeded, PatchClaims и
+// т.д.), декодируют его сами: передавайте токен в том виде, в каком его
+// получил клиент. DecodeToken нужен только для внешнего кода, которому
+// требуется компактный JWT (TokenFingerprint, сторонние библиотеки).
+// Хэш ath в DPoP-доказательстве (WithDPoP) считается от компактного JWT,
+// как требует RFC 9449, а не от представления Encode: клиент декодирует
+// полученный токен перед подписью доказательства.
+type TokenEncoder interface {
+	Encode(token string, expiresAt time.Time) (string, error)
+	Decode(encoded string) (string, error)
+}
+
+// JSONEnvelopeEncoder - TokenEncoder с самоописывающим конвертом
+// {"token": "...", "expires_at": "2006-01-02T15:04:05Z"} для встраивания
+// в сообщения внутренних протоколов. Decode возвращает строку, которая
+// не похожа на JSON-объект, как есть: клиенты с компактным токеном
+// продолжают работать.
+type JSONEnvelopeEncoder struct{}
+
+var _ TokenEncoder = JSONEnvelopeEncoder{}
+
+// tokenEnvelope - формат JSONEnvelopeEncoder.
+type tokenEnvelope struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Encode реализует TokenEncoder
+func (JSONEnvelopeEncoder) Encode(token string, expiresAt time.Time) (string, error) {
+	data, err := json.Marshal(tokenEnvelope{Token: token, ExpiresAt: expiresAt.UTC().Truncate(time.Second)})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Decode реализует TokenEncoder
+func (JSONEnvelopeEncoder) Decode(encoded string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(encoded), "{") {
+		return encoded, nil
+	}
+	var envelope tokenEnvelope
+	if err := json.Unmarshal([]byte(encoded), &envelope); err != nil {
+		return "", fmt.Errorf("%w: некорректный конверт токена", ErrTokenInvalid)
+	}
+	if envelope.Token == "" {
+		return "", fmt.Errorf("%w: в конверте нет токена", ErrTokenInvalid)
+	}
+	return envelope.Token, nil
+}
+
+// DecodeToken возвращает компактный токен из представления WithTokenEncoder.
+// Без WithTokenEncoder токен возвращается как есть.
+func (s *AuthService) DecodeToken(encoded string) (string, error) {
+	if s.tokenEncoder == nil {
+		return encoded, nil
+	}
+	return s.tokenEncoder.Decode(encoded)
+}
+
+// issueEncoded выпускает токен с готовыми claims (непрозрачный или JWT)
+// в представлении WithTokenEncoder.
+func (s *AuthService) issueEncoded(ctx context.Context, claims *JWTClaims) (string, error) {
+	var tokenString string
+	var err error
+	if s.tokenStore != nil {
+		tokenString, err = s.issueOpaqueToken(ctx, claims)
+	} else {
+		tokenString, err = s.sign(ctx, *claims)
+	}
+	if err != nil {
+		return "", err
+	}
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return s.encodeToken(tokenString, expiresAt)
+}
+
+// encodeToken переводит выпущенный токен в представление WithTokenEncoder.
+func (s *AuthService) encodeToken(token string, expiresAt time.Time) (string, error) {
+	if s.tokenEncoder == nil {
+		return token, nil
+	}
+	encoded, err := s.tokenEncoder.Encode(token, expiresAt)
+	if err != nil {
+		return "", fmt.Errorf("ошибка кодирования токена: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,178 @@
+package auth
+
+import "errors"
+
+// ----------------------------------------------------------------------
+// Ошибки пакета
+// Сравнивайте с ними через errors.Is.
+// ----------------------------------------------------------------------
+
+var (
+	// ErrInvalidCredentials - неверный email или пароль.
+	ErrInvalidCredentials = errors.New("неверные учетные данные")
+	// ErrWeakPassword - пароль не соответствует политике (PasswordPolicy).
+	ErrWeakPassword = errors.New("пароль не соответствует требованиям")
+	// ErrPasswordTooLong - пароль длиннее 72 байт, которые учитывает bcrypt
+	// (см. WithLongPasswords).
+	ErrPasswordTooLong = errors.New("пароль слишком длинный")
+	// ErrPasswordReused - новый пароль совпадает с одним из недавних.
+	ErrPasswordReused = errors.New("пароль недавно использовался")
+	// ErrPasswordBreached - пароль найден в известных утечках (WithBreachChecker).
+	ErrPasswordBreached = errors.New("пароль найден в утечках данных")
+	// ErrEmailNotVerified - email пользователя не подтвержден.
+	ErrEmailNotVerified = errors.New("email не подтвержден")
+	// ErrPasswordExpired - срок действия пароля истек (WithPasswordMaxAge,
+	// PasswordExpiryBlock), подробности - в *PasswordExpiredError.
+	ErrPasswordExpired = errors.New("срок действия пароля истек")
+	// ErrWrongTokenPurpose - токен выпущен для другой цели
+	// (например, токен сброса пароля вместо access-токена).
+	ErrWrongTokenPurpose = errors.New("токен выпущен для другой цели")
+	// ErrWrongTokenType - заголовок typ токена не соответствует месту
+	// проверки (например, токен сброса пароля вместо access-токена).
+	ErrWrongTokenType = errors.New("неверный тип токена")
+	// ErrTokenAlreadyUsed - одноразовый токен уже использован.
+	ErrTokenAlreadyUsed = errors.New("токен уже использован")
+	// ErrReservedPurpose - назначение пусто или занято служебными токенами пакета.
+	ErrReservedPurpose = errors.New("недопустимое назначение токена")
+	// ErrInvalidDPoPProof - нет или неверно DPoP-доказательство для токена,
+	// привязанного к ключу клиента (WithDPoP).
+	ErrInvalidDPoPProof = errors.New("неверное DPoP-доказательство")
+	// ErrUserDisabled - аккаунт владельца токена заблокирован или удален
+	// (WithUserStatusResolver).
+	ErrUserDisabled = errors.New("аккаунт пользователя отключен")
+	// ErrReauthRequired - вход слишком давний для этого действия (RequireFreshAuth).
+	ErrReauthRequired = errors.New("требуется повторный вход")
+	// ErrProtectedClaim - PatchClaims не может изменить это поле токена.
+	ErrProtectedClaim = errors.New("поле токена нельзя изменить")
+	// ErrTOTPRequired - у пользователя включена 2FA, нужен вход через LoginWith2FA.
+	ErrTOTPRequired = errors.New("требуется код двухфакторной аутентификации")
+	// ErrMFARequired - оценка риска требует второй фактор (см. MFARequiredError).
+	ErrMFARequired = errors.New("требуется двухфакторная аутентификация")
+	// ErrTOTPUnsupported - Storage не реализует TOTPStorage.
+	ErrTOTPUnsupported = errors.New("хранилище не поддерживает подключение 2FA")
+	// ErrInvalidTOTPCode - неверный код двухфакторной аутентификации.
+	ErrInvalidTOTPCode = errors.New("неверный код двухфакторной аутентификации")
+	// ErrTokenExpired - срок действия токена истек (можно попробовать Refresh).
+	ErrTokenExpired = errors.New("срок действия токена истек")
+	// ErrTokenNotYetValid - токен еще не начал действовать (nbf в будущем).
+	ErrTokenNotYetValid = errors.New("токен еще не действует")
+	// ErrTokenIssuedInFuture - iat токена в будущем (WithRejectFutureIssuedAt).
+	ErrTokenIssuedInFuture = errors.New("токен выпущен в будущем")
+	// ErrInvalidTokenOptions - некорректный срок действия в TokenOptions
+	// или пустой список аудиторий LoginMultiAudience.
+	ErrInvalidTokenOptions = errors.New("некорректные параметры токена")
+	// ErrTokenInvalid - токен поврежден, подделан или не прошел проверку.
+	ErrTokenInvalid = errors.New("токен недействителен")
+	// ErrUnexpectedSigningMethod - токен подписан не тем алгоритмом.
+	ErrUnexpectedSigningMethod = errors.New("неожиданный метод подписи")
+	// ErrSigningFailed - не удалось подписать токен.
+	ErrSigningFailed = errors.New("ошибка подписи токена")
+	// ErrTokenTooLarge - предъявленный токен больше WithMaxTokenBytes
+	// (возвращается вместе с ErrTokenInvalid).
+	ErrTokenTooLarge = errors.New("слишком большой токен")
+	// ErrTokenOverBudget - выпускаемый токен больше WithTokenSizeBudget.
+	ErrTokenOverBudget = errors.New("токен превышает допустимый размер")
+	// ErrIDGeneration - IDGenerator не смог выдать идентификатор токена.
+	ErrIDGeneration = errors.New("ошибка генерации идентификатора токена")
+	// ErrUnknownKeyID - токен подписан ключом с неизвестным kid.
+	ErrUnknownKeyID = errors.New("неизвестный идентификатор ключа (kid)")
+	// ErrInvalidIssuer - токен выпущен другим издателем (iss).
+	ErrInvalidIssuer = errors.New("токен выпущен другим издателем")
+	// ErrNoPublicKeys - JWKS недоступен: сервис использует симметричный ключ.
+	ErrNoPublicKeys = errors.New("нет публичных ключей для JWKS")
+	// ErrUnknownTenant - арендатор неизвестен или для него нет ключей.
+	ErrUnknownTenant = errors.New("неизвестный арендатор")
+	// ErrInvalidAudience - токен выпущен для другого сервиса (aud).
+	ErrInvalidAudience = errors.New("токен выпущен для другой аудитории")
+	// ErrRateLimited - слишком много попыток входа, повторите позже.
+	ErrRateLimited = errors.New("слишком много попыток, повторите позже")
+	// ErrInvalidConfig - некорректный Config (NewAuthServiceFromConfig).
+	ErrInvalidConfig = errors.New("некорректная конфигурация")
+	// ErrAuditChainBroken - цепочка записей аудита нарушена (VerifyAuditChain).
+	ErrAuditChainBroken = errors.New("цепочка записей аудита нарушена")
+	// ErrWeakSecret - HMAC-секрет слишком короткий.
+	ErrWeakSecret = errors.New("слишком короткий секретный ключ")
+	// ErrMalformedHash - сохраненный хэш пароля поврежден или в неизвестном формате.
+	ErrMalformedHash = errors.New("некорректный хэш пароля")
+	// ErrSigningKeyUnavailable - SecretProvider не смог вернуть ключ.
+	ErrSigningKeyUnavailable = errors.New("ключ подписи недоступен")
+	// ErrAccountLocked - аккаунт временно заблокирован после серии неудачных входов.
+	ErrAccountLocked = errors.New("аккаунт временно заблокирован")
+	// ErrUserNotFound - пользователь не найден.
+	// Storage должен возвращать ее (или оборачивать), чтобы сервис мог
+	// отличить отсутствие пользователя от сбоя БД.
+	ErrUserNotFound = errors.New("пользователь не найден")
+	// ErrUserAlreadyExists - пользователь с таким email уже зарегистрирован.
+	ErrUserAlreadyExists = errors.New("пользователь уже существует")
+	// ErrEmptyEmail - email не указан.
+	ErrEmptyEmail = errors.New("email не может быть пустым")
+	// ErrTokenRevoked - токен отозван (находится в черном списке).
+	ErrTokenRevoked = errors.New("токен отозван")
+	// ErrMissingTokenID - в токене нет идентификатора (jti).
+	ErrMissingTokenID = errors.New("токен не содержит идентификатор (jti)")
+	// ErrMissingToken - в запросе нет токена авторизации.
+	ErrMissingToken = errors.New("отсутствует токен авторизации")
+	// ErrMultipleAuthHeaders - в запросе несколько заголовков Authorization.
+	ErrMultipleAuthHeaders = errors.New("несколько заголовков Authorization")
+	// ErrSessionNotFound - сессия не найдена (возвращается SessionStore.Get).
+	ErrSessionNotFound = errors.New("сессия не найдена")
+	// ErrSessionRevoked - сессия токена завершена (выход на другом устройстве и т.п.).
+	ErrSessionRevoked = errors.New("сессия завершена")
+	// ErrSessionsUnsupported - хранилище сессий не настроено.
+	ErrSessionsUnsupported = errors.New("хранилище сессий не настроено")
+	// ErrTokenVersioningUnsupported - версии токенов не включены или Storage
+	// не реализует TokenVersionStorage.
+	ErrTokenVersioningUnsupported = errors.New("версии токенов не поддерживаются")
+	// ErrLogoutUnsupported - токен нечем отозвать: нет ни черного списка,
+	// ни сессий (WithStrictLogout).
+	ErrLogoutUnsupported = errors.New("выход не настроен: токен нельзя отозвать")
+	// ErrLogoutAllUnsupported - для LogoutAll нужен WithTokenVersioning или WithSessionStore.
+	ErrLogoutAllUnsupported = errors.New("выход на всех устройствах не настроен")
+	// ErrStorageTimeout - вызов Storage не уложился в WithStorageTimeout.
+	ErrStorageTimeout = errors.New("превышено время ожидания хранилища")
+	// ErrIPMismatch - токен привязан к другому IP (WithIPBinding).
+	ErrIPMismatch = errors.New("токен выпущен для другого IP-адреса")
+	// ErrBlacklistUnsupported - операции нужен черный список (WithTokenBlacklist).
+	ErrBlacklistUnsupported = errors.New("черный список токенов не настроен")
+	// ErrTokenNotFound - запись токена не найдена (возвращается TokenStore.Lookup).
+	ErrTokenNotFound = errors.New("токен не найден")
+	// ErrRefreshUnsupported - хранилище refresh-токенов не настроено.
+	ErrRefreshUnsupported = errors.New("хранилище refresh-токенов не настроено")
+	// ErrWebAuthnUnsupported - вход по passkey не настроен (WithWebAuthn).
+	ErrWebAuthnUnsupported = errors.New("WebAuthn не настроен")
+	// ErrCeremonyNotFound - церемония WebAuthn не найдена, истекла или уже завершена.
+	ErrCeremonyNotFound = errors.New("церемония WebAuthn не найдена")
+	// ErrPasskeyInvalid - ответ аутентификатора не прошел проверку.
+	ErrPasskeyInvalid = errors.New("ответ passkey недействителен")
+	// ErrRefreshNotFound - refresh-токен не найден (возвращается RefreshStore.Lookup).
+	ErrRefreshNotFound = errors.New("refresh-токен не найден")
+	// ErrRefreshInvalid - refresh-токен неизвестен или просрочен.
+	ErrRefreshInvalid = errors.New("refresh-токен недействителен")
+	// ErrRefreshReused - повторное использование уже отозванного refresh-токена.
+	// Вероятная кража токена.
+	ErrRefreshReused = errors.New("повторное использование refresh-токена")
+	// ErrRefreshBindingMismatch - refresh-токен предъявлен не тем клиентом,
+	// которому выдан (WithRefreshBinding).
+	ErrRefreshBindingMismatch = errors.New("refresh-токен предъявлен с другого устройства")
+	// ErrTokenExchangeUnsupported - обмен токенов не настроен (WithTokenExchange).
+	ErrTokenExchangeUnsupported = errors.New("обмен токенов не настроен")
+	// ErrTooManyTokens - у пользователя уже максимум активных токенов
+	// (WithMaxActiveTokensPerUser с TokenLimitReject).
+	ErrTooManyTokens = errors.New("слишком много активных токенов")
+	// ErrScopeEscalation - при обмене запрошен scope, которого нет в исходном токене.
+	ErrScopeEscalation = errors.New("запрошенный scope шире исходного токена")
+)
+
+// Ошибки HTTP- и gRPC-оберток (их текст тоже локализуется, см. ErrorFormatter).
+var (
+	// ErrCSRFMismatch - CSRF-токен запроса отсутствует или неверен.
+	ErrCSRFMismatch = errors.New("неверный CSRF-токен")
+	// ErrForbidden - у владельца токена нет нужной роли или scope.
+	ErrForbidden = errors.New("недостаточно прав")
+	// ErrMalformedRequest - тело запроса не разбирается или неполно.
+	ErrMalformedRequest = errors.New("некорректное тело запроса")
+	// ErrMethodNotAllowed - HTTP-метод не поддерживается обработчиком.
+	ErrMethodNotAllowed = errors.New("метод не поддерживается")
+	// ErrInternal - внутренняя ошибка; подробности клиенту не раскрываются.
+	ErrInternal = errors.New("внутренняя ошибка сервера")
+)
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// EventType - тип события аутентификации.
+type EventType string
+
+const (
+	EventLoginSuccess EventType = "login_success"
+	EventLoginFailure EventType = "login_failure"
+	EventLogout       EventType = "logout"
+	EventRefresh      EventType = "refresh"
+	// EventRefreshReused - предъявлен уже использованный refresh-токен
+	// (вероятная кража); семья токенов отозвана.
+	EventRefreshReused EventType = "refresh_reused"
+	// EventImpersonation - администратор (ActorID) получил токен
+	// пользователя (UserID), см. IssueImpersonationToken.
+	EventImpersonation EventType = "impersonation"
+)
+
+// Event - событие аутентификации для аудита/SIEM.
+type Event struct {
+	Type   EventType
+	UserID int64  // 0, если пользователь не определен
+	Email  string // пусто, если неизвестен
+	Reason string // причина неудачи (ErrInvalidCredentials и т.д.)
+	// ActorID - администратор, действующий от имени UserID (EventImpersonation)
+	ActorID int64
+	Time    time.Time
+
+	// Сведения о клиенте из WithRequestMeta; пусто, если не переданы
+	IP        string
+	UserAgent string
+}
+
+// EventHook получает события аутентификации.
+// Вызывается асинхронно: не блокирует вход, паника перехватывается.
+type EventHook func(ctx context.Context, e Event)
+
+// emit отправляет событие в EventHook, если он задан.
+// Событие также пишется в журнал (WithLogger).
+func (s *AuthService) emit(ctx context.Context, e Event) {
+	s.logEvent(ctx, e)
+	if s.eventHook == nil {
+		return
+	}
+	e.Time = s.clock.Now()
+	if meta, ok := RequestMetaFromContext(ctx); ok {
+		e.IP, e.UserAgent = meta.IP, meta.UserAgent
+	}
+
+	// Хук не должен зависеть от отмены запроса
+	ctx = context.WithoutCancel(ctx)
+	hook := s.eventHook
+
+	s.hooks.Add(1)
+	go func() {
+		defer s.hooks.Done()
+		defer func() { _ = recover() }()
+		hook(ctx, e)
+	}()
+}
+
+// emitLoginFailure отправляет событие неудачного входа.
+func (s *AuthService) emitLoginFailure(ctx context.Context, email string, err error) {
+	s.emit(ctx, Event{Type: EventLoginFailure, Email: email, Reason: err.Error()})
+}
+
+// Close (Завершение работы)
+// Дожидается обработки отправленных событий (EventHook), чтобы при
+// плавной остановке не потерять записи аудита. Возвращает ctx.Err(),
+// если ctx завершился раньше. Без фоновых задач возвращает nil сразу.
+// Вызывайте после остановки приема запросов: события, отправленные
+// во время Close, тоже учитываются.
+func (s *AuthService) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.hooks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ExchangeToken (Обмен токена, RFC 8693)
+// Проверяет токен пользователя subjectToken, пришедший в этот сервис,
+// и выпускает на его основе токен для вызова сервиса audience от имени
+// пользователя. requestedScopes должны быть подмножеством scope исходного
+// токена (иначе ErrScopeEscalation); пустой список оставляет scope как
+// есть. В новом токене aud - audience, act.sub - WithTokenExchange,
+// прежний act (имперсонация, предыдущий обмен) вкладывается в act.act.
+// Пользователь, сессия, версия токенов и роли переносятся без обращения
+// к Storage; jti новый, срок - не дольше исходного. Привязки к клиенту
+// (cnf, WithIPBinding) снимаются: токен предъявляет сервис, а не клиент,
+// поэтому передавайте его только по внутренней сети. ReissueToken
+// для такого токена возвращает ErrForbidden.
+func (s *AuthService) ExchangeToken(ctx context.Context, subjectToken string, requestedScopes []string, audience string) (string, error) {
+	if s.exchangeServiceID == "" {
+		return "", ErrTokenExchangeUnsupported
+	}
+	if audience == "" {
+		return "", ErrInvalidTokenOptions
+	}
+
+	subject, err := s.ParseAndValidateTokenContext(ctx, subjectToken)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := subject.Scopes
+	if len(requestedScopes) > 0 {
+		for _, scope := range requestedScopes {
+			if !slices.Contains(subject.Scopes, scope) {
+				return "", ErrScopeEscalation
+			}
+		}
+		scopes = slices.Compact(slices.Sorted(slices.Values(requestedScopes)))
+	}
+
+	jti, err := s.newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := s.clock.Now()
+	var expiresAt *jwt.NumericDate
+	if s.exchangeTTL > 0 {
+		expiresAt = jwt.NewNumericDate(now.Add(s.exchangeTTL))
+	}
+	if subject.ExpiresAt != nil && (expiresAt == nil || subject.ExpiresAt.Before(expiresAt.Time)) {
+		expiresAt = subject.ExpiresAt
+	}
+	if expiresAt == nil {
+		expiresAt = jwt.NewNumericDate(now.Add(s.clampTTL(0)))
+	}
+
+	claims := *subject
+	claims.Scopes = scopes
+	claims.Actor = &Actor{Subject: s.exchangeServiceID, Actor: subject.Actor}
+	claims.IPHash = ""
+	claims.Confirmation = nil
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Subject:   subject.Subject,
+		Audience:  jwt.ClaimStrings{audience},
+		ID:        jti,
+		ExpiresAt: expiresAt,
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	return s.issueEncoded(ctx, &claims)
+}
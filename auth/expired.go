@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ParseAllowExpired (Чтение истекшего токена)
+// Проверяет подпись и стандартные поля токена (aud, iss, nbf), но не срок
+// действия, и возвращает claims - например, чтобы шлюз по UserID решил,
+// стоит ли пробовать Refresh. Неверная подпись по-прежнему дает ошибку.
+//
+// ВНИМАНИЕ: результат не подтверждает аутентификацию. Черный список,
+// сессии и версии токенов не проверяются; не принимайте по этим claims
+// решений о доступе - для этого есть ParseAndValidateToken.
+func (s *AuthService) ParseAllowExpired(tokenString string) (*JWTClaims, error) {
+	return s.ParseAllowExpiredContext(context.Background(), tokenString)
+}
+
+// ParseAllowExpiredContext - ParseAllowExpired с контекстом.
+func (s *AuthService) ParseAllowExpiredContext(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTokenSize(tokenString); err != nil {
+		return nil, err
+	}
+
+	var claims *JWTClaims
+	if s.isOpaqueToken(tokenString) {
+		c, err := s.lookupOpaqueToken(ctx, tokenString)
+		if err != nil && !errors.Is(err, ErrTokenExpired) {
+			return nil, err
+		}
+		claims = c
+	} else {
+		claims = &JWTClaims{}
+		opts := append(s.parserOptions(), jwt.WithoutClaimsValidation())
+		token, err := s.parseToken(ctx, jwt.NewParser(opts...), nil, tokenString, claims)
+		if err != nil {
+			return nil, mapParseError(err)
+		}
+		if err := s.checkTokenType(token, s.accessTokenType()); err != nil {
+			return nil, err
+		}
+		backfillUserID(claims)
+
+		// Все стандартные проверки, кроме exp
+		withoutExp := *claims
+		withoutExp.ExpiresAt = nil
+		if err := jwt.NewValidator(s.parserOptions()...).Validate(&withoutExp); err != nil {
+			return nil, mapParseError(err)
+		}
+		if err := s.checkTrustedAudience(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if isPurposeToken(claims) {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
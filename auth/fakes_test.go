@@ -0,0 +1,349 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+// testSecret - секрет HS256 тестовых сервисов (32 байта).
+var testSecret = []byte(strings.Repeat("s", 32))
+
+const (
+	testEmail    = "user@example.com"
+	testPassword = "Passw0rd!Secure"
+)
+
+// newTestService создает сервис с одним пользователем testEmail/testPassword.
+func newTestService(t testing.TB, opts ...auth.Option) (*auth.AuthService, *memory.InMemoryStorage, int64) {
+	t.Helper()
+	storage := memory.NewInMemoryStorage()
+	userID, err := storage.SeedUser(testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("SeedUser: %v", err)
+	}
+	svc, err := auth.NewAuthService(storage, testSecret, time.Hour, opts...)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	return svc, storage, userID
+}
+
+// fakeClock - управляемые часы для WithClock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now().Truncate(time.Second)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// memBlacklist - auth.TokenBlacklist и auth.Sweepable в памяти.
+type memBlacklist struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newMemBlacklist() *memBlacklist {
+	return &memBlacklist{entries: make(map[string]time.Time)}
+}
+
+func (b *memBlacklist) Add(ctx context.Context, jti string, exp time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[jti] = exp
+	return nil
+}
+
+func (b *memBlacklist) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.entries[jti]
+	return ok, nil
+}
+
+func (b *memBlacklist) Sweep(ctx context.Context, now time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	removed := 0
+	for jti, exp := range b.entries {
+		if !exp.IsZero() && exp.Before(now) {
+			delete(b.entries, jti)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (b *memBlacklist) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// memRefreshStore - auth.RefreshStore в памяти.
+type memRefreshStore struct {
+	mu     sync.Mutex
+	tokens map[string]auth.RefreshToken
+}
+
+func newMemRefreshStore() *memRefreshStore {
+	return &memRefreshStore{tokens: make(map[string]auth.RefreshToken)}
+}
+
+func (s *memRefreshStore) Save(ctx context.Context, token auth.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Token] = token
+	return nil
+}
+
+func (s *memRefreshStore) Lookup(ctx context.Context, token string) (auth.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.tokens[token]
+	if !ok {
+		return auth.RefreshToken{}, auth.ErrRefreshNotFound
+	}
+	return rt, nil
+}
+
+func (s *memRefreshStore) Revoke(ctx context.Context, token string) error {
+	return s.revokeWhere(func(rt auth.RefreshToken) bool { return rt.Token == token })
+}
+
+func (s *memRefreshStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return s.revokeWhere(func(rt auth.RefreshToken) bool { return rt.UserID == userID })
+}
+
+func (s *memRefreshStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.revokeWhere(func(rt auth.RefreshToken) bool { return rt.FamilyID == familyID })
+}
+
+func (s *memRefreshStore) revokeWhere(match func(auth.RefreshToken) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, rt := range s.tokens {
+		if match(rt) {
+			rt.Revoked = true
+			s.tokens[key] = rt
+		}
+	}
+	return nil
+}
+
+// memActiveTokens - auth.ActiveTokenStore в памяти.
+type memActiveTokens struct {
+	mu     sync.Mutex
+	tokens map[int64][]auth.ActiveToken
+}
+
+func newMemActiveTokens() *memActiveTokens {
+	return &memActiveTokens{tokens: make(map[int64][]auth.ActiveToken)}
+}
+
+func (s *memActiveTokens) Track(ctx context.Context, userID int64, token auth.ActiveToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[userID] = append(s.tokens[userID], token)
+	return nil
+}
+
+func (s *memActiveTokens) ListActive(ctx context.Context, userID int64, now time.Time) ([]auth.ActiveToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var active []auth.ActiveToken
+	for _, token := range s.tokens[userID] {
+		if token.ExpiresAt.After(now) {
+			active = append(active, token)
+		}
+	}
+	return active, nil
+}
+
+func (s *memActiveTokens) Remove(ctx context.Context, userID int64, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.tokens[userID]
+	for i, token := range list {
+		if token.ID == jti {
+			s.tokens[userID] = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memActiveTokens) RemoveAll(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, userID)
+	return nil
+}
+
+// memSessions - auth.SessionStore и auth.Sweepable в памяти. Sweep удаляет
+// сессии без активности дольше idle.
+type memSessions struct {
+	mu       sync.Mutex
+	sessions map[string]auth.Session
+	idle     time.Duration
+}
+
+func newMemSessions(idle time.Duration) *memSessions {
+	return &memSessions{sessions: make(map[string]auth.Session), idle: idle}
+}
+
+func (s *memSessions) Create(ctx context.Context, session auth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *memSessions) Get(ctx context.Context, sessionID string) (auth.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return auth.Session{}, auth.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *memSessions) ListByUser(ctx context.Context, userID int64) ([]auth.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []auth.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			list = append(list, session)
+		}
+	}
+	return list, nil
+}
+
+func (s *memSessions) Touch(ctx context.Context, sessionID string, lastSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[sessionID]; ok {
+		session.LastSeen = lastSeen
+		s.sessions[sessionID] = session
+	}
+	return nil
+}
+
+func (s *memSessions) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+func (s *memSessions) Sweep(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, session := range s.sessions {
+		if now.Sub(session.LastSeen) > s.idle {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// memLoginAttempts - auth.LoginAttemptStore в памяти.
+type memLoginAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]auth.LoginAttempts
+}
+
+func newMemLoginAttempts() *memLoginAttempts {
+	return &memLoginAttempts{attempts: make(map[string]auth.LoginAttempts)}
+}
+
+func (s *memLoginAttempts) Get(ctx context.Context, email string) (auth.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[email], nil
+}
+
+func (s *memLoginAttempts) RecordFailure(ctx context.Context, email string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.attempts[email]
+	if a.Count == 0 {
+		a.FirstFailure = at
+	}
+	a.Count++
+	a.LastFailure = at
+	s.attempts[email] = a
+	return nil
+}
+
+func (s *memLoginAttempts) Reset(ctx context.Context, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, email)
+	return nil
+}
+
+// memPasswordHistory - auth.PasswordHistoryStore в памяти.
+type memPasswordHistory struct {
+	mu     sync.Mutex
+	hashes map[int64][]string
+}
+
+func newMemPasswordHistory() *memPasswordHistory {
+	return &memPasswordHistory{hashes: make(map[int64][]string)}
+}
+
+func (s *memPasswordHistory) Recent(ctx context.Context, userID int64, limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.hashes[userID]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return append([]string(nil), list...), nil
+}
+
+func (s *memPasswordHistory) Push(ctx context.Context, userID int64, passwordHash string, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := append([]string{passwordHash}, s.hashes[userID]...)
+	if len(list) > keep {
+		list = list[:keep]
+	}
+	s.hashes[userID] = list
+	return nil
+}
+
+// failingStorage - Storage, у которого GetUserByEmail всегда возвращает err
+// (сбой или таймаут хранилища).
+type failingStorage struct {
+	*memory.InMemoryStorage
+	err error
+}
+
+func (s *failingStorage) GetUserByEmail(ctx context.Context, email string) (auth.UserIn, error) {
+	return nil, s.err
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// unverifiedExpired читает exp из payload без проверки подписи
+// (WithExpiredFastPath). Данным payload здесь не доверяют ни для чего,
+// кроме досрочного отказа: подделанный exp может только отклонить токен,
+// а не пропустить его - полная проверка выполняется в любом случае.
+func (s *AuthService) unverifiedExpired(tokenString string) bool {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	var claims struct {
+		ExpiresAt *jwt.NumericDate `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == nil {
+		return false
+	}
+	return s.clock.Now().After(claims.ExpiresAt.Add(s.leeway))
+}
@@ -0,0 +1,30 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+)
+
+func BenchmarkExpiredToken(b *testing.B) {
+	token := authtest.ExpiredToken(auth.JWTClaims{UserID: 1, Email: testEmail}, testSecret)
+	for _, bm := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"FastPath", true},
+		{"SignatureFirst", false},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			svc, _, _ := newTestService(b, auth.WithExpiredFastPath(bm.enabled))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := svc.ParseAndValidateToken(token); !errors.Is(err, auth.ErrTokenExpired) {
+					b.Fatalf("err = %v, ожидался ErrTokenExpired", err)
+				}
+			}
+		})
+	}
+}
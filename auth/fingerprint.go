@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// TokenFingerprint (Отпечаток токена)
+// Возвращает короткий необратимый отпечаток токена: первые 16 байт
+// SHA-256 в hex (32 символа). Один и тот же токен всегда дает один и тот
+// же отпечаток, а восстановить по нему токен нельзя - его безопасно
+// писать в журналы и передавать в запросах на отзыв (RevokeFingerprint).
+// Передавайте компактный токен (см. DecodeToken).
+func TokenFingerprint(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:16])
+}
+
+// fingerprintPrefix отделяет отпечатки в черном списке от jti.
+const fingerprintPrefix = "fp:"
+
+// blacklistKey - ключ токена в черном списке: jti, а для токена
+// без jti - его отпечаток.
+func blacklistKey(claims *JWTClaims, tokenString string) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+	return fingerprintPrefix + TokenFingerprint(tokenString)
+}
+
+// RevokeFingerprint (Отзыв по отпечатку)
+// Заносит в черный список токен без jti (выпущенный до его появления)
+// по отпечатку TokenFingerprint: такой токен перестает проходить проверку.
+// Токены с jti проверяются только по jti - отзывайте их через Logout.
+// until - срок хранения записи, не раньше exp токена; нулевое время -
+// как для токена без exp в Logout. Нужен WithTokenBlacklist, иначе
+// ErrBlacklistUnsupported.
+func (s *AuthService) RevokeFingerprint(ctx context.Context, fingerprint string, until time.Time) error {
+	if s.blacklist == nil {
+		return ErrBlacklistUnsupported
+	}
+	if fingerprint == "" {
+		return ErrTokenInvalid
+	}
+	return s.blacklist.Add(ctx, fingerprintPrefix+fingerprint, until)
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// RequireFreshAuth (Проверка свежести входа)
+// Проверяет токен и требует, чтобы пользователь вводил пароль (или другой
+// фактор) не раньше чем maxAge назад по claim auth_time - как max_age
+// в OIDC. Нужна перед чувствительными действиями: сменой email, удалением
+// аккаунта. Устаревший вход и токен без auth_time (выпущенный до его
+// появления или токен имперсонации) дают ErrReauthRequired: клиенту нужно
+// снова войти. Обновление через refresh-токен auth_time не меняет.
+func (s *AuthService) RequireFreshAuth(tokenString string, maxAge time.Duration) (*JWTClaims, error) {
+	return s.RequireFreshAuthContext(context.Background(), tokenString, maxAge)
+}
+
+// RequireFreshAuthContext - то же, что RequireFreshAuth, с контекстом.
+func (s *AuthService) RequireFreshAuthContext(ctx context.Context, tokenString string, maxAge time.Duration) (*JWTClaims, error) {
+	claims, err := s.ParseAndValidateTokenContext(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.AuthTime == nil || s.clock.Now().Sub(claims.AuthTime.Time) > maxAge {
+		return nil, ErrReauthRequired
+	}
+	return claims, nil
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config - параметры AuthService для NewAuthServiceFromConfig в виде,
+// удобном для JSON или YAML (теги json и yaml совпадают).
+//
+//	{
+//	  "algorithm": "HS256",
+//	  "secret_env": "JWT_SECRET",
+//	  "token_ttl": "15m",
+//	  "refresh_ttl": "720h",
+//	  "issuer": "https://auth.example.com",
+//	  "audience": ["api"],
+//	  "leeway": "30s"
+//	}
+type Config struct {
+	// Algorithm - "HS256" (по умолчанию) или "RS256".
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+
+	// Secret - HMAC-секрет для HS256 в base64 (см. SecretFromEnv).
+	// Вместо секрета в файле лучше задать SecretEnv.
+	Secret string `json:"secret" yaml:"secret"`
+	// SecretEnv - имя переменной окружения с секретом для HS256.
+	SecretEnv string `json:"secret_env" yaml:"secret_env"`
+
+	// PrivateKeyPath и PublicKeyPath - PEM-ключи для RS256
+	// (см. LoadRSAPrivateKeyFromPEM). Без закрытого ключа сервис только
+	// проверяет токены; открытый ключ по умолчанию берется из закрытого.
+	PrivateKeyPath string `json:"private_key_path" yaml:"private_key_path"`
+	PublicKeyPath  string `json:"public_key_path" yaml:"public_key_path"`
+	KeyID          string `json:"key_id" yaml:"key_id"` // WithKeyID
+
+	TokenTTL   Duration `json:"token_ttl" yaml:"token_ttl"`     // обязателен
+	RefreshTTL Duration `json:"refresh_ttl" yaml:"refresh_ttl"` // 0 - 30 дней
+	Leeway     Duration `json:"leeway" yaml:"leeway"`           // WithLeeway
+	BcryptCost int      `json:"bcrypt_cost" yaml:"bcrypt_cost"` // 0 - bcrypt.DefaultCost
+
+	Issuer   string   `json:"issuer" yaml:"issuer"`     // WithIssuer
+	Audience []string `json:"audience" yaml:"audience"` // WithAudience
+}
+
+// Duration - time.Duration, которая читается из строки вида "15m"
+// или "1h30m" (JSON и YAML). В JSON допускается и число секунд.
+type Duration time.Duration
+
+// UnmarshalText разбирает строку в формате time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return fmt.Errorf("некорректная длительность %q: %w", text, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// UnmarshalJSON принимает строку ("15m") или число секунд.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("длительность должна быть строкой или числом секунд: %s", data)
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+// MarshalText записывает длительность строкой ("15m0s").
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// NewAuthServiceFromConfig создает AuthService по Config: проверяет
+// сочетание параметров (ключи для выбранного алгоритма, срок жизни
+// токена) и возвращает ошибку с понятным описанием, обернутую
+// в ErrInvalidConfig. opts применяются после параметров Config.
+func NewAuthServiceFromConfig(cfg Config, storage Storage, opts ...Option) (*AuthService, error) {
+	if cfg.TokenTTL <= 0 {
+		return nil, fmt.Errorf("%w: token_ttl должен быть больше нуля", ErrInvalidConfig)
+	}
+	if cfg.RefreshTTL < 0 || cfg.Leeway < 0 {
+		return nil, fmt.Errorf("%w: refresh_ttl и leeway не могут быть отрицательными", ErrInvalidConfig)
+	}
+
+	options := cfg.options()
+	options = append(options, opts...)
+	ttl := time.Duration(cfg.TokenTTL)
+
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", "HS256":
+		if cfg.PrivateKeyPath != "" || cfg.PublicKeyPath != "" {
+			return nil, fmt.Errorf("%w: для HS256 ключи RSA не используются, задайте secret или secret_env", ErrInvalidConfig)
+		}
+		secret, err := cfg.secret()
+		if err != nil {
+			return nil, err
+		}
+		return NewAuthService(storage, secret, ttl, options...)
+	case "RS256":
+		if cfg.Secret != "" || cfg.SecretEnv != "" {
+			return nil, fmt.Errorf("%w: для RS256 секрет не используется, задайте private_key_path", ErrInvalidConfig)
+		}
+		if cfg.PrivateKeyPath == "" && cfg.PublicKeyPath == "" {
+			return nil, fmt.Errorf("%w: для RS256 нужен private_key_path или public_key_path", ErrInvalidConfig)
+		}
+		return newRS256FromConfig(cfg, storage, ttl, options)
+	default:
+		return nil, fmt.Errorf("%w: неподдерживаемый алгоритм %q (HS256 или RS256)", ErrInvalidConfig, cfg.Algorithm)
+	}
+}
+
+// options переводит необязательные поля Config в опции.
+func (cfg Config) options() []Option {
+	var opts []Option
+	if cfg.RefreshTTL > 0 {
+		opts = append(opts, WithRefreshTTL(time.Duration(cfg.RefreshTTL)))
+	}
+	if cfg.Leeway > 0 {
+		opts = append(opts, WithLeeway(time.Duration(cfg.Leeway)))
+	}
+	if cfg.BcryptCost != 0 {
+		opts = append(opts, WithBcryptCost(cfg.BcryptCost))
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, WithIssuer(cfg.Issuer))
+	}
+	if len(cfg.Audience) > 0 {
+		opts = append(opts, WithAudience(cfg.Audience...))
+	}
+	if cfg.KeyID != "" {
+		opts = append(opts, WithKeyID(cfg.KeyID))
+	}
+	return opts
+}
+
+// secret возвращает HMAC-секрет из Secret или SecretEnv.
+func (cfg Config) secret() ([]byte, error) {
+	switch {
+	case cfg.Secret != "" && cfg.SecretEnv != "":
+		return nil, fmt.Errorf("%w: задайте только одно из secret и secret_env", ErrInvalidConfig)
+	case cfg.SecretEnv != "":
+		secret, err := SecretFromEnv(cfg.SecretEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+		return secret, nil
+	case cfg.Secret != "":
+		secret, err := decodeBase64Secret(strings.TrimSpace(cfg.Secret))
+		if err != nil {
+			return nil, fmt.Errorf("%w: secret не base64: %v", ErrInvalidConfig, err)
+		}
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("%w: %w: secret: нужно не меньше %d байт, передано %d",
+				ErrInvalidConfig, ErrWeakSecret, minSecretLength, len(secret))
+		}
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("%w: для HS256 нужен secret или secret_env", ErrInvalidConfig)
+	}
+}
+
+// newRS256FromConfig читает PEM-ключи и создает сервис RS256.
+func newRS256FromConfig(cfg Config, storage Storage, ttl time.Duration, opts []Option) (*AuthService, error) {
+	var err error
+	var privateKey *rsa.PrivateKey
+	if cfg.PrivateKeyPath != "" {
+		if privateKey, err = readPEMFile(cfg.PrivateKeyPath, LoadRSAPrivateKeyFromPEM); err != nil {
+			return nil, err
+		}
+	}
+	var publicKey *rsa.PublicKey
+	if cfg.PublicKeyPath != "" {
+		if publicKey, err = readPEMFile(cfg.PublicKeyPath, LoadRSAPublicKeyFromPEM); err != nil {
+			return nil, err
+		}
+	}
+	if privateKey != nil && publicKey != nil && !privateKey.PublicKey.Equal(publicKey) {
+		return nil, fmt.Errorf("%w: public_key_path не соответствует private_key_path", ErrInvalidConfig)
+	}
+	return NewAuthServiceRS256(storage, privateKey, publicKey, ttl, opts...)
+}
+
+// readPEMFile читает файл ключа и разбирает его функцией parse.
+func readPEMFile[K any](path string, parse func([]byte) (K, error)) (K, error) {
+	var zero K
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return zero, fmt.Errorf("%w: файл ключа %s не найден", ErrInvalidConfig, path)
+		}
+		return zero, fmt.Errorf("%w: чтение ключа %s: %v", ErrInvalidConfig, path, err)
+	}
+	key, err := parse(data)
+	if err != nil {
+		return zero, fmt.Errorf("%w: ключ %s: %v", ErrInvalidConfig, path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor (gRPC-перехватчик)
+// Аналог Middleware для gRPC: читает метаданные "authorization",
+// проверяет токен и кладет claims в контекст (см. ClaimsFromContext).
+// skipMethods - полные имена методов без проверки
+// (например, "/auth.AuthService/Login").
+// Токен, привязанный к ключу клиента (cnf.jkt, см. WithDPoP), отклоняется:
+// у gRPC-вызова нет метода и адреса HTTP-запроса, которые подписывает
+// DPoP-доказательство, а без доказательства такой токен нельзя
+// принимать как обычный bearer-токен.
+func (s *AuthService) UnaryServerInterceptor(skipMethods ...string) grpc.UnaryServerInterceptor {
+	skip := make(map[string]bool, len(skipMethods))
+	for _, m := range skipMethods {
+		skip[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, s.ErrorMessage(ErrMissingToken))
+		}
+
+		tokenString := bearerToken(values[0])
+		if tokenString == "" {
+			return nil, status.Error(codes.Unauthenticated, s.ErrorMessage(ErrMissingToken))
+		}
+
+		claims, err := s.ParseAndValidateTokenContext(ctx, tokenString)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, s.ErrorMessage(ErrTokenInvalid))
+		}
+		if claims.Confirmation != nil && claims.Confirmation.JKT != "" {
+			return nil, status.Error(codes.Unauthenticated, s.ErrorMessage(ErrInvalidDPoPProof))
+		}
+
+		return handler(contextWithClaims(ctx, claims), req)
+	}
+}
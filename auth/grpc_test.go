@@ -0,0 +1,74 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go_auth_pkg/auth"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	svc, _, userID := newTestService(t, auth.WithDPoP(0))
+	ctx := context.Background()
+	interceptor := svc.UnaryServerInterceptor("/test.Service/Public")
+
+	bearer, err := svc.Login(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	bound, err := svc.LoginWithOptions(ctx, testEmail, testPassword, auth.TokenOptions{DPoPThumbprint: "client-key-thumbprint"})
+	if err != nil {
+		t.Fatalf("LoginWithOptions: %v", err)
+	}
+
+	call := func(method, authorization string) (bool, error) {
+		callCtx := ctx
+		if authorization != "" {
+			callCtx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+		}
+		called := false
+		_, err := interceptor(callCtx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			if claims, ok := auth.ClaimsFromContext(ctx); authorization != "" && (!ok || claims.UserID != userID) {
+				t.Errorf("claims в контексте обработчика: %+v, %v", claims, ok)
+			}
+			return nil, nil
+		})
+		return called, err
+	}
+
+	if called, err := call("/test.Service/Private", "Bearer "+bearer); err != nil || !called {
+		t.Fatalf("bearer-токен: called = %v, err = %v", called, err)
+	}
+	if called, err := call("/test.Service/Public", ""); err != nil || !called {
+		t.Fatalf("метод без проверки: called = %v, err = %v", called, err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"без токена", ""},
+		{"неверный токен", "Bearer not-a-token"},
+		// Без DPoP-доказательства украденный привязанный токен не должен
+		// работать как обычный bearer-токен
+		{"привязанный к ключу", "Bearer " + bound},
+		{"привязанный к ключу со схемой DPoP", "DPoP " + bound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called, err := call("/test.Service/Private", tt.authorization)
+			if called {
+				t.Fatal("обработчик вызван")
+			}
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("код = %v, ожидался Unauthenticated (err = %v)", status.Code(err), err)
+			}
+		})
+	}
+}
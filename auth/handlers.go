@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxHandlerBody - предельный размер JSON-тела запроса к Handlers.
+const maxHandlerBody = 64 << 10
+
+// Handlers - готовые HTTP-обработчики входа, обновления и выхода.
+// Все обработчики принимают только POST, читают и возвращают JSON;
+// ошибки отдаются телом {"error": "..."} (как у Middleware).
+//
+//	h := authService.Handlers()
+//	mux.HandleFunc("/login", h.Login)
+//	mux.HandleFunc("/refresh", h.Refresh)
+//	mux.HandleFunc("/logout", h.Logout)
+type Handlers struct {
+	// Login принимает {"email", "password", "totp_code", "device_token"}
+	// и возвращает TokenResponse. totp_code нужен только пользователям
+	// с включенной 2FA; действующий device_token (IssueDeviceToken) его заменяет.
+	// Если подключен WithRefreshStore, в ответе есть refresh_token
+	// (с WithRefreshCookie - в cookie вместо тела). С WithDPoP запрос
+	// с заголовком DPoP получает токены, привязанные к ключу клиента.
+	Login http.HandlerFunc
+	// Refresh принимает {"refresh_token"} и возвращает новую пару токенов
+	// (см. Refresh). С WithRefreshCookie тело можно не передавать: токен
+	// берется из cookie. Без WithRefreshStore отвечает 501.
+	Refresh http.HandlerFunc
+	// Logout завершает токен из Authorization (или cookie, см. WithCookieName)
+	// и отвечает 204 без тела. С WithRefreshCookie удаляет cookie
+	// refresh-токена и отзывает его.
+	Logout http.HandlerFunc
+}
+
+// TokenResponse - тело успешного ответа Handlers.Login и Handlers.Refresh.
+type TokenResponse struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // секунды до истечения access-токена
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+}
+
+// loginRequest - тело запроса Handlers.Login.
+type loginRequest struct {
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	TOTPCode    string `json:"totp_code"`
+	DeviceToken string `json:"device_token"`
+}
+
+// refreshRequest - тело запроса Handlers.Refresh.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Handlers (HTTP-обработчики)
+// Возвращает обработчики POST /login, /refresh и /logout для быстрого
+// подключения к http.ServeMux. Это необязательная обертка: те же действия
+// доступны напрямую через Login, Refresh и Logout.
+// IP и User-Agent клиента берутся из запроса так же, как в ValidateRequest.
+func (s *AuthService) Handlers() Handlers {
+	return Handlers{
+		Login:   s.handleLogin,
+		Refresh: s.handleRefresh,
+		Logout:  s.handleLogout,
+	}
+}
+
+// handleLogin - обработчик Handlers.Login.
+func (s *AuthService) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+
+	ctx := requestContext(r)
+	user, err := s.authenticate(ctx, req.Email, req.Password,
+		secondFactor{totpCode: req.TOTPCode, deviceToken: req.DeviceToken})
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+
+	opts := TokenOptions{authTime: s.clock.Now()}
+	if s.dpop && r.Header.Get("DPoP") != "" {
+		// Клиент подтверждает владение ключом: токены привязываются к нему
+		if opts.DPoPThumbprint, err = s.verifyDPoPProof(r, ""); err != nil {
+			s.writeAuthError(w, err)
+			return
+		}
+	}
+
+	var pair tokenPair
+	if s.refresh != nil {
+		pair, err = s.issueTokenPair(ctx, user, "", opts)
+	} else {
+		pair.access, pair.expiresAt, err = s.mintAccessToken(ctx, user, opts)
+	}
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+
+	s.writeTokenResponse(w, pair)
+}
+
+// handleRefresh - обработчик Handlers.Refresh.
+func (s *AuthService) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if s.refreshCookie != nil && r.Method == http.MethodPost && r.ContentLength == 0 {
+		// Браузер с cookie может не передавать тело
+		req.RefreshToken = s.refreshFromCookie(r)
+	} else if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+	if req.RefreshToken == "" && s.refreshCookie != nil {
+		req.RefreshToken = s.refreshFromCookie(r)
+	}
+	if req.RefreshToken == "" {
+		s.writeAuthError(w, ErrMalformedRequest)
+		return
+	}
+
+	pair, err := s.rotateRefresh(requestContext(r), req.RefreshToken)
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+
+	s.writeTokenResponse(w, pair)
+}
+
+// handleLogout - обработчик Handlers.Logout.
+func (s *AuthService) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeMethodNotAllowed(w)
+		return
+	}
+	if s.refreshCookie != nil {
+		s.revokeCookieRefresh(r)
+		s.clearRefreshCookie(w)
+	}
+
+	tokenString, _, err := s.requestToken(r)
+	if err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+	if err := s.Logout(requestContext(r), tokenString); err != nil {
+		s.writeAuthError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokenResponse отвечает 200 с TokenResponse.
+func (s *AuthService) writeTokenResponse(w http.ResponseWriter, pair tokenPair) {
+	resp := TokenResponse{
+		AccessToken:  pair.access,
+		TokenType:    "Bearer",
+		ExpiresAt:    pair.expiresAt,
+		RefreshToken: pair.refresh,
+	}
+	if !pair.expiresAt.IsZero() {
+		resp.ExpiresIn = int64(pair.expiresAt.Sub(s.clock.Now()).Round(time.Second) / time.Second)
+	}
+
+	if s.refreshCookie != nil && pair.refresh != "" {
+		s.setRefreshCookie(w, pair.refresh)
+		resp.RefreshToken = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// decodeJSONRequest проверяет метод и разбирает JSON-тело запроса.
+// При ошибке сам отвечает клиенту и возвращает false.
+func (s *AuthService) decodeJSONRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if r.Method != http.MethodPost {
+		s.writeMethodNotAllowed(w)
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHandlerBody))
+	if err := dec.Decode(dst); err != nil {
+		s.writeAuthError(w, ErrMalformedRequest)
+		return false
+	}
+	return true
+}
+
+// writeMethodNotAllowed отвечает 405 для всех методов, кроме POST.
+func (s *AuthService) writeMethodNotAllowed(w http.ResponseWriter) {
+	w.Header().Set("Allow", http.MethodPost)
+	s.writeAuthError(w, ErrMethodNotAllowed)
+}
+
+// writeAuthError отвечает JSON-ошибкой с кодом, соответствующим err,
+// и текстом из ErrorMessage. Текст неизвестных (внутренних) ошибок
+// клиенту не раскрывается.
+func (s *AuthService) writeAuthError(w http.ResponseWriter, err error) {
+	status := authErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		err = ErrInternal
+	}
+	var retry *RetryAfterError
+	if errors.As(err, &retry) && retry.RetryAfter > 0 {
+		// Секунды с округлением вверх: раньше повторять бессмысленно
+		w.Header().Set("Retry-After", strconv.FormatInt(int64((retry.RetryAfter+time.Second-1)/time.Second), 10))
+	}
+	writeJSONError(w, status, s.ErrorMessage(err))
+}
+
+// authErrorStatus сопоставляет ошибки пакета с HTTP-статусами.
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrEmptyEmail), errors.Is(err, ErrMalformedRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMethodNotAllowed):
+		return http.StatusMethodNotAllowed
+	case errors.Is(err, ErrInvalidCredentials),
+		errors.Is(err, ErrTOTPRequired),
+		errors.Is(err, ErrMFARequired),
+		errors.Is(err, ErrInvalidTOTPCode),
+		errors.Is(err, ErrMissingToken),
+		errors.Is(err, ErrMultipleAuthHeaders),
+		errors.Is(err, ErrRefreshInvalid),
+		errors.Is(err, ErrRefreshReused),
+		errors.Is(err, ErrRefreshBindingMismatch),
+		errors.Is(err, ErrSessionRevoked),
+		errors.Is(err, ErrTokenInvalid),
+		errors.Is(err, ErrTokenExpired),
+		errors.Is(err, ErrTokenNotYetValid),
+		errors.Is(err, ErrTokenIssuedInFuture),
+		errors.Is(err, ErrTokenRevoked),
+		errors.Is(err, ErrTokenNotFound),
+		errors.Is(err, ErrMissingTokenID),
+		errors.Is(err, ErrWrongTokenPurpose),
+		errors.Is(err, ErrWrongTokenType),
+		errors.Is(err, ErrUnexpectedSigningMethod),
+		errors.Is(err, ErrUnknownKeyID),
+		errors.Is(err, ErrUnknownTenant),
+		errors.Is(err, ErrInvalidAudience),
+		errors.Is(err, ErrInvalidIssuer),
+		errors.Is(err, ErrIPMismatch),
+		errors.Is(err, ErrReauthRequired),
+		errors.Is(err, ErrUserDisabled),
+		errors.Is(err, ErrInvalidDPoPProof):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrEmailNotVerified), errors.Is(err, ErrCSRFMismatch), errors.Is(err, ErrPasswordExpired),
+		errors.Is(err, ErrScopeEscalation):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrAccountLocked), errors.Is(err, ErrTooManyTokens):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrRefreshUnsupported), errors.Is(err, ErrLogoutUnsupported),
+		errors.Is(err, ErrTokenExchangeUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, ErrStorageTimeout):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,442 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher хэширует и проверяет пароли (WithPasswordHasher).
+// Хэши должны быть самоописывающими (PHC-формат), чтобы сервис мог
+// проверять старые хэши во время миграции на другой алгоритм.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Compare возвращает nil, если пароль соответствует хэшу.
+	Compare(password, hash string) error
+	// NeedsRehash сообщает, что хэш создан другим алгоритмом
+	// или с более слабыми параметрами, чем текущие.
+	NeedsRehash(hash string) bool
+}
+
+// errPasswordMismatch - пароль не соответствует хэшу.
+var errPasswordMismatch = errors.New("пароль не соответствует хэшу")
+
+// ----------------------------------------------------------------------
+// bcrypt
+// ----------------------------------------------------------------------
+
+// bcryptMaxPassword - bcrypt учитывает только первые 72 байта пароля.
+const bcryptMaxPassword = 72
+
+// LongPasswordMode - обработка паролей длиннее 72 байт при bcrypt
+// (WithLongPasswords). bcrypt молча отбрасывает байты после 72-го,
+// и пароли с общим началом дали бы один хэш.
+type LongPasswordMode int
+
+const (
+	// LongPasswordReject - Register, ChangePassword и ResetPassword
+	// отклоняют такие пароли ошибкой ErrPasswordTooLong (по умолчанию).
+	LongPasswordReject LongPasswordMode = iota
+	// LongPasswordPrehash - длинный пароль перед bcrypt заменяется
+	// SHA-256 от него (base64), так что учитывается весь пароль.
+	// Пароли до 72 байт хэшируются как прежде. Режим нельзя выключать
+	// при наличии таких хэшей: их пароли перестанут подходить.
+	LongPasswordPrehash
+)
+
+// BcryptHasher - PasswordHasher на bcrypt (по умолчанию).
+// Учитывает только первые 72 байта пароля: более длинные Hash отклоняет
+// ошибкой ErrPasswordTooLong, если не задан PrehashLong.
+type BcryptHasher struct {
+	Cost int // 0 - bcrypt.DefaultCost
+	// PrehashLong - пароли длиннее 72 байт хэшируются через SHA-256
+	// (см. LongPasswordPrehash).
+	PrehashLong bool
+}
+
+func (h BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+// Hash реализует PasswordHasher
+func (h BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPassword && !h.PrehashLong {
+		return "", ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword(h.input(password), h.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare реализует PasswordHasher
+func (h BcryptHasher) Compare(password, hash string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), h.input(password))
+}
+
+// input возвращает байты, которые получит bcrypt: длинный пароль
+// при PrehashLong заменяется SHA-256 в base64 (44 байта, без нулевых байтов).
+func (h BcryptHasher) input(password string) []byte {
+	if len(password) <= bcryptMaxPassword || !h.PrehashLong {
+		return []byte(password)
+	}
+	sum := sha256.Sum256([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+// NeedsRehash реализует PasswordHasher
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.cost()
+}
+
+// isBcryptHash сообщает, что хэш в формате bcrypt ($2a$, $2b$, $2y$).
+func isBcryptHash(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+// ----------------------------------------------------------------------
+// Argon2id
+// ----------------------------------------------------------------------
+
+// argon2idPrefix - префикс PHC-строки Argon2id.
+const argon2idPrefix = "$argon2id$"
+
+// Верхние границы параметров хэша из хранилища: испорченная или подложенная
+// строка (m=4294967295) иначе заставила бы argon2.IDKey выделить терабайты
+// памяти и уронить процесс.
+const (
+	argon2idMaxMemory = 1 << 22 // КиБ (4 ГиБ)
+	argon2idMaxTime   = 16
+)
+
+// Argon2idHasher - PasswordHasher на Argon2id (RFC 9106).
+// Хэш сохраняется в PHC-формате:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<соль base64>$<хэш base64>
+//
+// Нулевые поля заменяются значениями DefaultArgon2idHasher.
+type Argon2idHasher struct {
+	Time    uint32 // число проходов
+	Memory  uint32 // память в КиБ
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// DefaultArgon2idHasher - параметры, рекомендованные RFC 9106 для систем
+// с ограниченной памятью: 3 прохода, 64 МиБ, 4 потока.
+var DefaultArgon2idHasher = Argon2idHasher{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+	KeyLen:  32,
+	SaltLen: 16,
+}
+
+// withDefaults заполняет незаданные параметры значениями DefaultArgon2idHasher.
+func (h Argon2idHasher) withDefaults() Argon2idHasher {
+	d := DefaultArgon2idHasher
+	if h.Time == 0 {
+		h.Time = d.Time
+	}
+	if h.Memory == 0 {
+		h.Memory = d.Memory
+	}
+	if h.Threads == 0 {
+		h.Threads = d.Threads
+	}
+	if h.KeyLen == 0 {
+		h.KeyLen = d.KeyLen
+	}
+	if h.SaltLen == 0 {
+		h.SaltLen = d.SaltLen
+	}
+	return h
+}
+
+// Hash реализует PasswordHasher
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	h = h.withDefaults()
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare реализует PasswordHasher. Параметры берутся из самого хэша.
+func (h Argon2idHasher) Compare(password, hash string) error {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return errPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash реализует PasswordHasher
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	h = h.withDefaults()
+	params, _, key, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Time < h.Time || params.Memory < h.Memory ||
+		params.Threads < h.Threads || uint32(len(key)) < h.KeyLen
+}
+
+// parseArgon2id разбирает PHC-строку Argon2id.
+func parseArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("неверный формат хэша argon2id")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("неподдерживаемая версия argon2id")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("неверные параметры argon2id: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("неверная соль argon2id: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("неверный хэш argon2id")
+	}
+	if params.Time < 1 || params.Threads < 1 {
+		return params, nil, nil, errors.New("неверные параметры argon2id")
+	}
+	if params.Memory > argon2idMaxMemory || params.Time > argon2idMaxTime {
+		return params, nil, nil, fmt.Errorf("параметры argon2id превышают допустимые (m <= %d, t <= %d)",
+			argon2idMaxMemory, argon2idMaxTime)
+	}
+	params.SaltLen, params.KeyLen = uint32(len(salt)), uint32(len(key))
+	return params, salt, key, nil
+}
+
+// ----------------------------------------------------------------------
+// Выбор алгоритма
+// ----------------------------------------------------------------------
+
+// passwordHasher возвращает алгоритм для новых хэшей: заданный через
+// WithPasswordHasher или bcrypt с текущей стоимостью (SetBcryptCost).
+func (s *AuthService) passwordHasher() PasswordHasher {
+	if s.hasher != nil {
+		return s.hasher
+	}
+	return BcryptHasher{Cost: s.currentBcryptCost(), PrehashLong: s.longPasswords == LongPasswordPrehash}
+}
+
+// compareHash проверяет пароль (с текущим перцем, см. WithPepper)
+// по хэшу любого известного формата (WithVerifyHasher, bcrypt, Argon2id),
+// иначе - заданным алгоритмом.
+func (s *AuthService) compareHash(password, hash string) error {
+	return s.compareHashInput(s.pepperedInput(password), hash)
+}
+
+// compareHashInput - compareHash для уже подготовленного ввода хэшера.
+func (s *AuthService) compareHashInput(input, hash string) error {
+	defer s.observeHash(time.Now())
+	if hasher, ok := s.registeredHasher(hash); ok {
+		return hasher.Compare(input, hash)
+	}
+	switch {
+	case isBcryptHash(hash):
+		return BcryptHasher{PrehashLong: s.longPasswords == LongPasswordPrehash}.Compare(input, hash)
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return Argon2idHasher{}.Compare(input, hash)
+	default:
+		return s.passwordHasher().Compare(input, hash)
+	}
+}
+
+// hasherEntry - алгоритм проверки хэшей с префиксом prefix (WithVerifyHasher).
+type hasherEntry struct {
+	prefix string
+	hasher PasswordHasher
+}
+
+// registeredHasher выбирает алгоритм WithVerifyHasher по префиксу хэша;
+// при нескольких подходящих побеждает самый длинный префикс.
+func (s *AuthService) registeredHasher(hash string) (PasswordHasher, bool) {
+	var best *hasherEntry
+	for i := range s.verifyHashers {
+		e := &s.verifyHashers[i]
+		if strings.HasPrefix(hash, e.prefix) && (best == nil || len(e.prefix) > len(best.prefix)) {
+			best = e
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.hasher, true
+}
+
+// isMigratedHash сообщает, что хэш проверен алгоритмом WithVerifyHasher
+// и должен быть заменен хэшем основного алгоритма.
+func (s *AuthService) isMigratedHash(hash string) bool {
+	if _, ok := s.registeredHasher(hash); !ok {
+		return false
+	}
+	return s.passwordHasher().NeedsRehash(hash)
+}
+
+// isKnownHash сообщает, что хэш можно проверить без LegacyHashVerifier.
+func (s *AuthService) isKnownHash(hash string) bool {
+	if isBcryptHash(hash) || strings.HasPrefix(hash, argon2idPrefix) {
+		return true
+	}
+	if _, ok := s.registeredHasher(hash); ok {
+		return true
+	}
+	// Собственный алгоритм: хэш считается известным, если не требует миграции
+	return s.hasher != nil && !s.hasher.NeedsRehash(hash)
+}
+
+// HashPassword (Хэширование пароля)
+// Возвращает готовый для Storage хэш текущим алгоритмом и параметрами
+// сервиса - для массового импорта пользователей в обход Register.
+// Политика паролей не применяется.
+func (s *AuthService) HashPassword(password string) (string, error) {
+	return s.hashPassword(password)
+}
+
+// VerifyPassword проверяет пароль по хэшу так же, как Login (включая
+// Argon2id, bcrypt и LegacyHashVerifier). При несовпадении возвращает
+// ErrInvalidCredentials.
+func (s *AuthService) VerifyPassword(password, hash string) error {
+	if !s.verifyPassword(hash, password) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------
+// Проверка хэшей при импорте
+// ----------------------------------------------------------------------
+
+// HashFormat - формат сохраненного хэша пароля.
+type HashFormat string
+
+const (
+	HashFormatBcrypt   HashFormat = "bcrypt"
+	HashFormatArgon2id HashFormat = "argon2id"
+	// HashFormatLegacy - не PHC-строка (например, hex SHA-256 старой
+	// системы): проверяется только через WithLegacyHashVerifier.
+	HashFormatLegacy HashFormat = "legacy"
+)
+
+// HashInfo - сведения о сохраненном хэше (InspectHash).
+type HashInfo struct {
+	Format HashFormat
+	// Cost - стоимость bcrypt; для других форматов 0.
+	Cost int
+	// Argon2id - параметры Argon2id; для других форматов нулевые.
+	Argon2id Argon2idHasher
+}
+
+// IdentifyHashFormat определяет формат хэша по префиксу, не проверяя
+// его корректность (для этого - ValidateStoredHash). Пустой хэш и
+// PHC-строка неизвестного алгоритма дают ErrMalformedHash.
+func IdentifyHashFormat(hash string) (HashFormat, error) {
+	switch {
+	case hash == "":
+		return "", fmt.Errorf("%w: пустой хэш", ErrMalformedHash)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"),
+		strings.HasPrefix(hash, "$2y$"), strings.HasPrefix(hash, "$2x$"):
+		return HashFormatBcrypt, nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return HashFormatArgon2id, nil
+	case strings.HasPrefix(hash, "$"):
+		id, _, _ := strings.Cut(hash[1:], "$")
+		return "", fmt.Errorf("%w: неподдерживаемый алгоритм %q", ErrMalformedHash, id)
+	default:
+		return HashFormatLegacy, nil
+	}
+}
+
+// ValidateStoredHash проверяет, что хэш - корректная строка bcrypt или
+// Argon2id (PHC), которую Login сможет проверить. Хэш устаревшего формата
+// (HashFormatLegacy) тоже отклоняется: ему нужен WithLegacyHashVerifier.
+// Параметры хэша (стоимость bcrypt и т.д.) возвращает InspectHash.
+func ValidateStoredHash(hash string) error {
+	_, err := InspectHash(hash)
+	return err
+}
+
+// InspectHash проверяет хэш, как ValidateStoredHash, и возвращает его
+// формат и параметры - например, чтобы при импорте отметить хэши
+// с заниженной стоимостью bcrypt.
+func InspectHash(hash string) (HashInfo, error) {
+	format, err := IdentifyHashFormat(hash)
+	if err != nil {
+		return HashInfo{}, err
+	}
+
+	info := HashInfo{Format: format}
+	switch format {
+	case HashFormatBcrypt:
+		// 60 символов: $2b$, стоимость, $, 22 символа соли и 31 символ хэша
+		if len(hash) != 60 {
+			return info, fmt.Errorf("%w: длина хэша bcrypt %d вместо 60", ErrMalformedHash, len(hash))
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return info, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return info, fmt.Errorf("%w: стоимость bcrypt %d вне диапазона [%d, %d]",
+				ErrMalformedHash, cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		if !isBcryptBase64(hash[7:29]) || !isBcryptBase64(hash[29:]) {
+			return info, fmt.Errorf("%w: недопустимые символы в хэше bcrypt", ErrMalformedHash)
+		}
+		info.Cost = cost
+	case HashFormatArgon2id:
+		params, _, _, err := parseArgon2id(hash)
+		if err != nil {
+			return info, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		info.Argon2id = params
+	default:
+		return info, fmt.Errorf("%w: хэш устаревшего формата требует WithLegacyHashVerifier", ErrMalformedHash)
+	}
+	return info, nil
+}
+
+// bcryptBase64 - алфавит base64 bcrypt (соль и хэш, без дополнения).
+var bcryptBase64 = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// isBcryptBase64 сообщает, что часть хэша bcrypt закодирована его алфавитом.
+func isBcryptBase64(part string) bool {
+	_, err := bcryptBase64.DecodeString(part)
+	return err == nil
+}
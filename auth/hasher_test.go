@@ -0,0 +1,151 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// longPrefix - 72 байта: все, что учитывает bcrypt.
+var longPrefix = strings.Repeat("Aa1!", 18)
+
+func TestLongPasswordRejected(t *testing.T) {
+	svc, _, userID := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, "long@example.com", longPrefix+"X"); !errors.Is(err, auth.ErrPasswordTooLong) {
+		t.Fatalf("Register: err = %v, ожидался ErrPasswordTooLong", err)
+	}
+	if err := svc.ChangePassword(ctx, userID, testPassword, longPrefix+"X"); !errors.Is(err, auth.ErrPasswordTooLong) {
+		t.Fatalf("ChangePassword: err = %v, ожидался ErrPasswordTooLong", err)
+	}
+	if _, err := svc.Register(ctx, "exact@example.com", longPrefix); err != nil {
+		t.Fatalf("Register с паролем ровно 72 байта: %v", err)
+	}
+}
+
+func TestLongPasswordPrehash(t *testing.T) {
+	svc, _, _ := newTestService(t, auth.WithLongPasswords(auth.LongPasswordPrehash))
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, "long@example.com", longPrefix+"X"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := svc.Login(ctx, "long@example.com", longPrefix+"X"); err != nil {
+		t.Fatalf("Login тем же паролем: %v", err)
+	}
+	// Без предварительного SHA-256 bcrypt принял бы любой пароль с тем же началом
+	for _, password := range []string{longPrefix + "Y", longPrefix} {
+		if _, err := svc.Login(ctx, "long@example.com", password); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login паролем %d байт с тем же началом: err = %v, ожидался ErrInvalidCredentials", len(password), err)
+		}
+	}
+}
+
+func TestMixedHashFormats(t *testing.T) {
+	// Дешевые параметры Argon2id, чтобы тест не занимал 64 МиБ
+	argon := auth.Argon2idHasher{Time: 1, Memory: 8 * 1024, Threads: 1}
+	ctx := context.Background()
+	storage := memory.NewInMemoryStorage()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-Pass1!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argonHash, err := argon.Hash("argon-Pass1!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]struct {
+		password string
+		hash     string
+	}{
+		"bcrypt@example.com": {"bcrypt-Pass1!", string(bcryptHash)},
+		"argon@example.com":  {"argon-Pass1!", argonHash},
+	}
+	ids := make(map[string]int64)
+	for email, u := range users {
+		if ids[email], err = storage.CreateUser(ctx, email, u.hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc, err := auth.NewAuthService(storage, testSecret, time.Hour,
+		auth.WithPasswordHasher(argon), auth.WithRehashOnLogin(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for email, u := range users {
+		if _, err := svc.Login(ctx, email, u.password); err != nil {
+			t.Fatalf("Login %s: %v", email, err)
+		}
+		if _, err := svc.Login(ctx, email, u.password+"x"); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login %s неверным паролем: err = %v", email, err)
+		}
+		// Хэш не основного алгоритма заменен при входе; пароль по-прежнему подходит
+		user, err := storage.GetUserByID(ctx, ids[email])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format, err := auth.IdentifyHashFormat(user.GetPasswordHash()); err != nil || format != auth.HashFormatArgon2id {
+			t.Fatalf("%s: формат хэша после входа = %q (%v), ожидался argon2id", email, format, err)
+		}
+		if _, err := svc.Login(ctx, email, u.password); err != nil {
+			t.Fatalf("Login %s после перехэширования: %v", email, err)
+		}
+	}
+
+	// Новые пароли хэшируются основным алгоритмом
+	id, err := svc.Register(ctx, "new@example.com", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := storage.GetUserByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := auth.IdentifyHashFormat(user.GetPasswordHash()); format != auth.HashFormatArgon2id {
+		t.Fatalf("Register: формат хэша = %q, ожидался argon2id", format)
+	}
+}
+
+func TestArgon2idRejectsOversizedParams(t *testing.T) {
+	argon := auth.Argon2idHasher{Time: 1, Memory: 8 * 1024, Threads: 1}
+	hash, err := argon.Hash(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := argon.Compare(testPassword, hash); err != nil {
+		t.Fatalf("Compare исходного хэша: %v", err)
+	}
+
+	// Испорченная строка из хранилища не должна доходить до argon2.IDKey:
+	// m=4294967295 потребовал бы около 4 ТиБ памяти
+	for _, params := range []string{"m=4294967295,t=1,p=1", "m=8192,t=4294967295,p=1", "m=4194305,t=1,p=1", "m=8192,t=17,p=1"} {
+		hostile := strings.Replace(hash, "m=8192,t=1,p=1", params, 1)
+		if err := argon.Compare(testPassword, hostile); err == nil {
+			t.Fatalf("Compare с %s: ожидалась ошибка", params)
+		}
+		if !argon.NeedsRehash(hostile) {
+			t.Fatalf("NeedsRehash с %s = false", params)
+		}
+	}
+
+	// Вход с таким хэшем - обычная ошибка учетных данных
+	svc, storage, userID := newTestService(t)
+	hostile := strings.Replace(hash, "m=8192,t=1,p=1", "m=4294967295,t=1,p=1", 1)
+	if err := storage.UpdatePasswordHash(context.Background(), userID, hostile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Login(context.Background(), testEmail, testPassword); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("Login: err = %v, ожидался ErrInvalidCredentials", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package hibp - auth.BreachChecker на основе Pwned Passwords API сервиса
+// Have I Been Pwned. Используется k-анонимность: наружу уходят только
+// первые 5 символов SHA-1 пароля, а совпадение остатка хэша ищется
+// локально в ответе, поэтому ни пароль, ни его полный хэш не покидают
+// процесс. Ответы запрашиваются с дополнением (Add-Padding), чтобы их
+// размер не выдавал префикс.
+//
+//	checker := hibp.New()
+//	svc, err := auth.NewAuthService(storage, secret, ttl,
+//		auth.WithBreachChecker(checker.Check, auth.BreachCheckFailOpen))
+package hibp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL - адрес range API Pwned Passwords.
+const DefaultBaseURL = "https://api.pwnedpasswords.com/range/"
+
+// defaultTimeout - таймаут HTTP-клиента New.
+const defaultTimeout = 5 * time.Second
+
+// maxResponseBytes ограничивает чтение ответа (обычно около 40 КБ).
+const maxResponseBytes = 1 << 20
+
+// Checker проверяет пароли по range API. Нулевые поля заменяются
+// значениями по умолчанию.
+type Checker struct {
+	// Client - HTTP-клиент; nil - http.DefaultClient (без таймаута,
+	// задайте его через ctx).
+	Client *http.Client
+	// BaseURL - адрес range API с завершающим "/" (для зеркал и тестов);
+	// пусто - DefaultBaseURL.
+	BaseURL string
+	// MinCount - сколько раз пароль должен встретиться в утечках, чтобы
+	// считаться скомпрометированным; <= 1 - любое вхождение.
+	MinCount int
+}
+
+// New создает Checker с HTTP-клиентом с таймаутом 5 секунд.
+func New() *Checker {
+	return &Checker{Client: &http.Client{Timeout: defaultTimeout}}
+}
+
+// Check реализует auth.BreachChecker.
+func (c *Checker) Check(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "go_auth_pkg-hibp")
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("hibp: неожиданный ответ %s", resp.Status)
+	}
+
+	minCount := c.MinCount
+	if minCount < 1 {
+		minCount = 1
+	}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxResponseBytes))
+	for scanner.Scan() {
+		line, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(line, suffix) {
+			continue
+		}
+		// Строки дополнения имеют счетчик 0
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return false, fmt.Errorf("hibp: некорректная строка ответа: %w", err)
+		}
+		return n >= minCount, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("hibp: ошибка чтения ответа: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,117 @@
+package hibp_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_auth_pkg/auth/hibp"
+)
+
+// sha1Hex - SHA-1 пароля в верхнем регистре, как в range API.
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// rangeServer отвечает на /range/<prefix> строками body[prefix] и
+// запоминает путь и заголовок Add-Padding последнего запроса.
+type rangeServer struct {
+	*httptest.Server
+	body    map[string]string
+	status  int
+	path    string
+	padding string
+}
+
+func newRangeServer(t *testing.T) *rangeServer {
+	rs := &rangeServer{body: make(map[string]string), status: http.StatusOK}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.path = r.URL.Path
+		rs.padding = r.Header.Get("Add-Padding")
+		if rs.status != http.StatusOK {
+			w.WriteHeader(rs.status)
+			return
+		}
+		fmt.Fprint(w, rs.body[strings.TrimPrefix(r.URL.Path, "/range/")])
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+func (rs *rangeServer) checker(minCount int) *hibp.Checker {
+	return &hibp.Checker{Client: rs.Client(), BaseURL: rs.URL + "/range/", MinCount: minCount}
+}
+
+func TestCheckSendsOnlyPrefix(t *testing.T) {
+	rs := newRangeServer(t)
+	hash := sha1Hex("password")
+	rs.body[hash[:5]] = "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":3861493\r\n"
+
+	breached, err := rs.checker(0).Check(context.Background(), "password")
+	if err != nil || !breached {
+		t.Fatalf("Check: breached = %v, err = %v", breached, err)
+	}
+	if rs.path != "/range/"+hash[:5] {
+		t.Fatalf("путь запроса %q, ожидался префикс SHA-1 из 5 символов %q", rs.path, hash[:5])
+	}
+	if strings.Contains(rs.path, hash[5:]) {
+		t.Fatal("остаток хэша ушел в запрос")
+	}
+	if rs.padding != "true" {
+		t.Fatalf("Add-Padding = %q", rs.padding)
+	}
+}
+
+func TestCheckResponseLines(t *testing.T) {
+	const password = "correct horse battery staple"
+	hash := sha1Hex(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	tests := []struct {
+		name     string
+		body     string
+		minCount int
+		want     bool
+	}{
+		{"нет в ответе", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n", 0, false},
+		// Строка дополнения с тем же остатком и счетчиком 0 - не утечка
+		{"строка дополнения", suffix + ":0\r\n", 0, false},
+		{"остаток в нижнем регистре", strings.ToLower(suffix) + ":2\r\n", 0, true},
+		{"ниже MinCount", suffix + ":3\r\n", 10, false},
+		{"равно MinCount", suffix + ":10\r\n", 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newRangeServer(t)
+			rs.body[prefix] = tt.body
+			breached, err := rs.checker(tt.minCount).Check(context.Background(), password)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if breached != tt.want {
+				t.Fatalf("breached = %v, ожидалось %v", breached, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	rs := newRangeServer(t)
+	rs.status = http.StatusServiceUnavailable
+	if _, err := rs.checker(0).Check(context.Background(), "password"); err == nil {
+		t.Fatal("ответ 503: ожидалась ошибка")
+	}
+
+	rs.status = http.StatusOK
+	hash := sha1Hex("password")
+	rs.body[hash[:5]] = hash[5:] + ":many\r\n"
+	if _, err := rs.checker(0).Check(context.Background(), "password"); err == nil {
+		t.Fatal("некорректный счетчик: ожидалась ошибка")
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import "context"
+
+// defaultPasswordHistoryDepth - число запоминаемых паролей по умолчанию.
+const defaultPasswordHistoryDepth = 5
+
+// PasswordHistoryStore хранит последние хэши паролей пользователя,
+// чтобы запретить их повторное использование (WithPasswordHistory).
+type PasswordHistoryStore interface {
+	// Recent возвращает до limit последних хэшей, начиная с самого нового.
+	Recent(ctx context.Context, userID int64, limit int) ([]string, error)
+	// Push добавляет хэш и оставляет только keep последних.
+	Push(ctx context.Context, userID int64, passwordHash string, keep int) error
+}
+
+// checkPasswordHistory отклоняет пароль, совпадающий с текущим
+// или с одним из последних сохраненных (ErrPasswordReused).
+func (s *AuthService) checkPasswordHistory(ctx context.Context, user UserIn, password string) error {
+	if s.passwordHistory == nil {
+		return nil
+	}
+
+	hashes, err := s.passwordHistory.Recent(ctx, user.GetID(), s.passwordHistoryDepth)
+	if err != nil {
+		return err
+	}
+	hashes = append([]string{user.GetPasswordHash()}, hashes...)
+
+	for _, hash := range hashes {
+		// bcrypt дорогой: не продолжаем, если клиент уже ушел
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.verifyPassword(hash, password) {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// recordPasswordHistory запоминает новый хэш пароля пользователя.
+func (s *AuthService) recordPasswordHistory(ctx context.Context, userID int64, hash string) error {
+	if s.passwordHistory == nil {
+		return nil
+	}
+	return s.passwordHistory.Push(ctx, userID, hash, s.passwordHistoryDepth)
+}
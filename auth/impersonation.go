@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+)
+
+// defaultImpersonationRole - роль, которой по умолчанию разрешена имперсонация.
+const defaultImpersonationRole = "admin"
+
+// Actor - участник, действующий от имени владельца токена
+// (claim "act", RFC 8693). Subject - ID администратора или сервиса
+// (ExchangeToken). Actor - предыдущий участник цепочки делегирования.
+type Actor struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Actor   *Actor `json:"act,omitempty"`
+}
+
+// IsImpersonated сообщает, что токен выпущен администратору для входа
+// от имени пользователя: приложение может показать предупреждение.
+// Для токенов ExchangeToken (act - сервис) тоже true.
+func (c *JWTClaims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+// IssueImpersonationToken (Вход от имени пользователя)
+// Выпускает администратору access-токен пользователя targetUserID для
+// разбора обращений в поддержку. В токене sub - пользователь, act -
+// администратор (RFC 8693); срок жизни короткий (WithImpersonation),
+// refresh-токен не выдается. claims администратора должны быть уже
+// проверены (ParseAndValidateToken, ClaimsFromContext). Без нужной роли,
+// а также для токена, который сам выпущен имперсонацией, - ErrForbidden.
+// Каждая выдача отправляет событие EventImpersonation для аудита.
+func (s *AuthService) IssueImpersonationToken(ctx context.Context, adminClaims *JWTClaims, targetUserID int64) (string, error) {
+	if !s.HasRole(adminClaims, s.impersonationRole) || adminClaims.IsImpersonated() {
+		return "", ErrForbidden
+	}
+
+	user, err := s.storage.GetUserByID(ctx, targetUserID)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.issueAccessToken(ctx, user, TokenOptions{
+		TTL: s.impersonationTTL,
+		actor: &Actor{
+			Subject: strconv.FormatInt(adminClaims.UserID, 10),
+			Email:   adminClaims.Email,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.emit(ctx, Event{
+		Type:    EventImpersonation,
+		UserID:  user.GetID(),
+		Email:   user.GetEmail(),
+		ActorID: adminClaims.UserID,
+	})
+	return token, nil
+}
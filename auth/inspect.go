@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// InspectToken (Разбор токена для наблюдаемости)
+// Выполняет полную проверку токена, как ParseAndValidateToken, но
+// возвращает claims в любом случае: valid сообщает, прошел ли токен все
+// проверки. Claims непрошедшего токена (в т.ч. с неверной подписью)
+// декодируются без проверки - кто угодно мог их подставить.
+// Предназначен только для журналов и аналитики ("кем назвался клиент").
+// Это НЕ средство авторизации: доступ выдавайте только по claims
+// ParseAndValidateToken или при valid == true. err - только для
+// неразбираемого ввода (не JWT, больше WithMaxTokenBytes); неизвестный
+// непрозрачный токен дает nil, false, nil. Метрики и журнал проверок
+// (WithMetrics, WithLogger) не затрагиваются.
+func (s *AuthService) InspectToken(tokenString string) (claims *JWTClaims, valid bool, err error) {
+	return s.InspectTokenContext(context.Background(), tokenString)
+}
+
+// InspectTokenContext - InspectToken с контекстом.
+func (s *AuthService) InspectTokenContext(ctx context.Context, tokenString string) (*JWTClaims, bool, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.checkTokenSize(tokenString); err != nil {
+		return nil, false, err
+	}
+
+	claims, err := s.validateWith(ctx, s.parser, tokenString)
+	if err == nil {
+		return claims, true, nil
+	}
+	if claims != nil {
+		return claims, false, nil
+	}
+	if s.isOpaqueToken(tokenString) {
+		return nil, false, nil
+	}
+
+	unverified := &JWTClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, unverified); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+	backfillUserID(unverified)
+	return unverified, false, nil
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -11,9 +13,37 @@ import (
 
 // JWTClaims - Структура, содержащая данные, которые мы вкладываем в JWT.
 type JWTClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+	UserID int64    `json:"user_id,omitempty"` // при SubjectOnly пуст, ID берется из sub
+	Email  string   `json:"email"`
+	Roles  []string `json:"roles,omitempty"` // в старых токенах отсутствует
+	Scopes Scopes   `json:"scope,omitempty"` // строка через пробел (RFC 6749)
+	// EmailVerified - email подтвержден (VerificationProvider, OIDC
+	// email_verified); заполняется с WithEmailVerifiedClaim.
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// SessionID - идентификатор сессии (при подключенном SessionStore).
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion - версия токенов пользователя на момент входа (TokenVersionProvider).
+	TokenVersion int64 `json:"tver,omitempty"`
+	// IPHash - отпечаток IP клиента при входе (WithIPBinding).
+	IPHash string `json:"iph,omitempty"`
+	// TenantID - арендатор, ключом которого подписан токен (WithTenantKeys).
+	TenantID string `json:"tid,omitempty"`
+	// Actor - администратор, действующий от имени пользователя
+	// (IssueImpersonationToken); nil для обычного входа.
+	Actor *Actor `json:"act,omitempty"`
+	// AuthTime - время последнего ввода пароля или другого фактора
+	// (см. RequireFreshAuth); обновление токенов его не меняет.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Confirmation - ключ, к которому привязан токен (RFC 9449, см. WithDPoP).
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	// PasswordExpired - пароль пользователя старше WithPasswordMaxAge:
+	// приложению следует потребовать его смены.
+	PasswordExpired bool `json:"pwd_expired,omitempty"`
 	jwt.RegisteredClaims
+
+	// Extra - дополнительные поля payload (см. WithClaimsEnricher).
+	// Сериализуются на верхнем уровне токена, стандартные поля не переопределяют.
+	Extra map[string]interface{} `json:"-"`
 }
 
 // ----------------------------------------------------------------------
@@ -28,15 +58,249 @@ type UserIn interface {
 	GetPasswordHash() string // Должен возвращать хэш пароля
 }
 
+// TOTPProvider - необязательный интерфейс пользователя с двухфакторной
+// аутентификацией. Пустой секрет означает, что 2FA не включена.
+type TOTPProvider interface {
+	GetTOTPSecret() string // base32, как выдает GenerateTOTPSecret
+}
+
+// VerificationProvider - необязательный интерфейс пользователя с признаком
+// подтвержденного email. Без него email считается неподтвержденным.
+type VerificationProvider interface {
+	IsVerified() bool
+}
+
+// ClaimsEnricher возвращает дополнительные поля токена для пользователя
+// (tenant ID, тариф, feature flags и т.д.). Ошибка прерывает Login.
+type ClaimsEnricher func(ctx context.Context, user UserIn) (map[string]interface{}, error)
+
+// TTLResolver возвращает срок жизни access-токена для пользователя
+// (например, короче для администраторов). Ноль или отрицательное
+// значение - срок по умолчанию (ttl конструктора).
+type TTLResolver func(user UserIn) time.Duration
+
+// ClaimsValidator проверяет бизнес-правила для криптографически валидного
+// токена (пользователь деактивирован, тариф истек и т.д.).
+// Ошибка отклоняет токен; ctx - контекст запроса, можно обращаться к БД.
+type ClaimsValidator func(ctx context.Context, claims *JWTClaims) error
+
+// IDGenerator выдает уникальный идентификатор токена (jti):
+// например, UUIDv7 или ULID. Значение должно быть трудноугадываемым.
+type IDGenerator func() (string, error)
+
+// RoleProvider - необязательный интерфейс пользователя с ролями.
+// Если модель его реализует, Login записывает роли в токен.
+type RoleProvider interface {
+	GetRoles() []string
+}
+
+// ScopeProvider - необязательный интерфейс пользователя с OAuth-scope.
+// Если модель его реализует, Login записывает scope в токен.
+type ScopeProvider interface {
+	GetScopes() []string
+}
+
+// TokenVersionProvider - необязательный интерфейс пользователя с версией
+// токенов. Login записывает версию в токен, а при WithTokenVersioning
+// токены с версией ниже текущей отклоняются (см. RevokeAllTokens).
+type TokenVersionProvider interface {
+	GetTokenVersion() int64
+}
+
 // ----------------------------------------------------------------------
 // Интерфейс Хранилища (Storage)
 // ----------------------------------------------------------------------
 
 // Storage определяет методы для работы с Пользователями в БД.
 // При необходимости разработчик расширяет свою реализацию Storage
-// дополнительными методами (CreateUser, UpdateUser и т.д.)
+// дополнительными методами (UpdateUser и т.д.)
+// Если пользователь не найден, методы GetUserBy* должны возвращать
+// ErrUserNotFound (можно обернутую через fmt.Errorf("...: %w", ...)).
 type Storage interface {
 	// User
 	GetUserByEmail(ctx context.Context, email string) (UserIn, error)
 	GetUserByID(ctx context.Context, id int64) (UserIn, error)
+	// CreateUser сохраняет нового пользователя и возвращает его ID.
+	// passwordHash - уже готовый bcrypt-хэш, открытый пароль сюда не попадает.
+	CreateUser(ctx context.Context, email, passwordHash string) (int64, error)
+	// UpdatePasswordHash заменяет хэш пароля пользователя.
+	UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error
+}
+
+// TokenVersionStorage - необязательное расширение Storage для
+// WithTokenVersioning: увеличивает версию токенов пользователя,
+// после чего все ранее выданные ему токены недействительны.
+type TokenVersionStorage interface {
+	BumpTokenVersion(ctx context.Context, userID int64) error
+}
+
+// TOTPStorage - необязательное расширение Storage для EnrollTOTP
+// и ConsumeRecoveryCode. Хранит TOTP-секрет (его затем отдает
+// TOTPProvider пользователя) и SHA-256 хэши кодов восстановления.
+type TOTPStorage interface {
+	// SetTOTP сохраняет новый секрет и хэши кодов, заменяя прежние.
+	SetTOTP(ctx context.Context, userID int64, secret string, recoveryCodeHashes []string) error
+	// UseRecoveryCode атомарно удаляет хэш кода и сообщает, был ли он:
+	// так каждый код срабатывает не более одного раза.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+}
+
+// ----------------------------------------------------------------------
+// Черный список токенов (TokenBlacklist)
+// ----------------------------------------------------------------------
+
+// TokenBlacklist хранит идентификаторы (jti) отозванных токенов.
+// exp - время истечения токена: после него запись можно удалить (TTL).
+type TokenBlacklist interface {
+	Add(ctx context.Context, jti string, exp time.Time) error
+	IsBlacklisted(ctx context.Context, jti string) (bool, error)
+}
+
+// ----------------------------------------------------------------------
+// Хранилище refresh-токенов (RefreshStore)
+// ----------------------------------------------------------------------
+
+// RefreshToken - запись о выданном refresh-токене.
+type RefreshToken struct {
+	// Token - SHA-256 отпечаток токена (hex): сам токен отдается клиенту
+	// один раз и не хранится, поэтому утечка БД не раскрывает сессии.
+	// Миграция старых записей: замените значение на hex(SHA-256(token)),
+	// например UPDATE refresh_tokens SET token = encode(sha256(token::bytea), 'hex').
+	// Поиск по исходному значению не поддерживается: иначе отпечаток
+	// из утекшей БД работал бы как токен.
+	Token     string
+	UserID    int64
+	ExpiresAt time.Time
+	Revoked   bool   // true после ротации или отзыва
+	SessionID string // сессия, в рамках которой выдан токен (если есть)
+	// FamilyID объединяет цепочку ротаций, начатую одним входом:
+	// повторное предъявление любого звена отзывает всю семью.
+	FamilyID string
+	// AuthTime - время входа, начавшего семью: переносится в auth_time
+	// access-токенов после ротации (см. RequireFreshAuth).
+	AuthTime time.Time
+	// DPoPThumbprint - ключ клиента, к которому привязаны access-токены
+	// семьи (cnf.jkt, см. WithDPoP).
+	DPoPThumbprint string
+	// IP и UserAgent - клиент, которому выдан токен (WithRefreshBinding).
+	IP        string
+	UserAgent string
+}
+
+// RefreshStore хранит непрозрачные refresh-токены.
+// Revoke не удаляет запись, а помечает ее отозванной: это нужно,
+// чтобы распознать повторное использование (ErrRefreshReused).
+// Lookup должен возвращать ErrRefreshNotFound, если токена нет.
+// Все методы получают отпечаток токена (RefreshToken.Token), а не сам токен.
+type RefreshStore interface {
+	Save(ctx context.Context, token RefreshToken) error
+	Lookup(ctx context.Context, token string) (RefreshToken, error)
+	Revoke(ctx context.Context, token string) error
+	// RevokeAllForUser отзывает все refresh-токены пользователя.
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	// RevokeFamily отзывает все refresh-токены семьи (см. RefreshToken.FamilyID).
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+// RefreshBatchLookup - необязательное расширение RefreshStore: поиск
+// нескольких записей за один запрос (прогрев кэша, см. WithRefreshCache
+// и WarmRefreshCache). Ненайденные отпечатки в результат не попадают.
+type RefreshBatchLookup interface {
+	LookupBatch(ctx context.Context, tokens []string) (map[string]RefreshToken, error)
+}
+
+// ----------------------------------------------------------------------
+// Часы (Clock)
+// ----------------------------------------------------------------------
+
+// Clock - источник текущего времени. Подменяется в тестах через WithClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock - системные часы (по умолчанию).
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// ----------------------------------------------------------------------
+// Хранилище неудачных попыток входа (LoginAttemptStore)
+// ----------------------------------------------------------------------
+
+// LoginAttempts - счетчик неудачных попыток входа для одного email.
+type LoginAttempts struct {
+	Count        int
+	FirstFailure time.Time
+	LastFailure  time.Time
+}
+
+// LoginAttemptStore хранит неудачные попытки входа по email.
+// Get для неизвестного email возвращает нулевое значение без ошибки.
+type LoginAttemptStore interface {
+	Get(ctx context.Context, email string) (LoginAttempts, error)
+	// RecordFailure увеличивает Count и обновляет LastFailure
+	// (и FirstFailure, если это первая неудача).
+	RecordFailure(ctx context.Context, email string, at time.Time) error
+	Reset(ctx context.Context, email string) error
+}
+
+// ----------------------------------------------------------------------
+// Ограничение частоты входов (RateLimiter)
+// ----------------------------------------------------------------------
+
+// RateLimiter решает, можно ли выполнить еще одну попытку входа по ключу
+// (по умолчанию ключ - email, см. WithRateLimitKey).
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+// RateLimitKeyFunc строит ключ ограничения частоты для попытки входа,
+// например по IP из контекста запроса вместо email.
+type RateLimitKeyFunc func(ctx context.Context, email string) string
+
+// ----------------------------------------------------------------------
+// Хранилище сессий (SessionStore)
+// ----------------------------------------------------------------------
+
+// Session - активная сессия пользователя (один вход на одном устройстве).
+type Session struct {
+	ID        string
+	UserID    int64
+	CreatedAt time.Time
+	LastSeen  time.Time
+	IP        string // из RequestMeta при входе, если передан
+	UserAgent string
+}
+
+// SessionStore хранит активные сессии. ID сессии записывается в токен (sid),
+// и токен отклоняется, если его сессия удалена.
+// Get должен возвращать ErrSessionNotFound, если сессии нет.
+type SessionStore interface {
+	Create(ctx context.Context, session Session) error
+	Get(ctx context.Context, sessionID string) (Session, error)
+	ListByUser(ctx context.Context, userID int64) ([]Session, error)
+	Touch(ctx context.Context, sessionID string, lastSeen time.Time) error
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// ----------------------------------------------------------------------
+// Учет выданных access-токенов (ActiveTokenStore)
+// ----------------------------------------------------------------------
+
+// ActiveToken - выданный access-токен пользователя (WithMaxActiveTokensPerUser).
+type ActiveToken struct {
+	ID        string // jti
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ActiveTokenStore хранит jti выданных access-токенов по пользователям.
+// Истекшие записи хранилище может удалять само (TTL до ExpiresAt).
+type ActiveTokenStore interface {
+	Track(ctx context.Context, userID int64, token ActiveToken) error
+	// ListActive возвращает токены пользователя, не истекшие к now.
+	ListActive(ctx context.Context, userID int64, now time.Time) ([]ActiveToken, error)
+	// Remove удаляет запись; отсутствие записи - не ошибка.
+	Remove(ctx context.Context, userID int64, jti string) error
+	RemoveAll(ctx context.Context, userID int64) error
 }
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Причины недействительности токена (TokenInfo.Reason).
+const (
+	ReasonExpired         = "expired"
+	ReasonNotYetValid     = "not_yet_valid"
+	ReasonIssuedInFuture  = "issued_in_future"
+	ReasonRevoked         = "revoked"
+	ReasonSessionRevoked  = "session_revoked"
+	ReasonIPMismatch      = "ip_mismatch"
+	ReasonUserDisabled    = "user_disabled"
+	ReasonWrongPurpose    = "wrong_purpose"
+	ReasonWrongType       = "wrong_type"
+	ReasonBadSignature    = "bad_signature"
+	ReasonSigningMethod   = "unexpected_signing_method"
+	ReasonUnknownKey      = "unknown_key"
+	ReasonInvalidAudience = "invalid_audience"
+	ReasonInvalidIssuer   = "invalid_issuer"
+	ReasonMalformed       = "malformed"
+	ReasonTooLarge        = "too_large"
+	ReasonInvalid         = "invalid"
+	// ReasonError - отказ не связан с самим токеном (сбой хранилища, отмена ctx и т.п.).
+	ReasonError = "error"
+)
+
+// TokenInfo - состояние токена в духе RFC 7662 (OAuth Token Introspection).
+// Поля пользователя заполняются, только если подпись токена верна.
+type TokenInfo struct {
+	Active    bool
+	UserID    int64
+	Email     string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	Reason    string // пусто для активного токена
+}
+
+// Introspect (Интроспекция токена)
+// В отличие от ParseAndValidateToken, недействительный токен - это
+// не ошибка, а Active:false с причиной в Reason. Ошибка возвращается
+// только при сбое инфраструктуры (например, недоступен черный список).
+func (s *AuthService) Introspect(tokenString string) (TokenInfo, error) {
+	return s.IntrospectContext(context.Background(), tokenString)
+}
+
+// IntrospectContext - то же, что Introspect, с контекстом.
+func (s *AuthService) IntrospectContext(ctx context.Context, tokenString string) (TokenInfo, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	var claims *JWTClaims
+	if err == nil {
+		claims, err = s.validate(ctx, tokenString)
+	}
+
+	var info TokenInfo
+	if claims != nil {
+		info.UserID = claims.UserID
+		info.Email = claims.Email
+		if claims.ExpiresAt != nil {
+			info.ExpiresAt = claims.ExpiresAt.Time
+		}
+		if claims.IssuedAt != nil {
+			info.IssuedAt = claims.IssuedAt.Time
+		}
+	}
+
+	if err == nil {
+		info.Active = true
+		return info, nil
+	}
+
+	reason, ok := rejectionReason(err)
+	if !ok {
+		return TokenInfo{}, err
+	}
+	info.Reason = reason
+	return info, nil
+}
+
+// validationReason - причина отказа для метрик и журнала: константа Reason*,
+// для ошибок инфраструктуры - ReasonError.
+func validationReason(err error) string {
+	if reason, ok := rejectionReason(err); ok {
+		return reason
+	}
+	return ReasonError
+}
+
+// rejectionReason определяет причину отказа. ok=false означает,
+// что ошибка не связана с самим токеном (сбой хранилища и т.п.).
+func rejectionReason(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		return ReasonExpired, true
+	case errors.Is(err, ErrTokenNotYetValid):
+		return ReasonNotYetValid, true
+	case errors.Is(err, ErrTokenIssuedInFuture):
+		return ReasonIssuedInFuture, true
+	case errors.Is(err, ErrTokenRevoked):
+		return ReasonRevoked, true
+	case errors.Is(err, ErrSessionRevoked):
+		return ReasonSessionRevoked, true
+	case errors.Is(err, ErrIPMismatch):
+		return ReasonIPMismatch, true
+	case errors.Is(err, ErrUserDisabled):
+		return ReasonUserDisabled, true
+	case errors.Is(err, ErrWrongTokenPurpose):
+		return ReasonWrongPurpose, true
+	case errors.Is(err, ErrWrongTokenType):
+		return ReasonWrongType, true
+	case errors.Is(err, ErrUnexpectedSigningMethod):
+		return ReasonSigningMethod, true
+	case errors.Is(err, ErrUnknownKeyID), errors.Is(err, ErrUnknownTenant):
+		return ReasonUnknownKey, true
+	case errors.Is(err, ErrInvalidAudience):
+		return ReasonInvalidAudience, true
+	case errors.Is(err, ErrInvalidIssuer):
+		return ReasonInvalidIssuer, true
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return ReasonBadSignature, true
+	case errors.Is(err, ErrTokenTooLarge):
+		return ReasonTooLarge, true
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ReasonMalformed, true
+	case errors.Is(err, ErrTokenInvalid):
+		return ReasonInvalid, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+)
+
+// ipHash - отпечаток IP для claim iph. Соль - jti токена, поэтому
+// отпечатки разных токенов одного адреса не совпадают. IP не раскрывается
+// в открытом виде, но перебор IPv4-адресов по отпечатку возможен.
+func ipHash(jti, ip string) string {
+	sum := sha256.Sum256([]byte(jti + "|" + ip))
+	return hex.EncodeToString(sum[:16])
+}
+
+// bindIP записывает в claims отпечаток IP клиента (WithIPBinding).
+// Без RequestMeta токен выпускается непривязанным.
+func (s *AuthService) bindIP(ctx context.Context, claims *JWTClaims) {
+	if !s.ipBinding {
+		return
+	}
+	if meta, ok := RequestMetaFromContext(ctx); ok && meta.IP != "" {
+		claims.IPHash = ipHash(claims.ID, meta.IP)
+	}
+}
+
+// checkIPBinding сверяет IP текущего запроса (RequestMeta) с отпечатком
+// в токене. Если IP запроса неизвестен, привязанный токен отклоняется.
+func (s *AuthService) checkIPBinding(ctx context.Context, claims *JWTClaims) error {
+	if !s.ipBinding || claims.IPHash == "" {
+		return nil
+	}
+	meta, ok := RequestMetaFromContext(ctx)
+	if !ok || meta.IP == "" {
+		return ErrIPMismatch
+	}
+	if !SecureCompare(ipHash(claims.ID, meta.IP), claims.IPHash) {
+		return ErrIPMismatch
+	}
+	return nil
+}
+
+// requestContext дополняет контекст запроса сведениями о клиенте из
+// r.RemoteAddr и User-Agent, если RequestMeta еще не задан.
+func requestContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if _, ok := RequestMetaFromContext(ctx); ok {
+		return ctx
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return WithRequestMeta(ctx, RequestMeta{IP: ip, UserAgent: r.UserAgent()})
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+)
+
+// JWKSPath - стандартный путь публикации JWKS.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWK - публичный ключ в формате JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // OKP (Ed25519), EC
+	X   string `json:"x,omitempty"`   // OKP (Ed25519), EC
+	Y   string `json:"y,omitempty"`   // EC
+}
+
+// JWKSet - документ JWKS.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS (Экспорт публичных ключей)
+// Возвращает JSON-документ JWKS с публичными ключами проверки (RSA, Ed25519):
+// активным и дополнительными (WithPublicKey). Для HS256 ключи секретны,
+// поэтому возвращается ErrNoPublicKeys.
+func (s *AuthService) JWKS() ([]byte, error) {
+	set := JWKSet{Keys: []JWK{}}
+
+	if s.keyID == "" {
+		if jwk, ok := publicJWK("", s.signingMethod.Alg(), s.verifyKey); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		if jwk, ok := publicJWK(kid, s.signingMethod.Alg(), s.keys[kid]); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	if len(set.Keys) == 0 {
+		return nil, ErrNoPublicKeys
+	}
+	return json.Marshal(set)
+}
+
+// JWKSHandler отдает JWKS по HTTP. Обычно монтируется на JWKSPath:
+//
+//	mux.Handle(auth.JWKSPath, authService.JWKSHandler())
+func (s *AuthService) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := s.JWKS()
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, s.ErrorMessage(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
+
+// publicJWK кодирует публичный ключ в JWK; ok=false для секретных
+// и неподдерживаемых ключей.
+func publicJWK(kid, alg string, key interface{}) (JWK, bool) {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return rsaJWK(kid, alg, pub), true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: alg,
+			Kid: kid,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// rsaJWK кодирует публичный ключ RSA в JWK.
+func rsaJWK(kid, alg string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: alg,
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
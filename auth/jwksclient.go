@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Параметры загрузки JWKS.
+const (
+	defaultJWKSRefresh = time.Hour
+	jwksMissCooldown   = 30 * time.Second // не чаще для неизвестного kid
+	jwksFetchTimeout   = 10 * time.Second
+	maxJWKSBody        = 1 << 20
+)
+
+// NewVerifierFromJWKS создает Verifier, который берет ключи проверки
+// из JWKS издателя (например, https://issuer/.well-known/jwks.json).
+// Ключ выбирается по kid токена. Набор ключей обновляется в фоне раз
+// в refreshInterval (<= 0 - раз в час) и сразу при встрече неизвестного
+// kid (не чаще раза в 30 секунд) - так подхватывается ротация ключей.
+// Если обновить JWKS не удалось, используются последние загруженные ключи.
+// Первая загрузка выполняется сразу: ее ошибка возвращается.
+// Поддерживаются ключи RSA, EC (P-256, P-384, P-521) и Ed25519;
+// opts - опции проверки, как у NewVerifier.
+func NewVerifierFromJWKS(ctx context.Context, jwksURL string, refreshInterval time.Duration, opts ...Option) (*Verifier, error) {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultJWKSRefresh
+	}
+	cache := &jwksCache{
+		url:      jwksURL,
+		client:   &http.Client{Timeout: jwksFetchTimeout},
+		interval: refreshInterval,
+	}
+
+	s, err := newAuthService(nil, jwt.SigningMethodRS256, nil, nil, 0, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.signKey = nil
+	cache.clock = s.clock
+	if err := cache.refresh(ctx); err != nil {
+		return nil, err
+	}
+	s.jwks = cache
+
+	return &Verifier{service: s}, nil
+}
+
+// jwksCache - загруженные из JWKS ключи проверки.
+type jwksCache struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+	clock    Clock
+
+	mu         sync.RWMutex
+	keys       map[string]jwksKey // kid -> ключ
+	fetchedAt  time.Time
+	lastMiss   time.Time // последнее обновление из-за неизвестного kid
+	refreshing bool      // идет фоновое обновление
+
+	fetchMu sync.Mutex // одна загрузка за раз
+}
+
+// jwksKey - ключ из JWKS с ограничением алгоритма (alg), если он задан.
+type jwksKey struct {
+	key interface{}
+	alg string
+}
+
+// keyFor возвращает ключ проверки для токена.
+func (c *jwksCache) keyFor(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	c.mu.RLock()
+	key, ok := c.lookup(kid)
+	stale := c.clock.Now().Sub(c.fetchedAt) >= c.interval
+	c.mu.RUnlock()
+
+	if !ok && c.allowMissRefresh() {
+		// Возможно, издатель уже перешел на новый ключ
+		if err := c.refresh(ctx); err == nil {
+			c.mu.RLock()
+			key, ok = c.lookup(kid)
+			c.mu.RUnlock()
+		}
+	} else if stale {
+		c.refreshInBackground()
+	}
+
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if !algMatchesKey(token.Method.Alg(), key) {
+		return nil, ErrUnexpectedSigningMethod
+	}
+	return key.key, nil
+}
+
+// lookup ищет ключ по kid; токен без kid подходит к единственному ключу
+// набора. Вызывается под c.mu.
+func (c *jwksCache) lookup(kid string) (jwksKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// allowMissRefresh ограничивает частоту обновлений из-за неизвестного kid,
+// чтобы токены с произвольным kid не превращались в поток запросов к JWKS.
+func (c *jwksCache) allowMissRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	if now.Sub(c.lastMiss) < jwksMissCooldown {
+		return false
+	}
+	c.lastMiss = now
+	return true
+}
+
+// refreshInBackground запускает обновление, если оно еще не идет.
+func (c *jwksCache) refreshInBackground() {
+	c.mu.Lock()
+	if c.refreshing {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.refreshing = false
+			c.mu.Unlock()
+		}()
+		_ = c.refresh(context.Background())
+	}()
+}
+
+// refresh загружает JWKS и заменяет набор ключей. При ошибке прежний
+// набор сохраняется.
+func (c *jwksCache) refresh(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	keys, err := c.fetch(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Повторная попытка после ошибки - тоже не раньше чем через interval
+	c.fetchedAt = c.clock.Now()
+	if err != nil {
+		return err
+	}
+	c.keys = keys
+	return nil
+}
+
+// fetch загружает и разбирает JWKS.
+func (c *jwksCache) fetch(ctx context.Context) (map[string]jwksKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес JWKS: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка загрузки JWKS: статус %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBody)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("некорректный документ JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue // неподдерживаемые ключи пропускаются
+		}
+		keys[jwk.Kid] = jwksKey{key: key, alg: jwk.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: в JWKS нет поддерживаемых ключей подписи", ErrNoPublicKeys)
+	}
+	return keys, nil
+}
+
+// PublicKey разбирает JWK в публичный ключ: *rsa.PublicKey,
+// *ecdsa.PublicKey или ed25519.PublicKey.
+func (k JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKField(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKField(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 2 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("некорректная экспонента RSA")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := decodeJWKField(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKField(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("точка EC не лежит на кривой")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := decodeJWKField(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("некорректная длина ключа Ed25519")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %q", k.Kty)
+	}
+}
+
+// decodeJWKField декодирует base64url-поле JWK.
+func decodeJWKField(value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.New("в JWK не хватает поля ключа")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// algMatchesKey проверяет, что алгоритм токена подходит к типу ключа
+// и к его alg из JWKS, если тот задан.
+func algMatchesKey(alg string, key jwksKey) bool {
+	if key.alg != "" && key.alg != alg {
+		return false
+	}
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case ed25519.PublicKey:
+		return alg == jwt.SigningMethodEdDSA.Alg()
+	case *ecdsa.PublicKey:
+		method, err := methodForKey(k)
+		return err == nil && method.Alg() == alg
+	default:
+		return false
+	}
+}
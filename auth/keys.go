@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadRSAPrivateKeyFromPEM разбирает закрытый RSA-ключ из PEM
+// (блоки "RSA PRIVATE KEY" - PKCS#1 и "PRIVATE KEY" - PKCS#8) для
+// NewAuthServiceRS256. Зашифрованные ключи не поддерживаются.
+func LoadRSAPrivateKeyFromPEM(data []byte) (*rsa.PrivateKey, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора ключа PKCS#1: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора ключа PKCS#8: %w", err)
+		}
+		key, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("ключ PKCS#8 не RSA: %T", parsed)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока для закрытого ключа: %q", block.Type)
+	}
+}
+
+// LoadRSAPublicKeyFromPEM разбирает открытый RSA-ключ из PEM
+// (блоки "PUBLIC KEY" - PKIX, "RSA PUBLIC KEY" - PKCS#1 и "CERTIFICATE").
+func LoadRSAPublicKeyFromPEM(data []byte) (*rsa.PublicKey, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			parsed = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока для открытого ключа: %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора открытого ключа (%s): %w", block.Type, err)
+	}
+
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("открытый ключ не RSA: %T", parsed)
+	}
+	return key, nil
+}
+
+// decodePEM возвращает первый PEM-блок данных.
+func decodePEM(data []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("данные не содержат PEM-блока")
+	}
+	return block, nil
+}
+
+// SecretFromEnv читает HMAC-секрет для NewAuthService из переменной
+// окружения name. Значение - base64 (стандартный или URL-алфавит,
+// с дополнением или без). Секрет короче 32 байт дает ErrWeakSecret.
+//
+//	secret, err := auth.SecretFromEnv("JWT_SECRET") // openssl rand -base64 32
+func SecretFromEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("переменная окружения %s не задана", name)
+	}
+
+	secret, err := decodeBase64Secret(value)
+	if err != nil {
+		return nil, fmt.Errorf("переменная окружения %s: значение не base64: %w", name, err)
+	}
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("%w: %s: нужно не меньше %d байт, передано %d",
+			ErrWeakSecret, name, minSecretLength, len(secret))
+	}
+	return secret, nil
+}
+
+// GenerateSecretKey возвращает 32 криптографически случайных байта -
+// HMAC-секрет нужной длины для NewAuthService (HS256).
+func GenerateSecretKey() ([]byte, error) {
+	secret := make([]byte, minSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("ошибка генерации секрета: %w", err)
+	}
+	return secret, nil
+}
+
+// GenerateSecretKeyBase64 - GenerateSecretKey в base64 для хранения
+// в переменной окружения; читается обратно через SecretFromEnv.
+//
+//	secret, _ := auth.GenerateSecretKeyBase64() // JWT_SECRET=<secret>
+func GenerateSecretKeyBase64() (string, error) {
+	secret, err := GenerateSecretKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// decodeBase64Secret декодирует base64 в любом из распространенных вариантов.
+func decodeBase64Secret(value string) ([]byte, error) {
+	value = strings.TrimRight(value, "=")
+	if strings.ContainsAny(value, "-_") {
+		return base64.RawURLEncoding.DecodeString(value)
+	}
+	return base64.RawStdEncoding.DecodeString(value)
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Значения блокировки по умолчанию.
+const (
+	defaultMaxFailedAttempts = 5
+	defaultAttemptWindow     = 15 * time.Minute
+	defaultLockoutDuration   = 15 * time.Minute
+)
+
+// RetryAfterError сообщает, через сколько можно повторить вход
+// (WithLoginBackoff). errors.Is(err, ErrRateLimited) == true во время
+// задержки между попытками и errors.Is(err, ErrAccountLocked) == true
+// для заблокированного аккаунта.
+type RetryAfterError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s (через %s)", e.Err.Error(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// checkLockout возвращает ErrAccountLocked, если аккаунт заблокирован,
+// и ErrRateLimited, если не истекла задержка WithLoginBackoff.
+// Истекшая блокировка сбрасывается.
+func (s *AuthService) checkLockout(ctx context.Context, email string) error {
+	if s.attempts == nil {
+		return nil
+	}
+
+	a, err := s.attempts.Get(ctx, email)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	if a.Count < s.maxFailedAttempts {
+		if wait := a.LastFailure.Add(s.loginBackoff(a.Count)).Sub(now); wait > 0 {
+			return &RetryAfterError{RetryAfter: wait, Err: ErrRateLimited}
+		}
+		return nil
+	}
+	if unlock := a.LastFailure.Add(s.lockoutDuration); now.Before(unlock) {
+		if s.backoffBase > 0 {
+			return &RetryAfterError{RetryAfter: unlock.Sub(now), Err: ErrAccountLocked}
+		}
+		return ErrAccountLocked
+	}
+	return s.attempts.Reset(ctx, email)
+}
+
+// loginBackoff - задержка после count неудач подряд: base*2^(count-1),
+// не больше backoffMax (если он задан).
+func (s *AuthService) loginBackoff(count int) time.Duration {
+	if s.backoffBase <= 0 || count <= 0 {
+		return 0
+	}
+	delay := float64(s.backoffBase) * math.Pow(2, float64(count-1))
+	if s.backoffMax > 0 && delay > float64(s.backoffMax) {
+		return s.backoffMax
+	}
+	if delay > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// recordLoginFailure учитывает неудачную попытку входа.
+// Неудачи старше окна attemptWindow начинают отсчет заново.
+func (s *AuthService) recordLoginFailure(ctx context.Context, email string) error {
+	if s.attempts == nil {
+		return nil
+	}
+
+	now := s.clock.Now()
+	a, err := s.attempts.Get(ctx, email)
+	if err != nil {
+		return err
+	}
+	if a.Count > 0 && now.Sub(a.FirstFailure) > s.attemptWindow {
+		if err := s.attempts.Reset(ctx, email); err != nil {
+			return err
+		}
+	}
+	return s.attempts.RecordFailure(ctx, email, now)
+}
+
+// resetLoginFailures сбрасывает счетчик после успешного входа.
+func (s *AuthService) resetLoginFailures(ctx context.Context, email string) error {
+	if s.attempts == nil {
+		return nil
+	}
+	return s.attempts.Reset(ctx, email)
+}
+
+// checkRateLimit консультируется с RateLimiter, если он задан.
+func (s *AuthService) checkRateLimit(ctx context.Context, email string) error {
+	if s.rateLimiter == nil {
+		return nil
+	}
+
+	key := email
+	if s.rateLimitKey != nil {
+		key = s.rateLimitKey(ctx, email)
+	}
+
+	ok, err := s.rateLimiter.Allow(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Журналирование (WithLogger).
+// В журнал никогда не попадают токены, пароли, хэши и email:
+// пользователь идентифицируется по user_id, а claims пишутся только
+// в виде JWTClaims.Redacted (с ключами WithRedactedClaims).
+
+// logEvent пишет событие аутентификации: успешные операции - Info,
+// неудачный вход - Debug, повторное использование refresh-токена - Warn.
+func (s *AuthService) logEvent(ctx context.Context, e Event) {
+	attrs := []slog.Attr{slog.String("event", string(e.Type))}
+	if e.UserID != 0 {
+		attrs = append(attrs, slog.Int64("user_id", e.UserID))
+	}
+
+	switch e.Type {
+	case EventImpersonation:
+		attrs = append(attrs, slog.Int64("actor_id", e.ActorID))
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "auth: вход от имени пользователя", attrs...)
+		return
+	case EventLoginFailure:
+		attrs = append(attrs, slog.String("reason", e.Reason))
+		s.logger.LogAttrs(ctx, slog.LevelDebug, "auth: вход отклонен", attrs...)
+		return
+	case EventRefreshReused:
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "auth: повторное использование refresh-токена", attrs...)
+		return
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "auth: "+string(e.Type), attrs...)
+}
+
+// logValidation пишет на уровне Debug результат проверки токена: claims
+// принятого токена или причину отказа (с claims, если подпись была верна).
+func (s *AuthService) logValidation(ctx context.Context, claims *JWTClaims, err error) {
+	if !s.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	var attrs []slog.Attr
+	if claims != nil {
+		attrs = append(attrs, slog.Any("claims", claims.redacted(s.redactedClaims)))
+	}
+	if err == nil {
+		s.logger.LogAttrs(ctx, slog.LevelDebug, "auth: токен принят", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("reason", validationReason(err)), slog.String("error", err.Error()))
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "auth: токен отклонен", attrs...)
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// BeginMagicLink (Вход по ссылке из письма)
+// Выпускает одноразовый токен входа без пароля (срок - WithMagicLinkTTL),
+// который приложение отправляет ссылкой на email. Для неизвестного email
+// возвращает пустую строку без ошибки, чтобы по ответу нельзя было
+// определить наличие аккаунта: письмо в этом случае не отправляется,
+// а клиенту отвечают как обычно. Прочие ошибки хранилища (например,
+// ErrStorageTimeout) возвращаются, чтобы сбой не выглядел как успех.
+// Частота ограничивается WithRateLimiter, как у входа. Нужен черный
+// список (WithTokenBlacklist), иначе ErrBlacklistUnsupported.
+func (s *AuthService) BeginMagicLink(ctx context.Context, email string) (string, error) {
+	if s.blacklist == nil {
+		return "", ErrBlacklistUnsupported
+	}
+	email = s.normalizeEmail(email)
+	if err := s.checkRateLimit(ctx, email); err != nil {
+		return "", err
+	}
+
+	user, err := s.storage.GetUserByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	claims := purposeClaims{
+		Purpose:          PurposeMagicLink,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: strconv.FormatInt(user.GetID(), 10)},
+	}
+	if vp, ok := user.(TokenVersionProvider); ok && s.tokenVersioning {
+		claims.TokenVersion = vp.GetTokenVersion()
+	}
+	return s.signPurposeToken(ctx, claims, s.magicLinkTTL)
+}
+
+// CompleteMagicLink гасит токен BeginMagicLink и выдает access-токен
+// новой сессии, как Login. Повторное предъявление - ErrTokenAlreadyUsed,
+// ссылка, выпущенная до RevokeAllTokens, - ErrTokenRevoked. Ссылка
+// подтверждает только владение почтой, поэтому пользователям с 2FA
+// возвращается ErrTOTPRequired (им нужен LoginWith2FA); действуют также
+// WithRequireVerifiedEmail и WithSingleSession.
+func (s *AuthService) CompleteMagicLink(ctx context.Context, linkToken string) (string, error) {
+	if s.blacklist == nil {
+		return "", ErrBlacklistUnsupported
+	}
+	claims, err := s.consumePurposeToken(ctx, linkToken, PurposeMagicLink)
+	if err != nil {
+		return "", err
+	}
+	userID, err := claims.userID()
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", ErrTokenInvalid
+	}
+	if s.tokenVersioning {
+		var current int64
+		if vp, ok := user.(TokenVersionProvider); ok {
+			current = vp.GetTokenVersion()
+		}
+		if claims.TokenVersion < current {
+			return "", ErrTokenRevoked
+		}
+	}
+
+	if err := s.checkVerified(user); err != nil {
+		return "", err
+	}
+	if err := s.checkMFA(ctx, user, s.normalizeEmail(user.GetEmail()), secondFactor{}); err != nil {
+		return "", err
+	}
+	if user, err = s.endPreviousSessions(ctx, user); err != nil {
+		return "", err
+	}
+
+	s.metrics.IncLoginSuccess()
+	s.emit(ctx, Event{Type: EventLoginSuccess, UserID: user.GetID(), Email: user.GetEmail()})
+	return s.issueAccessToken(ctx, user, TokenOptions{authTime: s.clock.Now()})
+}
@@ -0,0 +1,34 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+func TestBeginMagicLinkStorageErrors(t *testing.T) {
+	svc, _, _ := newTestService(t, auth.WithTokenBlacklist(newMemBlacklist()))
+	ctx := context.Background()
+
+	token, err := svc.BeginMagicLink(ctx, "nobody@example.com")
+	if err != nil || token != "" {
+		t.Fatalf("неизвестный email: token = %q, err = %v, ожидались пустая строка и nil", token, err)
+	}
+	if token, err := svc.BeginMagicLink(ctx, testEmail); err != nil || token == "" {
+		t.Fatalf("известный email: token = %q, err = %v", token, err)
+	}
+
+	// Сбой хранилища не должен выглядеть как неизвестный email
+	storage := &failingStorage{InMemoryStorage: memory.NewInMemoryStorage(), err: auth.ErrStorageTimeout}
+	broken, err := auth.NewAuthService(storage, testSecret, time.Hour, auth.WithTokenBlacklist(newMemBlacklist()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broken.BeginMagicLink(ctx, testEmail); !errors.Is(err, auth.ErrStorageTimeout) {
+		t.Fatalf("сбой хранилища: err = %v, ожидался ErrStorageTimeout", err)
+	}
+}
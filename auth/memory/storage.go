@@ -0,0 +1,192 @@
+// Package memory содержит реализации интерфейсов пакета auth в памяти.
+// Подходит для тестов, демо и быстрого старта; данные не переживают перезапуск.
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go_auth_pkg/auth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User - модель пользователя InMemoryStorage.
+type User struct {
+	ID           int64
+	Email        string
+	PasswordHash string
+	Roles        []string
+	TokenVersion int64
+	TOTPSecret   string
+	// RecoveryCodeHashes - хэши неиспользованных кодов восстановления.
+	RecoveryCodeHashes []string
+	// PasswordChangedAt - время создания пользователя или последней смены пароля.
+	PasswordChangedAt time.Time
+}
+
+func (u *User) GetID() int64                    { return u.ID }
+func (u *User) GetEmail() string                { return u.Email }
+func (u *User) GetPasswordHash() string         { return u.PasswordHash }
+func (u *User) GetRoles() []string              { return u.Roles }
+func (u *User) GetTokenVersion() int64          { return u.TokenVersion }
+func (u *User) GetTOTPSecret() string           { return u.TOTPSecret }
+func (u *User) GetPasswordChangedAt() time.Time { return u.PasswordChangedAt }
+
+var (
+	_ auth.UserIn               = (*User)(nil)
+	_ auth.RoleProvider         = (*User)(nil)
+	_ auth.TokenVersionProvider = (*User)(nil)
+	_ auth.TOTPProvider         = (*User)(nil)
+	_ auth.PasswordAgeProvider  = (*User)(nil)
+)
+
+// InMemoryStorage - потокобезопасная реализация auth.Storage в памяти.
+type InMemoryStorage struct {
+	mu      sync.RWMutex
+	nextID  int64
+	byID    map[int64]*User
+	byEmail map[string]int64
+}
+
+var (
+	_ auth.Storage             = (*InMemoryStorage)(nil)
+	_ auth.TokenVersionStorage = (*InMemoryStorage)(nil)
+	_ auth.TOTPStorage         = (*InMemoryStorage)(nil)
+)
+
+// NewInMemoryStorage создает пустое хранилище.
+func NewInMemoryStorage() *InMemoryStorage {
+	return &InMemoryStorage{
+		byID:    make(map[int64]*User),
+		byEmail: make(map[string]int64),
+	}
+}
+
+// SeedUser добавляет пользователя с открытым паролем (хэшируется при вставке).
+// Удобно для тестов и демо; в рабочем коде используйте AuthService.Register.
+func (s *InMemoryStorage) SeedUser(email, password string, roles ...string) (int64, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.CreateUser(context.Background(), email, string(hash))
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.byID[id].Roles = roles
+	s.mu.Unlock()
+	return id, nil
+}
+
+// GetUserByEmail реализует auth.Storage
+func (s *InMemoryStorage) GetUserByEmail(ctx context.Context, email string) (auth.UserIn, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byEmail[strings.ToLower(email)]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+	return s.copyUser(id), nil
+}
+
+// GetUserByID реализует auth.Storage
+func (s *InMemoryStorage) GetUserByID(ctx context.Context, id int64) (auth.UserIn, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.byID[id]; !ok {
+		return nil, auth.ErrUserNotFound
+	}
+	return s.copyUser(id), nil
+}
+
+// CreateUser реализует auth.Storage
+func (s *InMemoryStorage) CreateUser(ctx context.Context, email, passwordHash string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(email)
+	if _, ok := s.byEmail[key]; ok {
+		return 0, auth.ErrUserAlreadyExists
+	}
+
+	s.nextID++
+	s.byID[s.nextID] = &User{ID: s.nextID, Email: email, PasswordHash: passwordHash, PasswordChangedAt: time.Now()}
+	s.byEmail[key] = s.nextID
+	return s.nextID, nil
+}
+
+// UpdatePasswordHash реализует auth.Storage
+func (s *InMemoryStorage) UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[userID]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+	u.PasswordHash = passwordHash
+	u.PasswordChangedAt = time.Now()
+	return nil
+}
+
+// BumpTokenVersion реализует auth.TokenVersionStorage
+func (s *InMemoryStorage) BumpTokenVersion(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[userID]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+	u.TokenVersion++
+	return nil
+}
+
+// SetTOTP реализует auth.TOTPStorage
+func (s *InMemoryStorage) SetTOTP(ctx context.Context, userID int64, secret string, recoveryCodeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[userID]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+	u.TOTPSecret = secret
+	u.RecoveryCodeHashes = append([]string(nil), recoveryCodeHashes...)
+	return nil
+}
+
+// UseRecoveryCode реализует auth.TOTPStorage
+func (s *InMemoryStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[userID]
+	if !ok {
+		return false, auth.ErrUserNotFound
+	}
+	for i, h := range u.RecoveryCodeHashes {
+		if h == codeHash {
+			u.RecoveryCodeHashes = append(u.RecoveryCodeHashes[:i:i], u.RecoveryCodeHashes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// copyUser возвращает копию, чтобы вызывающий не менял данные без блокировки.
+// Вызывается под блокировкой.
+func (s *InMemoryStorage) copyUser(id int64) *User {
+	u := *s.byID[id]
+	u.Roles = append([]string(nil), u.Roles...)
+	u.RecoveryCodeHashes = append([]string(nil), u.RecoveryCodeHashes...)
+	return &u
+}
@@ -0,0 +1,154 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+func TestInMemoryStorageRoundTrip(t *testing.T) {
+	storage := memory.NewInMemoryStorage()
+	ctx := context.Background()
+
+	id, err := storage.CreateUser(ctx, "User@Example.com", "hash-1")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	byEmail, err := storage.GetUserByEmail(ctx, "User@Example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	byID, err := storage.GetUserByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	for _, u := range []auth.UserIn{byEmail, byID} {
+		if u.GetID() != id || u.GetEmail() != "User@Example.com" || u.GetPasswordHash() != "hash-1" {
+			t.Fatalf("пользователь = {%d %q %q}, ожидался {%d User@Example.com hash-1}",
+				u.GetID(), u.GetEmail(), u.GetPasswordHash(), id)
+		}
+	}
+
+	if _, err := storage.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Fatalf("неизвестный email: err = %v, ожидался ErrUserNotFound", err)
+	}
+	if _, err := storage.GetUserByID(ctx, id+1); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Fatalf("неизвестный id: err = %v, ожидался ErrUserNotFound", err)
+	}
+}
+
+func TestInMemoryStorageEmailCaseInsensitive(t *testing.T) {
+	storage := memory.NewInMemoryStorage()
+	ctx := context.Background()
+
+	id, err := storage.CreateUser(ctx, "User@Example.com", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	u, err := storage.GetUserByEmail(ctx, "USER@example.COM")
+	if err != nil || u.GetID() != id {
+		t.Fatalf("GetUserByEmail в другом регистре: user = %v, err = %v", u, err)
+	}
+
+	for _, email := range []string{"User@Example.com", "user@example.com"} {
+		if _, err := storage.CreateUser(ctx, email, "other"); !errors.Is(err, auth.ErrUserAlreadyExists) {
+			t.Fatalf("CreateUser(%q): err = %v, ожидался ErrUserAlreadyExists", email, err)
+		}
+	}
+}
+
+func TestInMemoryStorageUpdatePasswordHash(t *testing.T) {
+	storage := memory.NewInMemoryStorage()
+	ctx := context.Background()
+
+	id, err := storage.CreateUser(ctx, "user@example.com", "old-hash")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	before, _ := storage.GetUserByID(ctx, id)
+
+	if err := storage.UpdatePasswordHash(ctx, id, "new-hash"); err != nil {
+		t.Fatalf("UpdatePasswordHash: %v", err)
+	}
+	u, err := storage.GetUserByEmail(ctx, "user@example.com")
+	if err != nil || u.GetPasswordHash() != "new-hash" {
+		t.Fatalf("хэш после смены: %q, err = %v", u.GetPasswordHash(), err)
+	}
+	changed := u.(auth.PasswordAgeProvider).GetPasswordChangedAt()
+	if changed.Before(before.(auth.PasswordAgeProvider).GetPasswordChangedAt()) {
+		t.Fatal("PasswordChangedAt не обновлен")
+	}
+	// Ранее полученная копия не меняется вместе с хранилищем
+	if before.GetPasswordHash() != "old-hash" {
+		t.Fatalf("копия пользователя изменилась: %q", before.GetPasswordHash())
+	}
+
+	if err := storage.UpdatePasswordHash(ctx, id+1, "hash"); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Fatalf("неизвестный id: err = %v, ожидался ErrUserNotFound", err)
+	}
+}
+
+// Запускайте с -race: проверяет блокировки хранилища.
+func TestInMemoryStorageConcurrentAccess(t *testing.T) {
+	storage := memory.NewInMemoryStorage()
+	ctx := context.Background()
+
+	const workers = 8
+	const perWorker = 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				email := fmt.Sprintf("user-%d-%d@example.com", w, i)
+				id, err := storage.CreateUser(ctx, email, "hash")
+				if err != nil {
+					t.Errorf("CreateUser(%q): %v", email, err)
+					return
+				}
+				if err := storage.UpdatePasswordHash(ctx, id, fmt.Sprintf("hash-%d", i)); err != nil {
+					t.Errorf("UpdatePasswordHash: %v", err)
+				}
+				if err := storage.BumpTokenVersion(ctx, id); err != nil {
+					t.Errorf("BumpTokenVersion: %v", err)
+				}
+				if _, err := storage.GetUserByEmail(ctx, email); err != nil {
+					t.Errorf("GetUserByEmail(%q): %v", email, err)
+				}
+				// Все гонятся за одним адресом: создать его должен ровно один
+				if _, err := storage.CreateUser(ctx, "shared@example.com", "hash"); err != nil && !errors.Is(err, auth.ErrUserAlreadyExists) {
+					t.Errorf("CreateUser(shared): %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < perWorker; i++ {
+			u, err := storage.GetUserByEmail(ctx, fmt.Sprintf("user-%d-%d@example.com", w, i))
+			if err != nil {
+				t.Fatalf("GetUserByEmail: %v", err)
+			}
+			if seen[u.GetID()] {
+				t.Fatalf("id %d выдан дважды", u.GetID())
+			}
+			seen[u.GetID()] = true
+			if u.GetPasswordHash() != fmt.Sprintf("hash-%d", i) {
+				t.Fatalf("хэш %q, ожидался hash-%d", u.GetPasswordHash(), i)
+			}
+		}
+	}
+	// Создано workers*perWorker пользователей и один общий
+	if id, err := storage.CreateUser(ctx, "last@example.com", "hash"); err != nil || id != workers*perWorker+2 {
+		t.Fatalf("следующий id = %d, err = %v, ожидался %d", id, err, workers*perWorker+2)
+	}
+}
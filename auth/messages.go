@@ -0,0 +1,143 @@
+package auth
+
+import "reflect"
+
+// ErrorFormatter возвращает текст ошибки для пользователя (локализация).
+// Идентичность ошибок не меняется - errors.Is работает как прежде;
+// форматер влияет только на текст ответов HTTP- и gRPC-оберток
+// (Middleware, Handlers и т.д.) и на ErrorMessage.
+type ErrorFormatter func(err error) string
+
+// MessageCatalog - тексты сообщений по ошибкам пакета (ErrTokenExpired и т.д.).
+type MessageCatalog map[error]string
+
+// Formatter превращает каталог в ErrorFormatter. Берется текст самой
+// конкретной ошибки цепочки (для "ErrTokenInvalid: ErrTokenTooLarge" -
+// ErrTokenTooLarge); если в каталоге нет ни одной, возвращается err.Error().
+func (c MessageCatalog) Formatter() ErrorFormatter {
+	return func(err error) string {
+		if msg, ok := c.lookup(err); ok {
+			return msg
+		}
+		return err.Error()
+	}
+}
+
+// lookup обходит цепочку ошибок (включая errors.Join и несколько %w)
+// и возвращает текст последней найденной в каталоге.
+func (c MessageCatalog) lookup(err error) (string, bool) {
+	var msg string
+	var found bool
+
+	var walk func(error)
+	walk = func(e error) {
+		if e == nil {
+			return
+		}
+		// Несравнимые типы ошибок нельзя использовать как ключ map
+		if reflect.TypeOf(e).Comparable() {
+			if m, ok := c[e]; ok {
+				msg, found = m, true
+			}
+		}
+		switch x := e.(type) {
+		case interface{ Unwrap() error }:
+			walk(x.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, inner := range x.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	walk(err)
+	return msg, found
+}
+
+// EnglishMessages - английский каталог сообщений:
+//
+//	auth.WithErrorFormatter(auth.EnglishMessages.Formatter())
+var EnglishMessages = MessageCatalog{
+	ErrInvalidCredentials:         "invalid credentials",
+	ErrWeakPassword:               "password does not meet the requirements",
+	ErrPasswordBreached:           "password has appeared in a data breach",
+	ErrPasswordTooLong:            "password is too long",
+	ErrPasswordReused:             "password was used recently",
+	ErrEmailNotVerified:           "email is not verified",
+	ErrPasswordExpired:            "password has expired, change it to continue",
+	ErrWrongTokenPurpose:          "token was issued for a different purpose",
+	ErrTokenAlreadyUsed:           "token has already been used",
+	ErrTOTPRequired:               "two-factor authentication code required",
+	ErrMFARequired:                "two-factor authentication required",
+	ErrInvalidTOTPCode:            "invalid two-factor authentication code",
+	ErrTOTPUnsupported:            "storage does not support two-factor enrollment",
+	ErrTokenExpired:               "token has expired",
+	ErrTokenNotYetValid:           "token is not valid yet",
+	ErrTokenIssuedInFuture:        "token was issued in the future",
+	ErrInvalidTokenOptions:        "invalid token options",
+	ErrWrongTokenType:             "wrong token type",
+	ErrReservedPurpose:            "token purpose is empty or reserved",
+	ErrLogoutUnsupported:          "logout is not configured",
+	ErrInvalidDPoPProof:           "invalid DPoP proof",
+	ErrUserDisabled:               "user account is disabled",
+	ErrReauthRequired:             "please sign in again to continue",
+	ErrProtectedClaim:             "token field cannot be changed",
+	ErrTokenInvalid:               "invalid token",
+	ErrUnexpectedSigningMethod:    "unexpected signing method",
+	ErrSigningFailed:              "failed to sign token",
+	ErrTokenTooLarge:              "token is too large",
+	ErrTokenOverBudget:            "token exceeds the size budget",
+	ErrIDGeneration:               "failed to generate token ID",
+	ErrUnknownKeyID:               "unknown key ID (kid)",
+	ErrInvalidIssuer:              "token was issued by a different issuer",
+	ErrNoPublicKeys:               "no public keys for JWKS",
+	ErrUnknownTenant:              "unknown tenant",
+	ErrInvalidAudience:            "token was issued for a different audience",
+	ErrRateLimited:                "too many attempts, try again later",
+	ErrMalformedHash:              "malformed password hash",
+	ErrWeakSecret:                 "secret key is too short",
+	ErrInvalidConfig:              "invalid configuration",
+	ErrAuditChainBroken:           "audit record chain is broken",
+	ErrSigningKeyUnavailable:      "signing key is unavailable",
+	ErrAccountLocked:              "account is temporarily locked",
+	ErrUserNotFound:               "user not found",
+	ErrUserAlreadyExists:          "user already exists",
+	ErrEmptyEmail:                 "email must not be empty",
+	ErrTokenRevoked:               "token has been revoked",
+	ErrMissingTokenID:             "token has no ID (jti)",
+	ErrMissingToken:               "missing authorization token",
+	ErrMultipleAuthHeaders:        "multiple Authorization headers",
+	ErrSessionNotFound:            "session not found",
+	ErrSessionRevoked:             "session has ended",
+	ErrSessionsUnsupported:        "session store is not configured",
+	ErrTokenVersioningUnsupported: "token versioning is not supported",
+	ErrLogoutAllUnsupported:       "logout from all devices is not configured",
+	ErrStorageTimeout:             "storage timed out",
+	ErrIPMismatch:                 "token was issued for a different IP address",
+	ErrBlacklistUnsupported:       "token blacklist is not configured",
+	ErrTokenNotFound:              "token not found",
+	ErrRefreshUnsupported:         "refresh token store is not configured",
+	ErrWebAuthnUnsupported:        "WebAuthn is not configured",
+	ErrCeremonyNotFound:           "WebAuthn ceremony not found",
+	ErrPasskeyInvalid:             "invalid passkey response",
+	ErrRefreshNotFound:            "refresh token not found",
+	ErrRefreshInvalid:             "invalid refresh token",
+	ErrRefreshReused:              "refresh token reuse detected",
+	ErrRefreshBindingMismatch:     "refresh token was issued to a different device",
+	ErrTokenExchangeUnsupported:   "token exchange is not configured",
+	ErrScopeEscalation:            "requested scope exceeds the subject token",
+	ErrTooManyTokens:              "too many active tokens",
+	ErrCSRFMismatch:               "invalid CSRF token",
+	ErrForbidden:                  "insufficient permissions",
+	ErrMalformedRequest:           "malformed request body",
+	ErrMethodNotAllowed:           "method not allowed",
+	ErrInternal:                   "internal server error",
+}
+
+// ErrorMessage возвращает текст ошибки для пользователя с учетом
+// WithErrorFormatter (по умолчанию - err.Error()).
+func (s *AuthService) ErrorMessage(err error) string {
+	if s.errorFormatter == nil {
+		return err.Error()
+	}
+	return s.errorFormatter(err)
+}
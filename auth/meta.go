@@ -0,0 +1,24 @@
+package auth
+
+import "context"
+
+// RequestMeta - сведения о клиенте запроса для аудита и списка сессий.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+// requestMetaKey - ключ для хранения RequestMeta в context.Context.
+type requestMetaKey struct{}
+
+// WithRequestMeta прикладывает к контексту сведения о клиенте. Login и другие
+// методы передают их в EventHook и SessionStore, не расширяя свои сигнатуры.
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext достает сведения, сохраненные WithRequestMeta.
+func RequestMetaFromContext(ctx context.Context) (RequestMeta, bool) {
+	meta, ok := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta, ok
+}
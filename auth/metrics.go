@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Metrics принимает счетчики аутентификации (Prometheus, StatsD и т.д.)
+// без зависимости библиотеки от конкретной системы метрик.
+// Методы вызываются синхронно и должны быть быстрыми и потокобезопасными.
+//
+// Пример адаптера для Prometheus:
+//
+//	type promMetrics struct {
+//		loginSuccess prometheus.Counter
+//		loginFailure *prometheus.CounterVec // label "reason"
+//		validations  *prometheus.CounterVec // label "result"
+//		rejections   *prometheus.CounterVec // label "reason"
+//	}
+//
+//	func (m promMetrics) IncLoginSuccess()              { m.loginSuccess.Inc() }
+//	func (m promMetrics) IncLoginFailure(reason string) { m.loginFailure.WithLabelValues(reason).Inc() }
+//	func (m promMetrics) ObserveValidation(ok bool) {
+//		m.validations.WithLabelValues(strconv.FormatBool(ok)).Inc()
+//	}
+//	func (m promMetrics) IncValidationFailure(reason string) {
+//		m.rejections.WithLabelValues(reason).Inc()
+//	}
+type Metrics interface {
+	IncLoginSuccess()
+	IncLoginFailure(reason string)
+	ObserveValidation(ok bool)
+	IncValidationFailure(reason string) // причины - константы Reason*
+}
+
+// HashMetrics - необязательное расширение Metrics: длительность
+// хэширования и проверки паролей (bcrypt, Argon2id) при входе, регистрации
+// и смене пароля. Помогает подобрать стоимость хэширования под SLO входа.
+// Если Metrics реализует HashMetrics, сервис вызывает ObserveHashDuration
+// на каждую операцию.
+type HashMetrics interface {
+	ObserveHashDuration(d time.Duration)
+}
+
+// observeHash передает длительность операции с хэшем в HashMetrics.
+func (s *AuthService) observeHash(start time.Time) {
+	if hm, ok := s.metrics.(HashMetrics); ok {
+		hm.ObserveHashDuration(time.Since(start))
+	}
+}
+
+// HashTimer - простая реализация Metrics с HashMetrics: запоминает
+// минимум, максимум и среднее время операций с хэшами паролей (тесты,
+// подбор стоимости bcrypt). Остальные счетчики не ведутся.
+//
+//	timer := &auth.HashTimer{}
+//	svc, _ := auth.NewAuthService(storage, secret, ttl, auth.WithMetrics(timer))
+//	// ... Login ...
+//	fmt.Println(timer.Stats().Avg)
+type HashTimer struct {
+	noopMetrics
+
+	mu    sync.Mutex
+	stats HashStats
+	total time.Duration
+}
+
+// HashStats - сводка HashTimer.
+type HashStats struct {
+	Count         int
+	Min, Max, Avg time.Duration
+}
+
+// ObserveHashDuration реализует HashMetrics
+func (t *HashTimer) ObserveHashDuration(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stats.Count == 0 || d < t.stats.Min {
+		t.stats.Min = d
+	}
+	if d > t.stats.Max {
+		t.stats.Max = d
+	}
+	t.stats.Count++
+	t.total += d
+	t.stats.Avg = t.total / time.Duration(t.stats.Count)
+}
+
+// Stats возвращает сводку наблюдений.
+func (t *HashTimer) Stats() HashStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// Reset очищает накопленные наблюдения.
+func (t *HashTimer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats = HashStats{}
+	t.total = 0
+}
+
+// noopMetrics - Metrics по умолчанию.
+type noopMetrics struct{}
+
+func (noopMetrics) IncLoginSuccess()            {}
+func (noopMetrics) IncLoginFailure(string)      {}
+func (noopMetrics) ObserveValidation(bool)      {}
+func (noopMetrics) IncValidationFailure(string) {}
+
+// Причины неудачного входа (Metrics.IncLoginFailure).
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureRateLimited        = "rate_limited"
+	LoginFailureAccountLocked      = "account_locked"
+	LoginFailureEmailNotVerified   = "email_not_verified"
+	LoginFailurePasswordExpired    = "password_expired"
+	LoginFailureTOTPRequired       = "totp_required"
+	LoginFailureMFARequired        = "mfa_required"
+	LoginFailureInvalidTOTP        = "invalid_totp"
+	LoginFailureCanceled           = "canceled"
+	LoginFailureError              = "error"
+)
+
+// loginFailureReason сводит ошибку входа к метке для метрик.
+func loginFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		return LoginFailureInvalidCredentials
+	case errors.Is(err, ErrRateLimited):
+		return LoginFailureRateLimited
+	case errors.Is(err, ErrAccountLocked):
+		return LoginFailureAccountLocked
+	case errors.Is(err, ErrEmailNotVerified):
+		return LoginFailureEmailNotVerified
+	case errors.Is(err, ErrPasswordExpired):
+		return LoginFailurePasswordExpired
+	case errors.Is(err, ErrTOTPRequired):
+		return LoginFailureTOTPRequired
+	case errors.Is(err, ErrMFARequired):
+		return LoginFailureMFARequired
+	case errors.Is(err, ErrInvalidTOTPCode):
+		return LoginFailureInvalidTOTP
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return LoginFailureCanceled
+	default:
+		return LoginFailureError
+	}
+}
+
+// observeValidation передает результат проверки токена в Metrics.
+func (s *AuthService) observeValidation(err error) {
+	s.metrics.ObserveValidation(err == nil)
+	if err == nil {
+		return
+	}
+	s.metrics.IncValidationFailure(validationReason(err))
+}
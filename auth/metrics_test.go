@@ -0,0 +1,113 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+// recordingMetrics записывает события Metrics и HashMetrics по порядку.
+type recordingMetrics struct {
+	mu        sync.Mutex
+	events    []string
+	durations []time.Duration
+}
+
+func (m *recordingMetrics) record(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+func (m *recordingMetrics) IncLoginSuccess()              { m.record("login_success") }
+func (m *recordingMetrics) IncLoginFailure(reason string) { m.record("login_failure:" + reason) }
+func (m *recordingMetrics) ObserveValidation(bool)        {}
+func (m *recordingMetrics) IncValidationFailure(string)   {}
+func (m *recordingMetrics) ObserveHashDuration(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, "hash")
+	m.durations = append(m.durations, d)
+}
+
+func TestHashDurationObservedOnLoginAndRegister(t *testing.T) {
+	metrics := &recordingMetrics{}
+	svc, err := auth.NewAuthService(memory.NewInMemoryStorage(), testSecret, time.Hour, auth.WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := svc.Login(ctx, testEmail, "wrong-password"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("Login с неверным паролем: %v", err)
+	}
+	// Неизвестный email сравнивается с фиктивным хэшем и тоже измеряется
+	if _, err := svc.Login(ctx, "nobody@example.com", testPassword); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("Login с неизвестным email: %v", err)
+	}
+
+	want := []string{
+		"hash",                  // Register: хэширование
+		"hash", "login_success", // Login: сравнение
+		"hash", "login_failure:invalid_credentials", // неверный пароль
+		"hash", "login_failure:invalid_credentials", // неизвестный email
+	}
+	if !slices.Equal(metrics.events, want) {
+		t.Fatalf("события = %v, ожидались %v", metrics.events, want)
+	}
+	for i, d := range metrics.durations {
+		// bcrypt со стоимостью по умолчанию заведомо дольше миллисекунды
+		if d < time.Millisecond {
+			t.Fatalf("длительность #%d = %s, ожидалось время операции bcrypt", i, d)
+		}
+	}
+}
+
+func TestHashTimerStats(t *testing.T) {
+	timer := &auth.HashTimer{}
+	svc, err := auth.NewAuthService(memory.NewInMemoryStorage(), testSecret, time.Hour, auth.WithMetrics(timer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+	}
+
+	stats := timer.Stats()
+	if stats.Count != 3 {
+		t.Fatalf("Count = %d, ожидалось 3 (Register и два Login)", stats.Count)
+	}
+	if stats.Min <= 0 || stats.Min > stats.Avg || stats.Avg > stats.Max {
+		t.Fatalf("нарушено Min <= Avg <= Max: %+v", stats)
+	}
+
+	timer.Reset()
+	if stats := timer.Stats(); stats != (auth.HashStats{}) {
+		t.Fatalf("после Reset: %+v", stats)
+	}
+	timer.ObserveHashDuration(2 * time.Millisecond)
+	timer.ObserveHashDuration(4 * time.Millisecond)
+	want := auth.HashStats{Count: 2, Min: 2 * time.Millisecond, Max: 4 * time.Millisecond, Avg: 3 * time.Millisecond}
+	if stats := timer.Stats(); stats != want {
+		t.Fatalf("Stats = %+v, ожидалось %+v", stats, want)
+	}
+}
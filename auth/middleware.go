@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// claimsContextKey - ключ для хранения JWTClaims в context.Context.
+type claimsContextKey struct{}
+
+// contextWithClaims кладет claims в контекст.
+func contextWithClaims(ctx context.Context, claims *JWTClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext достает claims, сохраненные Middleware.
+func ClaimsFromContext(ctx context.Context) (*JWTClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*JWTClaims)
+	return claims, ok && claims != nil
+}
+
+// ValidateRequest (Проверка HTTP-запроса)
+// Извлекает токен из заголовка "Authorization: Bearer <token>" и полностью
+// проверяет его. Префикс "Bearer" необязателен и нечувствителен к регистру.
+// Запрос с несколькими заголовками Authorization отклоняется
+// (ErrMultipleAuthHeaders), без токена - ErrMissingToken.
+// Если задан WithCookieName и заголовка нет, токен берется из cookie;
+// для изменяющих запросов тогда проверяется CSRF-заголовок (WithCSRFHeader).
+// Если нет и cookie, а задан WithQueryTokenParam, токен берется из URL.
+// Токен, привязанный к ключу клиента (cnf.jkt, см. WithDPoP), принимается
+// только с заголовком DPoP, подписанным этим ключом; схема "DPoP" в
+// Authorization допускается наравне с "Bearer".
+// Без RequestMeta в контексте IP клиента берется из r.RemoteAddr
+// (за прокси задайте RequestMeta с реальным IP заранее).
+// Удобен во фреймворках (Gin, Echo), где нет обертки над http.Handler.
+func (s *AuthService) ValidateRequest(r *http.Request) (*JWTClaims, error) {
+	rawToken, source, err := s.requestToken(r)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.ParseAndValidateTokenContext(requestContext(r), rawToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCSRF(r, claims, source); err != nil {
+		return nil, err
+	}
+	// ath доказательства считается от компактного JWT (RFC 9449), а не от
+	// представления WithTokenEncoder
+	compact, err := s.DecodeToken(rawToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDPoP(r, claims, compact); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// tokenSource - откуда взят токен запроса.
+type tokenSource int
+
+const (
+	sourceHeader tokenSource = iota
+	sourceCookie
+	sourceQuery
+)
+
+// requestToken извлекает токен запроса из Authorization, cookie
+// или параметра URL, в этом порядке.
+func (s *AuthService) requestToken(r *http.Request) (string, tokenSource, error) {
+	values := r.Header.Values("Authorization")
+	if len(values) > 1 {
+		return "", sourceHeader, ErrMultipleAuthHeaders
+	}
+	if len(values) == 1 {
+		return nonEmptyToken(bearerToken(values[0]), sourceHeader)
+	}
+
+	if s.cookieName != "" {
+		if cookie, err := r.Cookie(s.cookieName); err == nil && strings.TrimSpace(cookie.Value) != "" {
+			return strings.TrimSpace(cookie.Value), sourceCookie, nil
+		}
+	}
+	if s.queryTokenParam != "" {
+		return nonEmptyToken(strings.TrimSpace(r.URL.Query().Get(s.queryTokenParam)), sourceQuery)
+	}
+	return "", sourceHeader, ErrMissingToken
+}
+
+// nonEmptyToken возвращает ErrMissingToken для пустого токена.
+func nonEmptyToken(tokenString string, source tokenSource) (string, tokenSource, error) {
+	if tokenString == "" {
+		return "", source, ErrMissingToken
+	}
+	return tokenString, source, nil
+}
+
+// Middleware (HTTP-обертка)
+// Проверяет токен запроса (см. ValidateRequest) и передает claims дальше
+// через контекст запроса (см. ClaimsFromContext).
+// При ошибке отвечает 401 с JSON-телом (неверный CSRF-токен - 403).
+func (s *AuthService) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.ValidateRequest(r)
+		if errors.Is(err, ErrCSRFMismatch) {
+			writeJSONError(w, http.StatusForbidden, s.ErrorMessage(err))
+			return
+		}
+		if err != nil {
+			message := s.ErrorMessage(ErrTokenInvalid)
+			if errors.Is(err, ErrMissingToken) || errors.Is(err, ErrMultipleAuthHeaders) {
+				message = s.ErrorMessage(err)
+			}
+			writeJSONError(w, http.StatusUnauthorized, message)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(contextWithClaims(r.Context(), claims)))
+	})
+}
+
+// bearerToken убирает необязательный префикс "Bearer " (или "DPoP ")
+// из значения заголовка.
+func bearerToken(header string) string {
+	header = strings.TrimSpace(header)
+	for _, scheme := range []string{"bearer ", "dpop "} {
+		if len(header) >= len(scheme) && strings.EqualFold(header[:len(scheme)], scheme) {
+			header = header[len(scheme):]
+			break
+		}
+	}
+	return strings.TrimSpace(header)
+}
+
+// writeJSONError отвечает JSON-телом вида {"error": "..."}.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
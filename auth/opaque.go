@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TokenStore хранит claims непрозрачных (reference) токенов
+// (WithOpaqueTokens). Ключ - отпечаток токена (SHA-256), сам токен
+// в хранилище не попадает. Запись можно удалить после claims.ExpiresAt.
+// Lookup должен возвращать ErrTokenNotFound, если записи нет.
+type TokenStore interface {
+	Save(ctx context.Context, key string, claims *JWTClaims) error
+	Lookup(ctx context.Context, key string) (*JWTClaims, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// opaqueKey - ключ записи TokenStore для токена.
+func opaqueKey(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
+
+// isOpaqueToken сообщает, что токен непрозрачный: у JWT есть точки-разделители.
+// JWT, выпущенные до включения WithOpaqueTokens, проверяются как раньше.
+func (s *AuthService) isOpaqueToken(tokenString string) bool {
+	return s.tokenStore != nil && !strings.Contains(tokenString, ".")
+}
+
+// issueOpaqueToken сохраняет claims и возвращает случайный токен.
+func (s *AuthService) issueOpaqueToken(ctx context.Context, claims *JWTClaims) (string, error) {
+	tokenString, err := s.randomHex(32)
+	if err != nil {
+		return "", fmt.Errorf("%w: генерация токена: %v", ErrSigningFailed, err)
+	}
+	if err := s.tokenStore.Save(ctx, opaqueKey(tokenString), claims); err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// lookupOpaqueToken находит claims непрозрачного токена и проверяет сроки.
+// Как и для JWT, при истекшем сроке claims возвращаются вместе с ошибкой.
+func (s *AuthService) lookupOpaqueToken(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claims, err := s.tokenStore.Lookup(ctx, opaqueKey(tokenString))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("ошибка хранилища токенов: %w", err)
+	}
+
+	now := s.clock.Now()
+	if claims.ExpiresAt != nil && now.After(claims.ExpiresAt.Add(s.leeway)) {
+		return claims, ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(s.leeway).Before(claims.NotBefore.Time) {
+		return claims, ErrTokenNotYetValid
+	}
+	if s.rejectFutureIAT && claims.IssuedAt != nil && now.Add(s.leeway).Before(claims.IssuedAt.Time) {
+		return claims, ErrTokenIssuedInFuture
+	}
+	return claims, nil
+}
+
+// revokeOpaqueToken удаляет запись непрозрачного токена (Logout).
+func (s *AuthService) revokeOpaqueToken(ctx context.Context, tokenString string) error {
+	err := s.tokenStore.Delete(ctx, opaqueKey(tokenString))
+	if err != nil && !errors.Is(err, ErrTokenNotFound) {
+		return err
+	}
+	return nil
+}
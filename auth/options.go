@@ -0,0 +1,966 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Option - функциональная опция для настройки AuthService.
+type Option func(*AuthService)
+
+// WithBcryptCost задает стоимость (work factor) bcrypt.
+// Ноль означает bcrypt.DefaultCost.
+func WithBcryptCost(cost int) Option {
+	return func(s *AuthService) {
+		s.bcryptCost = cost
+	}
+}
+
+// WithTokenBlacklist подключает черный список токенов.
+// Без него Logout остается NO-OP.
+func WithTokenBlacklist(blacklist TokenBlacklist) Option {
+	return func(s *AuthService) {
+		s.blacklist = blacklist
+	}
+}
+
+// WithBlacklistFailureMode задает реакцию на ошибку TokenBlacklist при
+// проверке access-токена: BlacklistFailClosed (по умолчанию) отклоняет
+// токен, BlacklistFailOpen пропускает проверку отзыва ради доступности API.
+// Режим BlacklistFailOpen - осознанный компромисс: при создании сервиса
+// и на каждый пропуск пишется предупреждение (WithLogger, BlacklistMetrics).
+func WithBlacklistFailureMode(mode BlacklistFailureMode) Option {
+	return func(s *AuthService) {
+		s.blacklistFailureMode = mode
+	}
+}
+
+// WithRefreshStore подключает хранилище refresh-токенов.
+// Без него LoginWithRefresh и Refresh возвращают ErrRefreshUnsupported.
+func WithRefreshStore(store RefreshStore) Option {
+	return func(s *AuthService) {
+		s.refresh = store
+	}
+}
+
+// WithRefreshCache кэширует записи RefreshStore в памяти процесса на ttl,
+// чтобы Refresh на удаленном узле не ходил в центральное хранилище
+// за поиском токена (запись при ротации по-прежнему идет в хранилище).
+// Кэш заполняется при выдаче и поиске токенов и через WarmRefreshCache.
+// Отзывы через этот сервис сразу видны в кэше, а сделанные на других узлах -
+// только после истечения ttl: в этом окне старый refresh-токен может быть
+// обменян повторно без ErrRefreshReused. Выбирайте ttl короче допустимого
+// окна и направляйте запросы одной семьи на один узел. Ноль - без кэша.
+func WithRefreshCache(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.refreshCacheTTL = ttl
+	}
+}
+
+// WithRefreshBinding привязывает refresh-токены к клиенту, которому они
+// выданы: при выдаче в RefreshStore записываются IP и User-Agent
+// из RequestMeta, а Refresh с другого клиента отклоняется с
+// ErrRefreshBindingMismatch (токен при этом не гасится). mode задает
+// допуск к смене IP (RefreshBindingDevice - для мобильных клиентов).
+// По умолчанию RefreshBindingOff. Handlers передают RequestMeta сами,
+// при прямых вызовах Refresh используйте WithRequestMeta.
+func WithRefreshBinding(mode RefreshBindingMode) Option {
+	return func(s *AuthService) {
+		s.refreshBinding = mode
+	}
+}
+
+// WithRefreshTTL задает срок жизни refresh-токена (по умолчанию 30 дней).
+func WithRefreshTTL(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.refreshTTL = ttl
+	}
+}
+
+// WithClock подменяет источник времени (например, в тестах).
+func WithClock(c Clock) Option {
+	return func(s *AuthService) {
+		s.clock = c
+	}
+}
+
+// WithRevokeRefreshOnPasswordChange включает отзыв всех refresh-токенов
+// пользователя после ChangePassword, чтобы старые сессии завершались.
+func WithRevokeRefreshOnPasswordChange(enabled bool) Option {
+	return func(s *AuthService) {
+		s.revokeRefreshOnPasswordChange = enabled
+	}
+}
+
+// WithRehashOnLogin включает перехэширование пароля после успешного входа,
+// если хэш в Storage создан с меньшей стоимостью bcrypt, чем текущая.
+// Требует дополнительной записи в Storage (UpdatePasswordHash).
+func WithRehashOnLogin(enabled bool) Option {
+	return func(s *AuthService) {
+		s.rehashOnLogin = enabled
+	}
+}
+
+// WithLegacyHashVerifier подключает проверку хэшей устаревшего формата:
+// Login обращается к verifier, если хэш в Storage не является bcrypt,
+// и после успешного входа заменяет его на bcrypt (UpdatePasswordHash).
+func WithLegacyHashVerifier(verifier LegacyHashVerifier) Option {
+	return func(s *AuthService) {
+		s.legacyVerifier = verifier
+	}
+}
+
+// WithLoginAttemptStore включает блокировку аккаунта после серии неудачных входов.
+// Параметры блокировки задаются через WithLockout.
+func WithLoginAttemptStore(store LoginAttemptStore) Option {
+	return func(s *AuthService) {
+		s.attempts = store
+	}
+}
+
+// WithLockout задает порог блокировки: после maxFailedAttempts неудач
+// в пределах window аккаунт блокируется на lockoutDuration.
+// По умолчанию: 5 попыток за 15 минут, блокировка на 15 минут.
+func WithLockout(maxFailedAttempts int, window, lockoutDuration time.Duration) Option {
+	return func(s *AuthService) {
+		s.maxFailedAttempts = maxFailedAttempts
+		s.attemptWindow = window
+		s.lockoutDuration = lockoutDuration
+	}
+}
+
+// WithLoginBackoff включает задержку между неудачными попытками входа
+// (нужен WithLoginAttemptStore): после n-й неудачи следующая попытка
+// возможна не раньше чем через base*2^(n-1), но не больше max.
+// Вход во время задержки, как и заблокированный аккаунт, возвращает
+// *RetryAfterError с оставшимся временем; Handlers передают его
+// в заголовке Retry-After. base <= 0 отключает задержки (по умолчанию).
+func WithLoginBackoff(base, max time.Duration) Option {
+	return func(s *AuthService) {
+		s.backoffBase = base
+		s.backoffMax = max
+	}
+}
+
+// WithPatchablePrivileges разрешает PatchClaims менять поля прав
+// ("roles", "scope"). По умолчанию они защищены, как и exp или sub.
+func WithPatchablePrivileges(claims ...string) Option {
+	return func(s *AuthService) {
+		if s.patchablePrivileges == nil {
+			s.patchablePrivileges = make(map[string]bool, len(claims))
+		}
+		for _, name := range claims {
+			s.patchablePrivileges[name] = true
+		}
+	}
+}
+
+// WithClaimsEnricher добавляет в токен дополнительные поля при Login.
+// После проверки они доступны через JWTClaims.Extra.
+func WithClaimsEnricher(enricher ClaimsEnricher) Option {
+	return func(s *AuthService) {
+		s.enricher = enricher
+	}
+}
+
+// WithAudience задает аудиторию (aud): Login записывает ее в токен,
+// а проверка отклоняет токены с другой аудиторией (ErrInvalidAudience).
+// Для токена, действующего в нескольких сервисах, передайте их все:
+// aud станет массивом, а проверка примет токен, содержащий любую из них.
+// Свою принадлежность каждый сервис проверяет ValidateTokenForAudience.
+func WithAudience(audiences ...string) Option {
+	return func(s *AuthService) {
+		s.audience = nil
+		for _, aud := range audiences {
+			if aud != "" {
+				s.audience = append(s.audience, aud)
+			}
+		}
+	}
+}
+
+// WithKeySet включает ротацию HMAC-ключей. Login подписывает токены
+// ключом activeKID и пишет kid в заголовок, а проверка выбирает ключ
+// из keys по kid токена. Старые ключи оставляют в keys, пока не истекут
+// выпущенные ими токены. secretKey из конструктора при этом не используется.
+func WithKeySet(activeKID string, keys map[string][]byte) Option {
+	return func(s *AuthService) {
+		s.keyID = activeKID
+		s.keys = make(map[string]interface{}, len(keys))
+		for kid, key := range keys {
+			s.keys[kid] = key
+		}
+	}
+}
+
+// WithPasswordPolicy задает требования к паролю для Register и ChangePassword.
+// По умолчанию используется DefaultPasswordPolicy.
+func WithPasswordPolicy(policy PasswordPolicy) Option {
+	return func(s *AuthService) {
+		s.passwordPolicy = policy
+	}
+}
+
+// WithEventHook подключает получателя событий входа, выхода и обновления токенов.
+func WithEventHook(hook EventHook) Option {
+	return func(s *AuthService) {
+		s.eventHook = hook
+	}
+}
+
+// WithAuditSink подключает журнал аудита: смена и сброс пароля, подключение
+// 2FA, RevokeAllTokens и LogoutAll записываются в sink (см. AuditRecord).
+func WithAuditSink(sink AuditSink) Option {
+	return func(s *AuthService) {
+		s.auditSink = sink
+	}
+}
+
+// WithKeyID задает kid активного ключа для асимметричной подписи.
+// Login пишет его в заголовок токена, JWKS публикует ключ под этим kid.
+func WithKeyID(kid string) Option {
+	return func(s *AuthService) {
+		s.keyID = kid
+	}
+}
+
+// WithPublicKey добавляет дополнительный публичный ключ RSA под своим kid
+// (например, предыдущий ключ при ротации). Токены с этим kid будут
+// проверяться им, а JWKS опубликует его вместе с активным.
+func WithPublicKey(kid string, key *rsa.PublicKey) Option {
+	return func(s *AuthService) {
+		if s.keys == nil {
+			s.keys = make(map[string]interface{})
+		}
+		s.keys[kid] = key
+	}
+}
+
+// WithLeeway задает допуск рассинхронизации часов при проверке exp и nbf
+// (и iat при WithRejectFutureIssuedAt).
+// По умолчанию ноль (строгая проверка).
+func WithLeeway(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.leeway = d
+	}
+}
+
+// WithAllowedMethods разрешает проверку токенов, подписанных дополнительными
+// алгоритмами (например, "HS256" на время миграции на RS256).
+// Ключ для каждого алгоритма задается через WithMethodKey. "none" запрещен.
+func WithAllowedMethods(methods ...string) Option {
+	return func(s *AuthService) {
+		s.allowedMethods = append(s.allowedMethods, methods...)
+	}
+}
+
+// WithMethodKey задает ключ проверки для алгоритма из WithAllowedMethods:
+// []byte для HS*, *rsa.PublicKey для RS*.
+func WithMethodKey(alg string, key interface{}) Option {
+	return func(s *AuthService) {
+		if s.methodKeys == nil {
+			s.methodKeys = make(map[string]interface{})
+		}
+		s.methodKeys[alg] = key
+	}
+}
+
+// WithSigningMethod заменяет алгоритм и ключи, заданные конструктором,
+// например для EdDSA:
+//
+//	auth.WithSigningMethod(jwt.SigningMethodEdDSA, ed25519PrivateKey, ed25519PublicKey)
+//
+// Проверка принимает только токены этого алгоритма. signKey может быть nil,
+// если сервис только проверяет токены.
+func WithSigningMethod(method jwt.SigningMethod, signKey, verifyKey interface{}) Option {
+	return func(s *AuthService) {
+		s.signingMethod = method
+		s.signKey = signKey
+		s.verifyKey = verifyKey
+	}
+}
+
+// WithRefreshThreshold задает, за сколько до истечения access-токена
+// ValidateAndRefreshIfNeeded выпускает новый (по умолчанию 5 минут).
+func WithRefreshThreshold(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.refreshThreshold = d
+	}
+}
+
+// WithMaxTokenTTL задает максимальный срок жизни токена, который можно
+// запросить через TokenOptions.TTL (например, для "запомнить меня").
+// По умолчанию предел равен ttl из конструктора.
+func WithMaxTokenTTL(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.maxTokenTTL = d
+	}
+}
+
+// WithTTLResolver задает срок жизни access-токена в зависимости от
+// пользователя (например, по ролям). Он применяется, если TokenOptions.TTL
+// не задан, и так же ограничивается WithMaxTokenTTL (по умолчанию - ttl
+// конструктора): для сроков длиннее ttl конструктора задайте предел явно.
+func WithTTLResolver(resolver TTLResolver) Option {
+	return func(s *AuthService) {
+		s.ttlResolver = resolver
+	}
+}
+
+// WithVerificationTTL задает срок жизни токена подтверждения email (по умолчанию 24 часа).
+func WithVerificationTTL(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.verificationTTL = ttl
+	}
+}
+
+// WithPasswordMaxAge задает срок действия пароля: пароль пользователя
+// (PasswordAgeProvider) старше maxAge считается устаревшим. С
+// PasswordExpiryFlag вход успешен, а токены получают pwd_expired: true;
+// с PasswordExpiryBlock Login возвращает *PasswordExpiredError (ErrPasswordExpired)
+// с токеном сброса пароля. Ноль (по умолчанию) - без срока.
+func WithPasswordMaxAge(maxAge time.Duration, mode PasswordExpiryMode) Option {
+	return func(s *AuthService) {
+		s.passwordMaxAge = maxAge
+		s.passwordExpiryMode = mode
+	}
+}
+
+// WithEmailVerifiedClaim записывает в access-токены claim email_verified
+// (как в OIDC) из VerificationProvider пользователя, чтобы сервисы могли
+// проверить его без запроса к БД (JWTClaims.EmailVerified). Без
+// VerificationProvider значение - false.
+func WithEmailVerifiedClaim(enabled bool) Option {
+	return func(s *AuthService) {
+		s.emailVerifiedClaim = enabled
+	}
+}
+
+// WithRequireVerifiedEmail запрещает вход пользователям с неподтвержденным
+// email (VerificationProvider): Login возвращает ErrEmailNotVerified.
+func WithRequireVerifiedEmail(required bool) Option {
+	return func(s *AuthService) {
+		s.requireVerified = required
+	}
+}
+
+// WithDeviceTrustTTL задает срок жизни токена доверенного устройства
+// (IssueDeviceToken, по умолчанию 30 дней).
+func WithDeviceTrustTTL(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.deviceTrustTTL = ttl
+	}
+}
+
+// WithMaxActiveTokensPerUser ограничивает число действующих access-токенов
+// пользователя: каждый выпуск (вход, обновление) записывается в store,
+// а при превышении max новый токен отклоняется (TokenLimitReject,
+// ErrTooManyTokens) или самые старые отзываются через черный список
+// (TokenLimitEvictOldest, нужен WithTokenBlacklist). Подходит для API
+// без сессий; с сессиями удобнее WithMaxSessions. Без store или при
+// max <= 0 ограничения нет.
+func WithMaxActiveTokensPerUser(store ActiveTokenStore, max int, mode TokenLimitMode) Option {
+	return func(s *AuthService) {
+		s.activeTokens = store
+		s.maxActiveTokens = max
+		s.tokenLimitMode = mode
+	}
+}
+
+// WithTokenExchange включает ExchangeToken: serviceID - идентификатор
+// этого сервиса, который записывается в act.sub выданных токенов
+// (например, "svc-orders"). ttl ограничивает их срок жизни (не дольше
+// исходного токена); ttl <= 0 - до exp исходного токена.
+func WithTokenExchange(serviceID string, ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.exchangeServiceID = serviceID
+		s.exchangeTTL = ttl
+	}
+}
+
+// WithImpersonation задает роль, дающую право на IssueImpersonationToken
+// (по умолчанию "admin"), и срок жизни таких токенов (по умолчанию
+// 15 минут). Пустая роль или ttl <= 0 оставляют значение по умолчанию.
+func WithImpersonation(role string, ttl time.Duration) Option {
+	return func(s *AuthService) {
+		if role != "" {
+			s.impersonationRole = role
+		}
+		if ttl > 0 {
+			s.impersonationTTL = ttl
+		}
+	}
+}
+
+// WithPasswordResetTTL задает срок жизни токена сброса пароля (по умолчанию 1 час).
+func WithPasswordResetTTL(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.passwordResetTTL = ttl
+	}
+}
+
+// WithMagicLinkTTL задает срок жизни ссылки входа BeginMagicLink
+// (по умолчанию 15 минут).
+func WithMagicLinkTTL(ttl time.Duration) Option {
+	return func(s *AuthService) {
+		s.magicLinkTTL = ttl
+	}
+}
+
+// WithRateLimiter ограничивает частоту попыток входа. Проверка выполняется
+// до bcrypt, поэтому отклоненные попытки не нагружают CPU.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(s *AuthService) {
+		s.rateLimiter = limiter
+	}
+}
+
+// WithRateLimitKey переопределяет ключ для RateLimiter (по умолчанию email).
+func WithRateLimitKey(fn RateLimitKeyFunc) Option {
+	return func(s *AuthService) {
+		s.rateLimitKey = fn
+	}
+}
+
+// WithIssuer задает издателя (iss): Login записывает его в токен,
+// а проверка отклоняет токены другого издателя (ErrInvalidIssuer).
+func WithIssuer(issuer string) Option {
+	return func(s *AuthService) {
+		s.issuer = issuer
+	}
+}
+
+// WithSigner передает подпись токенов внешнему Signer (KMS, HSM): ключ
+// подписи сервиса не используется и может быть не задан. Проверка
+// остается локальной - по открытому ключу сервиса:
+//
+//	auth.NewAuthServiceRS256(storage, nil, publicKey, ttl, auth.WithSigner(kmsSigner))
+//
+// Signer.Alg() должен совпадать с алгоритмом сервиса. kid берется из
+// WithKeyID; ключи арендаторов (WithTenantKeys) с Signer не применяются.
+func WithSigner(signer Signer) Option {
+	return func(s *AuthService) {
+		s.signer = signer
+	}
+}
+
+// WithSignatureVerifier передает проверку подписи внешнему
+// SignatureVerifier вместо локальных ключей (в т.ч. WithKeyID-набора,
+// WithMethodKey и WithTenantKeys). Сроки, aud и iss по-прежнему
+// проверяются сервисом. SignatureVerifier.Alg() должен совпадать
+// с алгоритмом сервиса.
+func WithSignatureVerifier(verifier SignatureVerifier) Option {
+	return func(s *AuthService) {
+		s.sigVerifier = verifier
+	}
+}
+
+// WithSecretProvider заменяет статический HMAC-секрет динамическим:
+// provider вызывается при каждой подписи и проверке токена. Его ошибка
+// возвращается как ErrSigningKeyUnavailable. Кэширование - на стороне provider.
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(s *AuthService) {
+		s.secretProvider = provider
+	}
+}
+
+// WithSessionStore включает учет сессий: каждый вход создает сессию,
+// ее ID записывается в токен (sid), а токены завершенных сессий отклоняются.
+func WithSessionStore(store SessionStore) Option {
+	return func(s *AuthService) {
+		s.sessions = store
+	}
+}
+
+// WithMaxSessions ограничивает число одновременных сессий пользователя:
+// при превышении самая старая сессия завершается. Ноль - без ограничения.
+func WithMaxSessions(n int) Option {
+	return func(s *AuthService) {
+		s.maxSessions = n
+	}
+}
+
+// WithSingleSession оставляет пользователю одну активную сессию: каждый
+// успешный вход перед выдачей токена завершает все прежние (как LogoutAll),
+// и их токены перестают проходить проверку. Нужен WithTokenVersioning
+// или WithSessionStore.
+func WithSingleSession(enabled bool) Option {
+	return func(s *AuthService) {
+		s.singleSession = enabled
+	}
+}
+
+// WithUserStatusResolver подключает проверку состояния аккаунта при каждой
+// проверке токена: токены заблокированных (UserDisabled) и удаленных
+// (UserDeleted) пользователей отклоняются ошибкой ErrUserDisabled, даже
+// если срок их действия не истек. Добавляет обращение к resolver
+// на каждый запрос, поэтому по умолчанию выключено.
+func WithUserStatusResolver(resolver UserStatusResolver) Option {
+	return func(s *AuthService) {
+		s.userStatus = resolver
+	}
+}
+
+// WithDPoP включает привязку токенов к ключу клиента (DPoP, RFC 9449):
+// Handlers.Login, получив запрос с заголовком DPoP, проверяет
+// доказательство и записывает отпечаток ключа в cnf.jkt выданных токенов
+// (программно - TokenOptions.DPoPThumbprint в LoginWithOptions). Такой
+// токен ValidateRequest и Middleware принимают только вместе со свежим
+// DPoP-доказательством, подписанным тем же ключом, поэтому украденный
+// токен бесполезен без закрытого ключа клиента. maxProofAge - срок
+// годности доказательства (<= 0 - минута). С WithTokenBlacklist повторное
+// предъявление доказательства отклоняется.
+// ParseAndValidateToken не видит запроса и доказательство не проверяет;
+// UnaryServerInterceptor привязанные токены отклоняет.
+func WithDPoP(maxProofAge time.Duration) Option {
+	return func(s *AuthService) {
+		s.dpop = true
+		s.dpopProofAge = maxProofAge
+	}
+}
+
+// WithStrictLogout запрещает Logout молча ничего не делать: без черного
+// списка (WithTokenBlacklist) Logout завершает сессию токена
+// (WithSessionStore), а если ее нет - возвращает ErrLogoutUnsupported.
+// Так ошибка конфигурации видна при интеграции. По умолчанию выключено:
+// Logout без черного списка возвращает nil.
+func WithStrictLogout(enabled bool) Option {
+	return func(s *AuthService) {
+		s.strictLogout = enabled
+	}
+}
+
+// WithRefreshCookie переносит refresh-токен Handlers в cookie:
+// Login и Refresh ставят HttpOnly-cookie с флагами Secure и SameSite
+// вместо поля refresh_token в JSON, Refresh читает токен из нее, если его
+// нет в теле, а Logout удаляет cookie и отзывает токен из нее.
+// Нулевые поля cookie получают безопасные значения по умолчанию.
+func WithRefreshCookie(cookie RefreshCookie) Option {
+	return func(s *AuthService) {
+		s.refreshCookie = &cookie
+	}
+}
+
+// WithCookieName разрешает брать токен из cookie с указанным именем,
+// если заголовок Authorization отсутствует (заголовок имеет приоритет).
+// По умолчанию выключено.
+//
+// Браузер отправляет cookie автоматически, поэтому такой режим
+// уязвим для CSRF: выставляйте cookie с SameSite=Strict (или Lax)
+// и HttpOnly, а для изменяющих запросов проверяйте CSRF-токен
+// (WithCSRFHeader, IssueCSRFToken).
+func WithCookieName(name string) Option {
+	return func(s *AuthService) {
+		s.cookieName = name
+	}
+}
+
+// WithQueryTokenParam разрешает брать токен из параметра URL с указанным
+// именем (например, "access_token"), если нет ни заголовка Authorization,
+// ни cookie (WithCookieName). Нужен для рукопожатия WebSocket: браузер
+// не позволяет задать для него заголовки. По умолчанию выключено.
+//
+// URL с токеном попадает в журналы прокси и серверов, историю браузера
+// и заголовок Referer: включайте параметр только на маршрутах WebSocket
+// (отдельным AuthService или проверкой пути) и используйте короткоживущие
+// токены, а доступ к журналам ограничьте.
+func WithQueryTokenParam(name string) Option {
+	return func(s *AuthService) {
+		s.queryTokenParam = name
+	}
+}
+
+// WithCSRFHeader требует для изменяющих запросов (не GET, HEAD, OPTIONS,
+// TRACE) с токеном из cookie заголовок name (например, "X-CSRF-Token")
+// со значением IssueCSRFToken. Запросы с заголовком Authorization не
+// проверяются: браузер не добавляет его сам.
+func WithCSRFHeader(name string) Option {
+	return func(s *AuthService) {
+		s.csrfHeader = name
+	}
+}
+
+// WithCSRFKey задает ключ CSRF-токенов. По умолчанию он выводится из
+// HMAC-секрета, а при RSA и SecretProvider генерируется при запуске -
+// тогда для нескольких экземпляров сервиса задайте общий ключ явно.
+func WithCSRFKey(key []byte) Option {
+	return func(s *AuthService) {
+		s.csrfKey = key
+	}
+}
+
+// WithMetrics подключает сбор метрик входа и проверки токенов.
+// По умолчанию метрики не собираются.
+func WithMetrics(metrics Metrics) Option {
+	return func(s *AuthService) {
+		s.metrics = metrics
+	}
+}
+
+// WithTokenVersioning включает отзыв всех токенов пользователя через версию
+// (RevokeAllTokens). Storage должен реализовать TokenVersionStorage, а модель
+// пользователя - TokenVersionProvider. Каждая проверка токена читает
+// пользователя из Storage.
+func WithTokenVersioning(enabled bool) Option {
+	return func(s *AuthService) {
+		s.tokenVersioning = enabled
+	}
+}
+
+// WithSubjectClaim записывает ID пользователя в стандартный claim sub
+// (SubjectAlso - вместе с user_id, SubjectOnly - вместо него) для
+// совместимости со сторонними JWT-верификаторами. При проверке UserID
+// заполняется из sub, если user_id в токене нет.
+func WithSubjectClaim(mode SubjectMode) Option {
+	return func(s *AuthService) {
+		s.subjectMode = mode
+	}
+}
+
+// WithStorageTimeout ограничивает время каждого вызова Storage: контекст
+// вызова получает таймаут d, а его истечение возвращается как ErrStorageTimeout.
+// Таймаут действует, только если Storage учитывает ctx (драйверы БД это делают).
+// Ноль (по умолчанию) - без дополнительного таймаута.
+func WithStorageTimeout(d time.Duration) Option {
+	return func(s *AuthService) {
+		s.storageTimeout = d
+	}
+}
+
+// WithBreachChecker отклоняет в Register, ChangePassword и ResetPassword
+// пароли из известных утечек (ErrPasswordBreached). mode задает поведение
+// при ошибке проверки (по умолчанию BreachCheckFailOpen - пароль
+// принимается). Эталонная реализация с k-анонимностью - пакет auth/hibp:
+//
+//	auth.WithBreachChecker(hibp.New().Check, auth.BreachCheckFailOpen)
+func WithBreachChecker(checker BreachChecker, mode BreachCheckFailureMode) Option {
+	return func(s *AuthService) {
+		s.breachChecker = checker
+		s.breachCheckFailureMode = mode
+	}
+}
+
+// WithPasswordHistory запрещает повторно использовать depth последних паролей
+// в ChangePassword и ResetPassword (ErrPasswordReused). При depth <= 0
+// запоминается 5 паролей.
+func WithPasswordHistory(store PasswordHistoryStore, depth int) Option {
+	return func(s *AuthService) {
+		if depth <= 0 {
+			depth = defaultPasswordHistoryDepth
+		}
+		s.passwordHistory = store
+		s.passwordHistoryDepth = depth
+	}
+}
+
+// WithTrustedIssuers включает прием access-токенов нескольких издателей
+// (внешних IdP): ключ и алгоритм проверки выбираются по iss токена,
+// aud проверяется по IssuerConfig.Audience издателя (пусто - WithAudience).
+// Токены неизвестных издателей отклоняются с ErrInvalidIssuer. Собственные
+// токены сервиса принимаются по-прежнему, если задан WithIssuer.
+// Для IdP с typ "JWT" нужен WithLegacyTokenType; user_id таких токенов
+// обычно пуст (sub не число), поэтому WithTokenVersioning и WithUserStatusResolver
+// к ним неприменимы. Несовместим с WithSignatureVerifier.
+//
+//	auth.WithTrustedIssuers(map[string]auth.IssuerConfig{
+//		"https://idp.example.com": {Key: idpPublicKey, Audience: []string{"orders"}},
+//	})
+func WithTrustedIssuers(issuers map[string]IssuerConfig) Option {
+	return func(s *AuthService) {
+		s.trustedIssuerConfigs = issuers
+	}
+}
+
+// WithTokenEncoder задает представление выдаваемых access-токенов
+// (Login, обновление токенов, Handlers), например JSONEnvelopeEncoder.
+// Middleware, ValidateRequest и UnaryServerInterceptor декодируют его
+// перед проверкой. По умолчанию - компактный JWT по стандарту (RFC 7519).
+func WithTokenEncoder(encoder TokenEncoder) Option {
+	return func(s *AuthService) {
+		s.tokenEncoder = encoder
+	}
+}
+
+// WithTokenHeaders добавляет заголовки в выпускаемые токены (например, cty
+// или typ) - для шлюзов, маршрутизирующих по заголовку. Заголовок alg
+// переопределить нельзя (NewAuthService вернет ошибку), а kid из
+// WithKeyID/WithKeySet имеет приоритет над переданным здесь. Заданный
+// здесь typ заменяет TokenTypeAccess у access-токенов и тогда требуется
+// при их проверке; typ служебных токенов не меняется.
+func WithTokenHeaders(headers map[string]interface{}) Option {
+	return func(s *AuthService) {
+		s.tokenHeaders = make(map[string]interface{}, len(headers))
+		for name, value := range headers {
+			s.tokenHeaders[name] = value
+		}
+	}
+}
+
+// WithPasswordHasher задает алгоритм хэширования новых паролей
+// (например, DefaultArgon2idHasher). Хэши bcrypt и Argon2id проверяются
+// при любом алгоритме, поэтому пользователей можно переводить постепенно
+// (см. WithRehashOnLogin). По умолчанию - bcrypt (WithBcryptCost).
+func WithPasswordHasher(hasher PasswordHasher) Option {
+	return func(s *AuthService) {
+		s.hasher = hasher
+	}
+}
+
+// WithVerifyHasher добавляет алгоритм проверки хэшей с префиксом prefix
+// (например, "$scrypt$" или "pbkdf2_sha256$") на время миграции: в базе
+// одновременно лежат хэши разных форматов, и при входе каждый проверяется
+// своим алгоритмом. bcrypt и Argon2id распознаются и без этой опции.
+// Новые пароли всегда хэшируются основным алгоритмом (WithPasswordHasher),
+// а хэш, подошедший через hasher, после успешного входа заменяется хэшем
+// основного алгоритма.
+func WithVerifyHasher(prefix string, hasher PasswordHasher) Option {
+	return func(s *AuthService) {
+		if prefix != "" && hasher != nil {
+			s.verifyHashers = append(s.verifyHashers, hasherEntry{prefix: prefix, hasher: hasher})
+		}
+	}
+}
+
+// WithLongPasswords задает обработку паролей длиннее 72 байт при bcrypt:
+// LongPasswordReject (по умолчанию) или LongPasswordPrehash.
+// На Argon2id и собственные PasswordHasher не влияет.
+func WithLongPasswords(mode LongPasswordMode) Option {
+	return func(s *AuthService) {
+		s.longPasswords = mode
+	}
+}
+
+// WithLogger подключает журнал: Info - успешный вход, выход и обновление
+// токенов, Debug - причины отказов и claims проверенных токенов.
+// Токены, пароли и email не журналируются. По умолчанию журнал не ведется.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *AuthService) {
+		s.logger = logger
+	}
+}
+
+// WithRedactedClaims добавляет ключи claims (путь через точку, как
+// у JWTClaims.Get: "org.owner_email"), которые журнал заменяет
+// на RedactedValue вместе с email. Нужен, если WithClaimsEnricher
+// кладет в токен персональные данные (телефон, ФИО и т.п.).
+func WithRedactedClaims(keys ...string) Option {
+	return func(s *AuthService) {
+		s.redactedClaims = append(s.redactedClaims, keys...)
+	}
+}
+
+// WithIPBinding привязывает токены к IP клиента: Login записывает отпечаток
+// IP из RequestMeta, а проверка отклоняет токен с другого адреса
+// (ErrIPMismatch). Используйте ValidateRequest/Middleware или передавайте
+// RequestMeta в контексте проверки.
+// Подходит для админ-панелей: за NAT и в мобильных сетях IP клиента
+// меняется, и пользователю придется входить заново.
+func WithIPBinding(enabled bool) Option {
+	return func(s *AuthService) {
+		s.ipBinding = enabled
+	}
+}
+
+// WithOpaqueTokens включает непрозрачные (reference) токены: Login возвращает
+// случайную строку, а claims хранятся в store. Содержимое токена не видно
+// клиенту, а отзыв мгновенный (удаление записи), ценой обращения к store
+// на каждую проверку. API не меняется; ранее выданные JWT проверяются
+// как раньше, пока не истекут.
+func WithOpaqueTokens(store TokenStore) Option {
+	return func(s *AuthService) {
+		s.tokenStore = store
+	}
+}
+
+// WithTenantKeys включает изолированные ключи арендаторов: токены входа
+// с арендатором в контексте (WithTenant) подписываются его ключом и
+// получают tid, а при проверке ключ выбирается по tid токена, так что
+// утечка ключа одного арендатора не позволяет подделать токены другого.
+// Токены без арендатора подписываются и проверяются ключом сервиса.
+// Принадлежность токена арендатору запроса проверяет вызывающий (claims.TenantID).
+func WithTenantKeys(resolver TenantKeyResolver) Option {
+	return func(s *AuthService) {
+		s.tenantResolver = resolver
+	}
+}
+
+// WithRiskEvaluator включает пошаговую 2FA: второй фактор запрашивается,
+// только когда evaluator этого требует (MFARequiredError), а при низком
+// риске вход проходит по паролю даже с подключенным TOTP.
+func WithRiskEvaluator(evaluator RiskEvaluator) Option {
+	return func(s *AuthService) {
+		s.riskEvaluator = evaluator
+	}
+}
+
+// WithClaimsValidator подключает проверку бизнес-правил: validator
+// вызывается после проверки подписи, сроков и отзыва. Его ошибка
+// возвращается обернутой в ErrTokenInvalid (errors.Is работает для обеих).
+func WithClaimsValidator(validator ClaimsValidator) Option {
+	return func(s *AuthService) {
+		s.claimsValidator = validator
+	}
+}
+
+// WithExpiredFastPath отклоняет истекшие токены по exp из payload до
+// проверки подписи, экономя CPU при потоке старых токенов (например, при атаке).
+// Для таких токенов ошибка ErrTokenExpired возвращается без claims:
+// Introspect не заполняет поля пользователя, а ValidateAndRefreshIfNeeded
+// не продлевает уже истекший токен. По умолчанию выключено:
+// подпись проверяется первой.
+func WithExpiredFastPath(enabled bool) Option {
+	return func(s *AuthService) {
+		s.expiredFastPath = enabled
+	}
+}
+
+// WithPreviousSecret - ротация HMAC-секрета без kid: новые токены
+// подписываются текущим секретом, а до момента until (по часам сервиса)
+// принимаются и токены, подписанные прежним секретом old. После until
+// старые токены отклоняются. К old применяется то же требование длины,
+// что и к текущему секрету (ErrWeakSecret).
+func WithPreviousSecret(old []byte, until time.Time) Option {
+	return func(s *AuthService) {
+		s.previousSecret = old
+		s.previousUntil = until
+	}
+}
+
+// WithPepper включает перец - серверный секрет, с которым пароль
+// смешивается (HMAC-SHA256) перед хэшированием и проверкой в Login,
+// Register, ChangePassword и ResetPassword. Без перца украденную базу
+// хэшей нельзя перебирать офлайн. Перец хранится вне базы (например,
+// в переменной окружения, см. SecretFromEnv).
+// Хэши, созданные до включения перца, перестают подходить: для плавного
+// перехода используйте WithPreviousPepper(nil).
+func WithPepper(pepper []byte) Option {
+	return func(s *AuthService) {
+		s.pepper = pepper
+	}
+}
+
+// WithPreviousPepper - ротация перца: пароль, не подошедший с текущим
+// перцем, проверяется с прежним old (nil - без перца), и при успешном
+// входе хэш заменяется хэшем с текущим перцем. Неверный пароль при этом
+// проверяется дважды. Убирайте опцию, когда хэши обновятся.
+func WithPreviousPepper(old []byte) Option {
+	return func(s *AuthService) {
+		s.previousPepper = old
+		s.hasPreviousPepper = true
+	}
+}
+
+// WithEmailNormalizer задает нормализацию email перед обращением к Storage
+// в Login, Register, UserExists и GeneratePasswordResetToken.
+// По умолчанию - NormalizeEmail (обрезка пробелов и нижний регистр);
+// NormalizeEmailNFC дополнительно приводит адрес к Unicode NFC,
+// nil отключает нормализацию.
+// Регистронезависимый поиск работает, только если email в Storage
+// хранятся в той же форме: существующие записи нужно нормализовать
+// (например, UPDATE users SET email = lower(trim(email))).
+func WithEmailNormalizer(normalizer EmailNormalizer) Option {
+	return func(s *AuthService) {
+		s.emailNormalizer = normalizer
+	}
+}
+
+// WithRejectFutureIssuedAt отклоняет токены, чей iat позже текущего
+// времени сервиса больше чем на WithLeeway (ErrTokenIssuedInFuture):
+// это признак сбоя часов у выпускающей стороны или подделки.
+// По умолчанию выключено, т.к. небольшая рассинхронизация часов обычна.
+func WithRejectFutureIssuedAt(enabled bool) Option {
+	return func(s *AuthService) {
+		s.rejectFutureIAT = enabled
+	}
+}
+
+// WithIDGenerator задает генератор jti для access- и служебных токенов
+// (например, UUIDv7 для сортируемых идентификаторов). По умолчанию -
+// 16 случайных байт (WithRandReader) в hex. Ошибка генератора прерывает
+// вход с ErrIDGeneration. nil оставляет генератор по умолчанию.
+func WithIDGenerator(generator IDGenerator) Option {
+	return func(s *AuthService) {
+		if generator != nil {
+			s.idGenerator = generator
+		}
+	}
+}
+
+// WithRandReader задает источник случайности для jti, refresh-
+// и непрозрачных токенов, ID сессий, TOTP-секретов и кодов восстановления
+// (например, детерминированный в тестах или сертифицированный FIPS-модуль).
+// По умолчанию - crypto/rand.Reader; nil оставляет его. Соли хэшей паролей
+// и challenge WebAuthn генерируются соответствующими библиотеками.
+func WithRandReader(r io.Reader) Option {
+	return func(s *AuthService) {
+		if r != nil {
+			s.randReader = r
+		}
+	}
+}
+
+// WithTokenSizeBudget ограничивает размер выпускаемого JWT в байтах:
+// если из-за ClaimsEnricher, ролей или scopes токен вырос больше budget,
+// выпуск завершается ошибкой ErrTokenOverBudget. Так проблема видна при
+// входе, а не как отказ прокси с лимитом заголовков (обычно 8 КБ).
+// Проверяются и служебные токены. По умолчанию ограничения нет.
+func WithTokenSizeBudget(budget int) Option {
+	return func(s *AuthService) {
+		s.tokenSizeBudget = budget
+	}
+}
+
+// WithMaxTokenBytes задает предельный размер проверяемого токена в байтах
+// (по умолчанию 8192). Токен длиннее отклоняется до любого разбора с
+// ErrTokenTooLarge - дешевая защита от DoS на пути проверки.
+// n <= 0 оставляет значение по умолчанию.
+func WithMaxTokenBytes(n int) Option {
+	return func(s *AuthService) {
+		if n > 0 {
+			s.maxTokenBytes = n
+		}
+	}
+}
+
+// WithWebAuthn включает вход по passkey (BeginRegistration, BeginLogin и т.д.).
+// config задает отношение с проверяющей стороной (RPID, RPDisplayName,
+// RPOrigins), store хранит ключи пользователей и данные церемоний.
+// Некорректный config - ошибка конструктора.
+func WithWebAuthn(config *webauthn.Config, store WebAuthnStore) Option {
+	return func(s *AuthService) {
+		s.webauthnConfig = config
+		s.webauthnStore = store
+	}
+}
+
+// WithErrorFormatter задает текст ошибок в ответах HTTP- и gRPC-оберток
+// и в ErrorMessage - например, EnglishMessages.Formatter() или свой
+// каталог MessageCatalog. Сами ошибки и errors.Is не меняются.
+func WithErrorFormatter(formatter ErrorFormatter) Option {
+	return func(s *AuthService) {
+		s.errorFormatter = formatter
+	}
+}
+
+// WithTOTPIssuer задает название сервиса в приложении-аутентификаторе
+// для EnrollTOTP. По умолчанию используется WithIssuer.
+func WithTOTPIssuer(name string) Option {
+	return func(s *AuthService) {
+		s.totpIssuerName = name
+	}
+}
+
+// WithLegacyTokenType принимает токены, выпущенные до введения заголовка
+// typ (typ "JWT" или без него), на всех путях проверки. Включайте на время
+// перехода, пока не истекут ранее выданные токены: назначение служебных
+// токенов по-прежнему проверяется по claim purpose.
+func WithLegacyTokenType(enabled bool) Option {
+	return func(s *AuthService) {
+		s.legacyTokenType = enabled
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Сравнение заранее собранного s.parser со сборкой парсера на каждый
+// вызов, как было до его появления.
+func BenchmarkParser(b *testing.B) {
+	s, err := NewAuthService(nil, []byte(strings.Repeat("s", 32)), time.Hour,
+		WithIssuer("https://auth.example.com"), WithAudience("api"), WithLeeway(time.Second))
+	if err != nil {
+		b.Fatal(err)
+	}
+	now := time.Now()
+	token, err := s.sign(context.Background(), JWTClaims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			Issuer:    "https://auth.example.com",
+			Audience:  jwt.ClaimStrings{"api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b.Run("Prebuilt", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := s.validateWith(ctx, s.parser, token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("PerCall", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := s.validateWith(ctx, s.newParser(), token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
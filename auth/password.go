@@ -0,0 +1,123 @@
+package auth
+
+import "context"
+
+// ChangePassword (Смена пароля)
+// Проверяет старый пароль, хэширует новый (если он соответствует политике)
+// и сохраняет его в Storage.
+// При WithRevokeRefreshOnPasswordChange отзывает все refresh-токены пользователя.
+// При WithPasswordHistory недавние пароли отклоняются (ErrPasswordReused),
+// при WithBreachChecker - пароли из утечек (ErrPasswordBreached).
+// Отмена ctx прерывает операцию до каждого шага bcrypt.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) error {
+	if err := s.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !s.verifyPassword(user.GetPasswordHash(), oldPassword) {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.checkPasswordHistory(ctx, user, newPassword); err != nil {
+		return err
+	}
+	// После проверки старого пароля: без него внешний сервис не вызывается
+	if err := s.checkBreached(ctx, newPassword); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	hash, err := s.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return err
+	}
+	if err := s.recordPasswordHistory(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if s.revokeRefreshOnPasswordChange && s.refresh != nil {
+		if err := s.refresh.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	s.audit(ctx, AuditPasswordChange, userID, userID, nil)
+	return nil
+}
+
+// rehashIfNeeded перехэширует пароль текущим алгоритмом, если сохраненный
+// хэш создан другим алгоритмом или слабее (WithRehashOnLogin).
+// Ошибки не прерывают вход: хэш будет обновлен при следующем входе.
+func (s *AuthService) rehashIfNeeded(ctx context.Context, user UserIn, password string) {
+	if !s.rehashOnLogin {
+		return
+	}
+
+	hash := user.GetPasswordHash()
+	// Хэш WithVerifyHasher уже заменен в checkPassword
+	if !s.isKnownHash(hash) || s.isMigratedHash(hash) || !s.passwordHasher().NeedsRehash(hash) {
+		return
+	}
+
+	s.upgradePasswordHash(ctx, user, password)
+}
+
+// LegacyHashVerifier проверяет пароль по хэшу устаревшего формата
+// (например, соленый SHA-256 из старой системы). ok - пароль верен,
+// needsUpgrade - хэш нужно заменить на текущий алгоритм.
+type LegacyHashVerifier func(password, storedHash string) (ok bool, needsUpgrade bool)
+
+// checkPassword сравнивает пароль с хэшем пользователя. Хэш неизвестного
+// формата проверяется через LegacyHashVerifier и после успешной проверки
+// заменяется хэшем текущего алгоритма.
+func (s *AuthService) checkPassword(ctx context.Context, user UserIn, password string) bool {
+	hash := user.GetPasswordHash()
+	if !s.isKnownHash(hash) && s.legacyVerifier != nil {
+		ok, needsUpgrade := s.legacyVerifier(password, hash)
+		if ok && needsUpgrade {
+			s.upgradePasswordHash(ctx, user, password)
+		}
+		return ok
+	}
+
+	ok, stale := s.matchPassword(password, hash)
+	if ok && stale {
+		s.upgradePasswordHash(ctx, user, password)
+	}
+	return ok
+}
+
+// verifyPassword сравнивает пароль с хэшем без миграции хэша.
+func (s *AuthService) verifyPassword(hash, password string) bool {
+	if !s.isKnownHash(hash) && s.legacyVerifier != nil {
+		ok, _ := s.legacyVerifier(password, hash)
+		return ok
+	}
+	ok, _ := s.matchPassword(password, hash)
+	return ok
+}
+
+// upgradePasswordHash сохраняет пароль, захэшированный текущим алгоритмом.
+// Ошибки не прерывают вход: хэш будет обновлен при следующем входе.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, user UserIn, password string) {
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		return
+	}
+	_ = s.storage.UpdatePasswordHash(ctx, user.GetID(), hash)
+}
@@ -0,0 +1,47 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"go_auth_pkg/auth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRehashOnLoginUpgradesCost(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		svc, storage, userID := newTestService(t, auth.WithBcryptCost(bcrypt.MinCost+2), auth.WithRehashOnLogin(enabled))
+		ctx := context.Background()
+		old, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.UpdatePasswordHash(ctx, userID, string(old)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+
+		user, err := storage.GetUserByID(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cost, err := bcrypt.Cost([]byte(user.GetPasswordHash()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := bcrypt.MinCost
+		if enabled {
+			want = bcrypt.MinCost + 2
+		}
+		if cost != want {
+			t.Fatalf("WithRehashOnLogin(%v): стоимость хэша после входа = %d, ожидалась %d", enabled, cost, want)
+		}
+		if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+			t.Fatalf("вход после перехэширования: %v", err)
+		}
+	}
+}
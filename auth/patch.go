@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// privilegeClaims - поля прав: PatchClaims меняет их только
+// с WithPatchablePrivileges.
+var privilegeClaims = map[string]bool{"roles": true, "scope": true}
+
+// PatchClaims (Изменение полей токена)
+// Проверяет действующий access-токен, применяет к его claims изменения
+// updates и подписывает новый токен с прежними exp, iat, jti и сессией.
+// Подходит, когда данные пользователя изменились посреди сессии
+// (например, тариф) и повторный вход нежелателен.
+//
+// Только для доверенного серверного кода: значения не проверяются,
+// передавать в updates данные клиента нельзя.
+//
+// Поля верхнего уровня попадают в JWTClaims.Extra (nil удаляет поле).
+// Стандартные и служебные поля (exp, iss, sub, user_id, sid, email и т.д.)
+// изменить нельзя - ErrProtectedClaim; поля прав "roles" и "scope" -
+// только если разрешены через WithPatchablePrivileges.
+// Старый токен продолжает действовать до своего exp.
+func (s *AuthService) PatchClaims(oldToken string, updates map[string]interface{}) (string, error) {
+	return s.PatchClaimsContext(context.Background(), oldToken, updates)
+}
+
+// PatchClaimsContext - то же, что PatchClaims, с контекстом.
+func (s *AuthService) PatchClaimsContext(ctx context.Context, oldToken string, updates map[string]interface{}) (string, error) {
+	claims, err := s.ParseAndValidateTokenContext(ctx, oldToken)
+	if err != nil {
+		return "", err
+	}
+	patched := *claims
+	if s.subjectMode == SubjectOnly {
+		patched.UserID = 0 // при проверке восстановлен из sub
+	}
+	if err := s.applyClaimPatch(&patched, updates); err != nil {
+		return "", err
+	}
+
+	return s.issueEncoded(ctx, &patched)
+}
+
+// applyClaimPatch применяет updates к claims. Extra копируется, чтобы
+// не менять claims исходного токена.
+func (s *AuthService) applyClaimPatch(claims *JWTClaims, updates map[string]interface{}) error {
+	extra := make(map[string]interface{}, len(claims.Extra)+len(updates))
+	for k, v := range claims.Extra {
+		extra[k] = v
+	}
+
+	for name, value := range updates {
+		switch {
+		case privilegeClaims[name]:
+			if !s.patchablePrivileges[name] {
+				return fmt.Errorf("%w: %q (см. WithPatchablePrivileges)", ErrProtectedClaim, name)
+			}
+			if err := patchPrivilege(claims, name, value); err != nil {
+				return err
+			}
+		case reservedClaims[name]:
+			return fmt.Errorf("%w: %q", ErrProtectedClaim, name)
+		case value == nil:
+			delete(extra, name)
+		default:
+			extra[name] = value
+		}
+	}
+
+	claims.Extra = nil
+	if len(extra) > 0 {
+		claims.Extra = extra
+	}
+	return nil
+}
+
+// patchPrivilege записывает новое значение roles или scope. Значение
+// принимается в любом виде, который разобрался бы из payload токена.
+func patchPrivilege(claims *JWTClaims, name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("некорректное значение поля %q: %w", name, err)
+	}
+
+	switch name {
+	case "roles":
+		var roles []string
+		if err := json.Unmarshal(data, &roles); err != nil {
+			return fmt.Errorf("некорректное значение поля %q: %w", name, err)
+		}
+		claims.Roles = roles
+	case "scope":
+		var scopes Scopes
+		if err := json.Unmarshal(data, &scopes); err != nil {
+			return fmt.Errorf("некорректное значение поля %q: %w", name, err)
+		}
+		claims.Scopes = scopes
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PeekHeader (Заголовок JWT без проверки)
+// Декодирует заголовок токена (kid, alg, tid и т.д.) без проверки подписи -
+// например, чтобы шлюз выбрал ключ или арендатора до проверки.
+// Значения НЕ ДОВЕРЕННЫЕ: их может подставить кто угодно, поэтому решения
+// о доступе по ним принимать нельзя - только маршрутизация перед
+// ParseAndValidateToken. Токены больше WithMaxTokenBytes и некорректные
+// токены дают ErrTokenInvalid.
+func (s *AuthService) PeekHeader(tokenString string) (map[string]interface{}, error) {
+	return s.peekSegment(tokenString, 0)
+}
+
+// PeekClaims (Claims JWT без проверки)
+// Декодирует payload токена (iss, sub, tid и т.д.) без проверки подписи
+// и сроков. Как и у PeekHeader, значения НЕ ДОВЕРЕННЫЕ и годятся только
+// для маршрутизации: авторизация - только по claims из ParseAndValidateToken.
+func (s *AuthService) PeekClaims(tokenString string) (map[string]interface{}, error) {
+	return s.peekSegment(tokenString, 1)
+}
+
+// peekSegment декодирует часть index (0 - заголовок, 1 - payload) JWT.
+func (s *AuthService) peekSegment(tokenString string, index int) (map[string]interface{}, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTokenSize(tokenString); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: %w: токен должен состоять из трех частей", ErrTokenInvalid, jwt.ErrTokenMalformed)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[index], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: часть токена не base64url", ErrTokenInvalid, jwt.ErrTokenMalformed)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil || values == nil {
+		return nil, fmt.Errorf("%w: %w: часть токена не JSON-объект", ErrTokenInvalid, jwt.ErrTokenMalformed)
+	}
+	return values, nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// pepperPassword смешивает пароль с перцем: base64(HMAC-SHA256(pepper, password)).
+// Результат - 44 байта, поэтому ограничение bcrypt в 72 байта не действует.
+func pepperPassword(password string, pepper []byte) string {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// pepperedInput - то, что получит хэшер: пароль с текущим перцем (WithPepper).
+func (s *AuthService) pepperedInput(password string) string {
+	if s.pepper == nil {
+		return password
+	}
+	return pepperPassword(password, s.pepper)
+}
+
+// matchesPreviousPepper проверяет пароль по хэшу, созданному с прежним
+// перцем (или без перца, см. WithPreviousPepper).
+func (s *AuthService) matchesPreviousPepper(password, hash string) bool {
+	if !s.hasPreviousPepper {
+		return false
+	}
+	input := password
+	if s.previousPepper != nil {
+		input = pepperPassword(password, s.previousPepper)
+	}
+	return s.compareHashInput(input, hash) == nil
+}
+
+// matchPassword сравнивает пароль с хэшем с учетом перца. stale - хэш
+// создан с прежним перцем или алгоритмом WithVerifyHasher и его нужно
+// заменить.
+func (s *AuthService) matchPassword(password, hash string) (ok, stale bool) {
+	if s.compareHash(password, hash) == nil {
+		return true, s.isMigratedHash(hash)
+	}
+	if s.matchesPreviousPepper(password, hash) {
+		return true, true
+	}
+	return false, false
+}
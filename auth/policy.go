@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy - требования к паролю при Register и ChangePassword.
+// Нулевые поля означают отсутствие соответствующего требования.
+type PasswordPolicy struct {
+	MinLength     int // в символах
+	MaxLength     int // в символах; ограничивает работу bcrypt (защита от DoS)
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy - политика, если WithPasswordPolicy не задана.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8}
+
+// Validate проверяет пароль и возвращает ErrWeakPassword
+// со списком всех нарушенных правил.
+func (p PasswordPolicy) Validate(password string) error {
+	var failed []string
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		failed = append(failed, fmt.Sprintf("не короче %d символов", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		failed = append(failed, fmt.Sprintf("не длиннее %d символов", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		failed = append(failed, "заглавная буква")
+	}
+	if p.RequireLower && !lower {
+		failed = append(failed, "строчная буква")
+	}
+	if p.RequireDigit && !digit {
+		failed = append(failed, "цифра")
+	}
+	if p.RequireSymbol && !symbol {
+		failed = append(failed, "спецсимвол")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrWeakPassword, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// ValidatePassword проверяет пароль по политике сервиса. При bcrypt без
+// перца пароль длиннее 72 байт дает ErrPasswordTooLong (см. WithLongPasswords).
+func (s *AuthService) ValidatePassword(password string) error {
+	s.mu.RLock()
+	policy := s.passwordPolicy
+	s.mu.RUnlock()
+
+	if err := policy.Validate(password); err != nil {
+		return err
+	}
+	// С перцем хэшер получает HMAC фиксированной длины
+	if len(password) > bcryptMaxPassword && s.pepper == nil {
+		if h, ok := s.passwordHasher().(BcryptHasher); ok && !h.PrehashLong {
+			return fmt.Errorf("%w: bcrypt учитывает не больше %d байт", ErrPasswordTooLong, bcryptMaxPassword)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// purposeClaim - имя поля, отличающего служебные токены от access-токенов.
+const purposeClaim = "purpose"
+
+// Назначения служебных токенов.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposePasswordReset = "password_reset"
+	PurposeDeviceTrust   = "device_trust"
+	PurposeMagicLink     = "magic_link"
+)
+
+// purposeClaims - payload служебного (одноразового) токена.
+type purposeClaims struct {
+	Purpose string `json:"purpose"`
+	// PasswordFingerprint привязывает токен к текущему хэшу пароля:
+	// после смены пароля токен сброса перестает действовать.
+	PasswordFingerprint string `json:"pwh,omitempty"`
+	// TokenVersion - версия токенов пользователя (WithTokenVersioning).
+	TokenVersion int64 `json:"tver,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// issuePurposeToken подписывает служебный токен для subject.
+func (s *AuthService) issuePurposeToken(ctx context.Context, purpose, subject string, ttl time.Duration) (string, error) {
+	return s.signPurposeToken(ctx, purposeClaims{
+		Purpose:          purpose,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+	}, ttl)
+}
+
+// signPurposeToken дополняет claims (jti, iat, exp) и подписывает токен.
+func (s *AuthService) signPurposeToken(ctx context.Context, claims purposeClaims, ttl time.Duration) (string, error) {
+	jti, err := s.newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+	claims.ID = jti
+	claims.Issuer = s.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	return s.sign(ctx, claims)
+}
+
+// consumePurposeToken проверяет служебный токен и его назначение.
+// Если подключен черный список, токен одноразовый: jti заносится
+// в черный список, и повторное предъявление дает ErrTokenAlreadyUsed.
+func (s *AuthService) consumePurposeToken(ctx context.Context, tokenString, purpose string) (*purposeClaims, error) {
+	claims, err := s.parsePurposeToken(ctx, tokenString, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.blacklist != nil && claims.ID != "" {
+		used, err := s.blacklist.IsBlacklisted(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка проверки черного списка: %w", err)
+		}
+		if used {
+			return nil, ErrTokenAlreadyUsed
+		}
+		var exp time.Time
+		if claims.ExpiresAt != nil {
+			exp = claims.ExpiresAt.Time
+		}
+		if err := s.blacklist.Add(ctx, claims.ID, exp); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// parsePurposeToken проверяет подпись, сроки и назначение служебного токена.
+func (s *AuthService) parsePurposeToken(ctx context.Context, tokenString, purpose string) (*purposeClaims, error) {
+	if err := s.checkTokenSize(tokenString); err != nil {
+		return nil, err
+	}
+	claims := &purposeClaims{}
+	// Без проверки aud: служебные токены не привязаны к аудитории
+	opts := []jwt.ParserOption{jwt.WithTimeFunc(s.clock.Now), jwt.WithLeeway(s.leeway)}
+	token, err := s.parseToken(ctx, jwt.NewParser(opts...), jwt.NewValidator(opts...), tokenString, claims)
+	if err != nil {
+		return nil, mapParseError(err)
+	}
+	if err := s.checkTokenType(token, purposeTokenType(purpose)); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
+
+// GenerateVerificationToken (Подтверждение email)
+// Выпускает короткоживущий токен подтверждения email для пользователя
+// (обычно отправляется ссылкой в письме). Срок жизни - WithVerificationTTL.
+func (s *AuthService) GenerateVerificationToken(ctx context.Context, userID int64) (string, error) {
+	if _, err := s.storage.GetUserByID(ctx, userID); err != nil {
+		return "", err
+	}
+	return s.issuePurposeToken(ctx, PurposeVerifyEmail, strconv.FormatInt(userID, 10), s.verificationTTL)
+}
+
+// ConsumeVerificationToken проверяет токен подтверждения email и возвращает
+// ID пользователя, которого вызывающий помечает подтвержденным.
+// Токен другого назначения (в т.ч. access-токен) отклоняется с
+// ErrWrongTokenPurpose. Повторное использование отклоняется, если
+// подключен черный список (WithTokenBlacklist).
+func (s *AuthService) ConsumeVerificationToken(ctx context.Context, tokenString string) (int64, error) {
+	claims, err := s.consumePurposeToken(ctx, tokenString, PurposeVerifyEmail)
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.userID()
+}
+
+// userID разбирает ID пользователя из sub.
+func (c *purposeClaims) userID() (int64, error) {
+	userID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil {
+		return 0, ErrTokenInvalid
+	}
+	return userID, nil
+}
+
+// checkVerified отклоняет вход неподтвержденных пользователей,
+// если включен WithRequireVerifiedEmail.
+func (s *AuthService) checkVerified(user UserIn) error {
+	if !s.requireVerified {
+		return nil
+	}
+	if vp, ok := user.(VerificationProvider); ok && vp.IsVerified() {
+		return nil
+	}
+	return ErrEmailNotVerified
+}
+
+// isPurposeToken сообщает, что payload принадлежит служебному токену.
+func isPurposeToken(claims *JWTClaims) bool {
+	_, ok := claims.Extra[purposeClaim]
+	return ok
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// PasswordAgeProvider - необязательный интерфейс пользователя со временем
+// последней смены пароля (WithPasswordMaxAge). Нулевое время - неизвестно,
+// такой пароль не считается устаревшим.
+type PasswordAgeProvider interface {
+	GetPasswordChangedAt() time.Time
+}
+
+// PasswordExpiryMode - что делать при входе с устаревшим паролем.
+type PasswordExpiryMode int
+
+const (
+	// PasswordExpiryFlag - вход успешен, в токене pwd_expired: true
+	// (JWTClaims.PasswordExpired); приложение само отправляет на смену пароля.
+	PasswordExpiryFlag PasswordExpiryMode = iota
+	// PasswordExpiryBlock - вход отклоняется с *PasswordExpiredError,
+	// содержащим токен для ResetPassword.
+	PasswordExpiryBlock
+)
+
+// PasswordExpiredError - вход отклонен из-за устаревшего пароля
+// (PasswordExpiryBlock). errors.Is(err, ErrPasswordExpired) == true.
+// ResetToken - токен сброса пароля (как у GeneratePasswordResetToken)
+// для ResetPassword: пароль и второй фактор уже проверены.
+// Handlers.Login отдает только ошибку 403 без ResetToken.
+type PasswordExpiredError struct {
+	ResetToken string
+}
+
+func (e *PasswordExpiredError) Error() string { return ErrPasswordExpired.Error() }
+
+func (e *PasswordExpiredError) Unwrap() error { return ErrPasswordExpired }
+
+// passwordExpired сообщает, что пароль пользователя старше WithPasswordMaxAge.
+func (s *AuthService) passwordExpired(user UserIn) bool {
+	if s.passwordMaxAge <= 0 {
+		return false
+	}
+	ap, ok := user.(PasswordAgeProvider)
+	if !ok {
+		return false
+	}
+	changedAt := ap.GetPasswordChangedAt()
+	return !changedAt.IsZero() && s.clock.Now().Sub(changedAt) > s.passwordMaxAge
+}
+
+// checkPasswordExpiry отклоняет вход с устаревшим паролем
+// при PasswordExpiryBlock.
+func (s *AuthService) checkPasswordExpiry(ctx context.Context, user UserIn) error {
+	if s.passwordExpiryMode != PasswordExpiryBlock || !s.passwordExpired(user) {
+		return nil
+	}
+	resetToken, err := s.passwordResetToken(ctx, user)
+	if err != nil {
+		return err
+	}
+	return &PasswordExpiredError{ResetToken: resetToken}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// randomBytes читает n байт из источника случайности сервиса (WithRandReader).
+// Короткое чтение считается ошибкой.
+func (s *AuthService) randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(s.randReader, b); err != nil {
+		return nil, fmt.Errorf("ошибка источника случайности: %w", err)
+	}
+	return b, nil
+}
+
+// randomHex возвращает n случайных байт в hex-кодировке.
+func (s *AuthService) randomHex(n int) (string, error) {
+	b, err := s.randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newTokenID генерирует jti (по умолчанию 16 случайных байт в hex);
+// пустой идентификатор тоже считается ошибкой, т.к. без него не
+// работают черный список и Logout.
+func (s *AuthService) newTokenID() (string, error) {
+	generate := s.idGenerator
+	if generate == nil {
+		generate = func() (string, error) { return s.randomHex(16) }
+	}
+	id, err := generate()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrIDGeneration, err)
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w: пустой идентификатор", ErrIDGeneration)
+	}
+	return id, nil
+}
+
+// SecureCompare сравнивает строки за время, не зависящее от их содержимого
+// (crypto/subtle) - для токенов, кодов и отпечатков, сверяемых на сервере.
+// Длина строк при этом не скрывается: если она секретна, сравнивайте
+// хэши фиксированной длины (например, SHA-256) вместо исходных значений.
+func SecureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue - значение, которым Redacted заменяет скрытые claims.
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactedClaims - claims с персональными данными, скрываемые всегда.
+var defaultRedactedClaims = []string{"email", "act.email"}
+
+// Redacted (Claims для журнала)
+// Возвращает payload токена как map (Extra - на верхнем уровне, как
+// в MarshalJSON, числа - json.Number), где email пользователя
+// и администратора (act.email) заменены на RedactedValue.
+// Отсутствующие поля не добавляются.
+// Сервис журналирует claims только в таком виде, дополнительно скрывая
+// ключи из WithRedactedClaims.
+func (c *JWTClaims) Redacted() map[string]interface{} {
+	return c.redacted(nil)
+}
+
+// redacted - Redacted с дополнительными ключами (путь через точку, как
+// у Get). Если claims не сериализуются, возвращается пустая map:
+// журнал не должен получить их в открытом виде.
+func (c *JWTClaims) redacted(extra []string) map[string]interface{} {
+	out := map[string]interface{}{}
+	if c == nil {
+		return out
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return out
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return map[string]interface{}{}
+	}
+	for _, path := range defaultRedactedClaims {
+		redactPath(out, path)
+	}
+	for _, path := range extra {
+		redactPath(out, path)
+	}
+	return out
+}
+
+// redactPath заменяет значение по пути через точку на RedactedValue.
+func redactPath(m map[string]interface{}, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+	last := keys[len(keys)-1]
+	if _, ok := m[last]; ok {
+		m[last] = RedactedValue
+	}
+}
@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// LoginWithRefresh (Логин с refresh-токеном)
+// Как Login, но дополнительно выдает долгоживущий непрозрачный refresh-токен.
+func (s *AuthService) LoginWithRefresh(ctx context.Context, email, password string) (string, string, error) {
+	if s.refresh == nil {
+		return "", "", ErrRefreshUnsupported
+	}
+
+	user, err := s.authenticate(ctx, email, password, secondFactor{})
+	if err != nil {
+		return "", "", err
+	}
+
+	pair, err := s.issueTokenPair(ctx, user, "", TokenOptions{authTime: s.clock.Now()})
+	if err != nil {
+		return "", "", err
+	}
+	return pair.access, pair.refresh, nil
+}
+
+// Refresh (Обновление токенов)
+// Обменивает refresh-токен на новую пару access/refresh.
+// Старый refresh-токен отзывается (ротация), новый наследует его семью.
+// Повторное предъявление уже использованного токена считается кражей:
+// вся семья (и ее сессия) отзывается, возвращается ErrRefreshReused,
+// и пользователю нужно войти заново.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	pair, err := s.rotateRefresh(ctx, refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	return pair.access, pair.refresh, nil
+}
+
+// rotateRefresh выполняет Refresh и возвращает пару вместе со сроком access-токена.
+func (s *AuthService) rotateRefresh(ctx context.Context, refreshToken string) (tokenPair, error) {
+	if s.refresh == nil {
+		return tokenPair{}, ErrRefreshUnsupported
+	}
+
+	record, err := s.lookupRefresh(ctx, refreshToken)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	// Чужой клиент не гасит токен: владелец сможет им воспользоваться
+	if err := s.checkRefreshBinding(ctx, record); err != nil {
+		return tokenPair{}, err
+	}
+
+	if err := s.refresh.Revoke(ctx, record.Token); err != nil {
+		return tokenPair{}, err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, record.UserID)
+	if err != nil {
+		return tokenPair{}, ErrRefreshInvalid
+	}
+
+	if err := s.checkRefreshSession(ctx, record); err != nil {
+		return tokenPair{}, err
+	}
+
+	pair, err := s.issueTokenPair(ctx, user, record.FamilyID, record.tokenOptions())
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	s.emit(ctx, Event{Type: EventRefresh, UserID: user.GetID(), Email: user.GetEmail()})
+	return pair, nil
+}
+
+// lookupRefresh находит действующий refresh-токен. Предъявление уже
+// использованного токена отзывает его семью (ErrRefreshReused).
+func (s *AuthService) lookupRefresh(ctx context.Context, refreshToken string) (RefreshToken, error) {
+	record, err := s.refresh.Lookup(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrRefreshNotFound) {
+			return RefreshToken{}, ErrRefreshInvalid
+		}
+		return RefreshToken{}, err
+	}
+	if record.Revoked {
+		if err := s.revokeRefreshFamily(ctx, record); err != nil {
+			return RefreshToken{}, err
+		}
+		s.emit(ctx, Event{Type: EventRefreshReused, UserID: record.UserID})
+		return RefreshToken{}, ErrRefreshReused
+	}
+	if s.clock.Now().After(record.ExpiresAt) {
+		return RefreshToken{}, ErrRefreshInvalid
+	}
+	return record, nil
+}
+
+// checkRefreshSession не дает refresh-токену продлить завершенную сессию.
+func (s *AuthService) checkRefreshSession(ctx context.Context, record RefreshToken) error {
+	if s.sessions == nil || record.SessionID == "" {
+		return nil
+	}
+	if _, err := s.sessions.Get(ctx, record.SessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	return nil
+}
+
+// tokenPair - выданные access- и refresh-токены.
+type tokenPair struct {
+	access    string
+	refresh   string
+	expiresAt time.Time // срок действия access-токена
+}
+
+// issueTokenPair выдает access-токен и сохраняет новый refresh-токен.
+// familyID и opts.sessionID - текущие семья и сессия; пустые означают новый
+// вход. opts.authTime и opts.DPoPThumbprint сохраняются в refresh-токене
+// и переходят к токенам после ротации.
+func (s *AuthService) issueTokenPair(ctx context.Context, user UserIn, familyID string, opts TokenOptions) (tokenPair, error) {
+	if familyID == "" {
+		id, err := s.randomHex(16)
+		if err != nil {
+			return tokenPair{}, errors.New("ошибка генерации семьи refresh-токенов")
+		}
+		familyID = id
+	}
+
+	if opts.sessionID == "" {
+		sid, err := s.startSession(ctx, user.GetID())
+		if err != nil {
+			return tokenPair{}, err
+		}
+		opts.sessionID = sid
+	}
+
+	accessToken, expiresAt, err := s.mintAccessToken(ctx, user, opts)
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	refreshToken, err := s.randomHex(32)
+	if err != nil {
+		return tokenPair{}, errors.New("ошибка генерации refresh-токена")
+	}
+
+	record := RefreshToken{
+		Token:          hashRefreshToken(refreshToken),
+		UserID:         user.GetID(),
+		ExpiresAt:      s.clock.Now().Add(s.refreshTTL),
+		SessionID:      opts.sessionID,
+		FamilyID:       familyID,
+		AuthTime:       opts.authTime,
+		DPoPThumbprint: opts.DPoPThumbprint,
+	}
+	s.bindRefresh(ctx, &record)
+	if err := s.refresh.Save(ctx, record); err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{access: accessToken, refresh: refreshToken, expiresAt: expiresAt}, nil
+}
+
+// tokenOptions - параметры access-токенов, выдаваемых по refresh-токену:
+// та же сессия, время входа и привязка к ключу DPoP.
+func (record RefreshToken) tokenOptions() TokenOptions {
+	return TokenOptions{
+		sessionID:      record.SessionID,
+		authTime:       record.AuthTime,
+		DPoPThumbprint: record.DPoPThumbprint,
+	}
+}
+
+// revokeRefreshFamily отзывает семью повторно предъявленного refresh-токена
+// и завершает ее сессию, чтобы выданные access-токены тоже перестали действовать.
+func (s *AuthService) revokeRefreshFamily(ctx context.Context, record RefreshToken) error {
+	if record.FamilyID != "" {
+		if err := s.refresh.RevokeFamily(ctx, record.FamilyID); err != nil {
+			return err
+		}
+	}
+	if s.sessions != nil && record.SessionID != "" {
+		if err := s.sessions.Delete(ctx, record.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateAndRefreshIfNeeded (Прозрачное продление)
+// Проверяет access-токен и, если до его истечения осталось меньше порога
+// (WithRefreshThreshold) или он уже истек, выпускает новый access-токен
+// по действующему refresh-токену. Refresh-токен при этом не ротируется
+// и остается у клиента. rotated сообщает, что выпущен новый токен;
+// claims относятся к возвращаемому токену. С WithTokenEncoder accessToken
+// принимается в представлении encoder, и в нем же возвращается токен:
+// без продления - переданный, после продления - новый.
+func (s *AuthService) ValidateAndRefreshIfNeeded(ctx context.Context, accessToken, refreshToken string) (string, bool, *JWTClaims, error) {
+	compact, err := s.DecodeToken(accessToken)
+	if err != nil {
+		return "", false, nil, err
+	}
+	claims, err := s.validate(ctx, compact)
+	switch {
+	case err == nil:
+		if claims.ExpiresAt == nil || claims.ExpiresAt.Sub(s.clock.Now()) > s.refreshThreshold {
+			return accessToken, false, claims, nil
+		}
+	case errors.Is(err, ErrTokenExpired) && claims != nil:
+		// Подпись верна, срок истек - продлеваем по refresh-токену
+		if isPurposeToken(claims) {
+			return "", false, nil, ErrWrongTokenPurpose
+		}
+	default:
+		return "", false, nil, err
+	}
+
+	if s.refresh == nil {
+		if err == nil {
+			return accessToken, false, claims, nil
+		}
+		return "", false, nil, err
+	}
+
+	record, rerr := s.lookupRefresh(ctx, refreshToken)
+	if rerr != nil {
+		return "", false, nil, rerr
+	}
+	if record.UserID != claims.UserID {
+		return "", false, nil, ErrRefreshInvalid
+	}
+	if err := s.checkRefreshBinding(ctx, record); err != nil {
+		return "", false, nil, err
+	}
+	if err := s.checkRefreshSession(ctx, record); err != nil {
+		return "", false, nil, err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, record.UserID)
+	if err != nil {
+		return "", false, nil, ErrRefreshInvalid
+	}
+	newAccess, err := s.issueAccessToken(ctx, user, record.tokenOptions())
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	// newAccess - в представлении WithTokenEncoder
+	if compact, err = s.DecodeToken(newAccess); err != nil {
+		return "", false, nil, err
+	}
+	newClaims, err := s.validate(ctx, compact)
+	if err != nil {
+		return "", false, nil, err
+	}
+	s.emit(ctx, Event{Type: EventRefresh, UserID: user.GetID(), Email: user.GetEmail()})
+	return newAccess, true, newClaims, nil
+}
+
+// hashRefreshToken - отпечаток refresh-токена для RefreshStore.
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
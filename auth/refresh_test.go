@@ -0,0 +1,89 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"go_auth_pkg/auth"
+)
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	store := newMemRefreshStore()
+	svc, _, _ := newTestService(t, auth.WithRefreshStore(store))
+	ctx := context.Background()
+
+	_, stolen, err := svc.LoginWithRefresh(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("LoginWithRefresh: %v", err)
+	}
+	// Законный клиент ротирует токен дважды
+	_, second, err := svc.Refresh(ctx, stolen)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	_, current, err := svc.Refresh(ctx, second)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Злоумышленник предъявляет украденный, уже использованный токен
+	if _, _, err := svc.Refresh(ctx, stolen); !errors.Is(err, auth.ErrRefreshReused) {
+		t.Fatalf("повторный Refresh: err = %v, ожидался ErrRefreshReused", err)
+	}
+
+	// Отозвана вся семья: действующий токен клиента тоже не работает
+	if _, _, err := svc.Refresh(ctx, current); err == nil {
+		t.Fatal("Refresh последним токеном семьи прошел после обнаружения кражи")
+	}
+	for _, rt := range store.tokens {
+		if !rt.Revoked {
+			t.Fatalf("запись семьи %s не отозвана", rt.FamilyID)
+		}
+	}
+
+	// Новый вход начинает новую семью
+	_, fresh, err := svc.LoginWithRefresh(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("LoginWithRefresh: %v", err)
+	}
+	if _, _, err := svc.Refresh(ctx, fresh); err != nil {
+		t.Fatalf("Refresh после повторного входа: %v", err)
+	}
+}
+
+func TestRefreshStoreKeepsOnlyHash(t *testing.T) {
+	store := newMemRefreshStore()
+	svc, _, _ := newTestService(t, auth.WithRefreshStore(store))
+	ctx := context.Background()
+
+	_, refresh, err := svc.LoginWithRefresh(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("LoginWithRefresh: %v", err)
+	}
+	if len(store.tokens) != 1 {
+		t.Fatalf("записей в хранилище: %d, ожидалась 1", len(store.tokens))
+	}
+
+	sum := sha256.Sum256([]byte(refresh))
+	want := hex.EncodeToString(sum[:])
+	for key, rt := range store.tokens {
+		if key != want || rt.Token != want {
+			t.Fatalf("сохранено %q, ожидался SHA-256 отпечаток %q", rt.Token, want)
+		}
+		if strings.Contains(rt.Token, refresh) || strings.Contains(rt.FamilyID, refresh) || strings.Contains(rt.SessionID, refresh) {
+			t.Fatal("запись хранилища содержит сам refresh-токен")
+		}
+	}
+
+	// Отпечаток из утекшей БД не работает как токен
+	if _, _, err := svc.Refresh(ctx, want); !errors.Is(err, auth.ErrRefreshInvalid) {
+		t.Fatalf("Refresh отпечатком: err = %v, ожидался ErrRefreshInvalid", err)
+	}
+	if _, _, err := svc.Refresh(ctx, refresh); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"net"
+)
+
+// RefreshBindingMode - строгость привязки refresh-токена к клиенту,
+// которому он выдан (WithRefreshBinding). Клиент описывается RequestMeta.
+type RefreshBindingMode int
+
+const (
+	// RefreshBindingOff - без привязки (по умолчанию).
+	RefreshBindingOff RefreshBindingMode = iota
+	// RefreshBindingDevice - совпадает User-Agent; IP может меняться
+	// (мобильные сети, переход между Wi-Fi и LTE).
+	RefreshBindingDevice
+	// RefreshBindingSubnet - совпадают User-Agent и подсеть IP
+	// (/24 для IPv4, /64 для IPv6): переживает смену адреса у провайдера.
+	RefreshBindingSubnet
+	// RefreshBindingStrict - совпадают User-Agent и IP.
+	RefreshBindingStrict
+)
+
+// bindRefresh записывает в refresh-токен клиента запроса (WithRefreshBinding).
+// Без RequestMeta токен выдается непривязанным.
+func (s *AuthService) bindRefresh(ctx context.Context, record *RefreshToken) {
+	if s.refreshBinding == RefreshBindingOff {
+		return
+	}
+	if meta, ok := RequestMetaFromContext(ctx); ok {
+		record.IP, record.UserAgent = meta.IP, meta.UserAgent
+	}
+}
+
+// checkRefreshBinding сверяет клиента запроса с клиентом, которому выдан
+// refresh-токен. Токены без сведений о клиенте (выданные до включения
+// привязки или без RequestMeta) не проверяются.
+func (s *AuthService) checkRefreshBinding(ctx context.Context, record RefreshToken) error {
+	if s.refreshBinding == RefreshBindingOff || (record.IP == "" && record.UserAgent == "") {
+		return nil
+	}
+	meta, ok := RequestMetaFromContext(ctx)
+	if !ok || meta.UserAgent != record.UserAgent {
+		return ErrRefreshBindingMismatch
+	}
+
+	switch s.refreshBinding {
+	case RefreshBindingSubnet:
+		if !sameSubnet(meta.IP, record.IP) {
+			return ErrRefreshBindingMismatch
+		}
+	case RefreshBindingStrict:
+		if meta.IP != record.IP {
+			return ErrRefreshBindingMismatch
+		}
+	}
+	return nil
+}
+
+// sameSubnet сообщает, что адреса в одной подсети /24 (IPv4) или /64 (IPv6).
+// Неразбираемые адреса сравниваются как строки.
+func sameSubnet(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a == b
+	}
+	if v4A, v4B := ipA.To4(), ipB.To4(); v4A != nil || v4B != nil {
+		if v4A == nil || v4B == nil {
+			return false
+		}
+		mask := net.CIDRMask(24, 32)
+		return v4A.Mask(mask).Equal(v4B.Mask(mask))
+	}
+	mask := net.CIDRMask(64, 128)
+	return ipA.Mask(mask).Equal(ipB.Mask(mask))
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// maxRefreshCacheEntries - предел записей локального кэша refresh-токенов.
+const maxRefreshCacheEntries = 100_000
+
+// WarmRefreshCache (Прогрев кэша refresh-токенов)
+// Загружает записи с отпечатками tokens (RefreshToken.Token) в локальный
+// кэш WithRefreshCache, например при старте узла или по списку активных
+// семей из центрального хранилища. Если RefreshStore реализует
+// RefreshBatchLookup, записи читаются одним запросом, иначе по одной.
+// Ненайденные отпечатки пропускаются. Без WithRefreshCache возвращает
+// ErrRefreshUnsupported.
+func (s *AuthService) WarmRefreshCache(ctx context.Context, tokens []string) error {
+	cache, ok := s.refresh.(*cachedRefreshStore)
+	if !ok {
+		return ErrRefreshUnsupported
+	}
+	return cache.warm(ctx, tokens)
+}
+
+// cachedRefreshStore - RefreshStore с локальным кэшем поиска
+// (WithRefreshCache). Запись всегда идет в inner; кэшируются только
+// найденные записи, промахи не кэшируются: токен мог быть выдан другим узлом.
+type cachedRefreshStore struct {
+	inner RefreshStore
+	ttl   time.Duration
+	clock Clock
+
+	mu      sync.Mutex
+	entries map[string]refreshCacheEntry // отпечаток -> запись
+}
+
+// refreshCacheEntry - закэшированная запись и время ее получения.
+type refreshCacheEntry struct {
+	record   RefreshToken
+	cachedAt time.Time
+}
+
+func newCachedRefreshStore(inner RefreshStore, ttl time.Duration, clock Clock) *cachedRefreshStore {
+	return &cachedRefreshStore{
+		inner:   inner,
+		ttl:     ttl,
+		clock:   clock,
+		entries: make(map[string]refreshCacheEntry),
+	}
+}
+
+func (c *cachedRefreshStore) Save(ctx context.Context, token RefreshToken) error {
+	if err := c.inner.Save(ctx, token); err != nil {
+		return err
+	}
+	c.put(token)
+	return nil
+}
+
+func (c *cachedRefreshStore) Lookup(ctx context.Context, token string) (RefreshToken, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[token]
+	if ok && c.clock.Now().Sub(entry.cachedAt) >= c.ttl {
+		delete(c.entries, token)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return entry.record, nil
+	}
+
+	record, err := c.inner.Lookup(ctx, token)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	c.put(record)
+	return record, nil
+}
+
+func (c *cachedRefreshStore) Revoke(ctx context.Context, token string) error {
+	if err := c.inner.Revoke(ctx, token); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if entry, ok := c.entries[token]; ok {
+		entry.record.Revoked = true
+		c.entries[token] = entry
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *cachedRefreshStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	if err := c.inner.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	c.revokeWhere(func(record RefreshToken) bool { return record.UserID == userID })
+	return nil
+}
+
+func (c *cachedRefreshStore) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := c.inner.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	c.revokeWhere(func(record RefreshToken) bool { return record.FamilyID == familyID })
+	return nil
+}
+
+// warm загружает записи tokens в кэш (см. WarmRefreshCache).
+func (c *cachedRefreshStore) warm(ctx context.Context, tokens []string) error {
+	if batch, ok := c.inner.(RefreshBatchLookup); ok {
+		records, err := batch.LookupBatch(ctx, tokens)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			c.put(record)
+		}
+		return nil
+	}
+
+	for _, token := range tokens {
+		record, err := c.inner.Lookup(ctx, token)
+		if errors.Is(err, ErrRefreshNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.put(record)
+	}
+	return nil
+}
+
+// put кэширует запись. При переполнении сначала удаляются устаревшие
+// записи; если места все равно нет, запись не кэшируется.
+func (c *cachedRefreshStore) put(record RefreshToken) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[record.Token]; !ok && len(c.entries) >= maxRefreshCacheEntries {
+		for token, entry := range c.entries {
+			if now.Sub(entry.cachedAt) >= c.ttl || now.After(entry.record.ExpiresAt) {
+				delete(c.entries, token)
+			}
+		}
+		if len(c.entries) >= maxRefreshCacheEntries {
+			return
+		}
+	}
+	c.entries[record.Token] = refreshCacheEntry{record: record, cachedAt: now}
+}
+
+// revokeWhere помечает отозванными закэшированные записи, подходящие под match.
+func (c *cachedRefreshStore) revokeWhere(match func(RefreshToken) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for token, entry := range c.entries {
+		if match(entry.record) {
+			entry.record.Revoked = true
+			c.entries[token] = entry
+		}
+	}
+}
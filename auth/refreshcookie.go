@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultRefreshCookieName - имя cookie refresh-токена по умолчанию.
+const defaultRefreshCookieName = "refresh_token"
+
+// RefreshCookie - параметры cookie, в которой Handlers передают
+// refresh-токен (WithRefreshCookie). Cookie всегда HttpOnly: скрипты
+// страницы токен не видят.
+type RefreshCookie struct {
+	Name   string // пусто - "refresh_token"
+	Path   string // пусто - "/"; лучше сузить до пути Handlers.Refresh
+	Domain string
+	// SameSite - режим SameSite; 0 - http.SameSiteStrictMode.
+	SameSite http.SameSite
+	// MaxAge - срок жизни cookie; 0 - срок refresh-токена (WithRefreshTTL).
+	MaxAge time.Duration
+	// Insecure снимает флаг Secure (только для разработки по http://).
+	Insecure bool
+}
+
+// withDefaults заполняет незаданные поля.
+func (c RefreshCookie) withDefaults(refreshTTL time.Duration) RefreshCookie {
+	if c.Name == "" {
+		c.Name = defaultRefreshCookieName
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteStrictMode
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = refreshTTL
+	}
+	return c
+}
+
+// cookie собирает cookie со значением value; maxAge < 0 удаляет ее.
+func (c RefreshCookie) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   !c.Insecure,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+}
+
+// setRefreshCookie кладет refresh-токен в cookie.
+func (s *AuthService) setRefreshCookie(w http.ResponseWriter, refreshToken string) {
+	c := s.refreshCookie
+	http.SetCookie(w, c.cookie(refreshToken, int(c.MaxAge/time.Second)))
+}
+
+// clearRefreshCookie удаляет cookie refresh-токена у клиента.
+func (s *AuthService) clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, s.refreshCookie.cookie("", -1))
+}
+
+// refreshFromCookie возвращает refresh-токен из cookie запроса.
+func (s *AuthService) refreshFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(s.refreshCookie.Name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(cookie.Value)
+}
+
+// revokeCookieRefresh отзывает refresh-токен из cookie при выходе.
+// Ошибки не мешают выходу: cookie все равно удаляется.
+func (s *AuthService) revokeCookieRefresh(r *http.Request) {
+	refreshToken := s.refreshFromCookie(r)
+	if s.refresh == nil || refreshToken == "" {
+		return
+	}
+	_ = s.refresh.Revoke(requestContext(r), hashRefreshToken(refreshToken))
+}
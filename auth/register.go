@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Register (Регистрация)
+// Создает нового пользователя: проверяет пароль по политике (и по утечкам,
+// см. WithBreachChecker) и что email свободен, хэширует пароль текущим
+// алгоритмом (по умолчанию bcrypt, см. WithPasswordHasher) и сохраняет
+// пользователя в Storage.
+// Возвращает ID из Storage.CreateUser как int64, а не строкой: это тот же
+// тип, что у UserIn.GetID и JWTClaims.UserID, и его можно сразу передавать
+// в ChangePassword, RevokeAllTokens и т.д.
+// В Storage передается нормализованный email (см. WithEmailNormalizer).
+// Отмена ctx прерывает регистрацию до хэширования.
+func (s *AuthService) Register(ctx context.Context, email, password string) (int64, error) {
+	email = s.normalizeEmail(email)
+	if strings.TrimSpace(email) == "" {
+		return 0, ErrEmptyEmail
+	}
+
+	if err := s.ValidatePassword(password); err != nil {
+		return 0, err
+	}
+	if err := s.checkBreached(ctx, password); err != nil {
+		return 0, err
+	}
+
+	exists, err := s.UserExists(ctx, email)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, ErrUserAlreadyExists
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.storage.CreateUser(ctx, email, hash)
+}
+
+// UserExists проверяет, занят ли email (например, для формы регистрации).
+// Отсутствие пользователя (ErrUserNotFound) - это (false, nil);
+// любая другая ошибка Storage возвращается обернутой, а не маскируется
+// под "не найден".
+func (s *AuthService) UserExists(ctx context.Context, email string) (bool, error) {
+	_, err := s.storage.GetUserByEmail(ctx, s.normalizeEmail(email))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrUserNotFound):
+		return false, nil
+	default:
+		return false, fmt.Errorf("ошибка хранилища: %w", err)
+	}
+}
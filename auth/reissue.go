@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ReissueToken (Перевыпуск токена)
+// Проверяет действующий access-токен и выпускает новый с теми же jti, iat
+// и сессией, но с новым сроком действия и claims, собранными заново по
+// текущим данным пользователя (роли, scope, WithClaimsEnricher и т.д.).
+// Позволяет клиентам получить поля, добавленные в схему токена, без
+// повторного входа. Старый токен продолжает действовать до своего exp;
+// отзыв по jti (Logout) действует на оба. Истекший или недействительный
+// токен отклоняется ошибкой проверки, токен имперсонации - ErrForbidden.
+func (s *AuthService) ReissueToken(oldToken string) (string, error) {
+	return s.ReissueTokenContext(context.Background(), oldToken)
+}
+
+// ReissueTokenContext - то же, что ReissueToken, с контекстом.
+func (s *AuthService) ReissueTokenContext(ctx context.Context, oldToken string) (string, error) {
+	claims, err := s.ParseAndValidateTokenContext(ctx, oldToken)
+	if err != nil {
+		return "", err
+	}
+	// Перевыпуск продлил бы короткий срок имперсонации
+	if claims.IsImpersonated() {
+		return "", ErrForbidden
+	}
+
+	user, err := s.storage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", ErrTokenRevoked
+		}
+		return "", err
+	}
+
+	opts := TokenOptions{sessionID: claims.SessionID, tokenID: claims.ID}
+	if claims.IssuedAt != nil {
+		opts.issuedAt = claims.IssuedAt.Time
+	}
+	if claims.AuthTime != nil {
+		opts.authTime = claims.AuthTime.Time
+	}
+	if claims.Confirmation != nil {
+		opts.DPoPThumbprint = claims.Confirmation.JKT
+	}
+	return s.issueAccessToken(ctx, user, opts)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GeneratePasswordResetToken (Забыли пароль)
+// Выпускает короткоживущий токен сброса пароля (срок - WithPasswordResetTTL).
+// Для неизвестного email возвращает пустую строку без ошибки, чтобы по
+// ответу нельзя было определить наличие аккаунта: вызывающий в этом
+// случае просто не отправляет письмо, а клиенту отвечает как обычно.
+// Прочие ошибки хранилища (например, ErrStorageTimeout) возвращаются.
+func (s *AuthService) GeneratePasswordResetToken(ctx context.Context, email string) (string, error) {
+	user, err := s.storage.GetUserByEmail(ctx, s.normalizeEmail(email))
+	if errors.Is(err, ErrUserNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.passwordResetToken(ctx, user)
+}
+
+// passwordResetToken выпускает токен сброса пароля пользователя.
+func (s *AuthService) passwordResetToken(ctx context.Context, user UserIn) (string, error) {
+	return s.signPurposeToken(ctx, purposeClaims{
+		Purpose:             PurposePasswordReset,
+		PasswordFingerprint: passwordFingerprint(user.GetPasswordHash()),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: strconv.FormatInt(user.GetID(), 10),
+		},
+	}, s.passwordResetTTL)
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса.
+// Токен расходуется только после всех проверок нового пароля (политика,
+// WithBreachChecker, WithPasswordHistory): отклоненный пароль можно
+// сменить и повторить сброс по той же ссылке.
+// Токен привязан к прежнему хэшу пароля, поэтому после успешного сброса
+// он перестает действовать даже без черного списка.
+func (s *AuthService) ResetPassword(ctx context.Context, tokenString, newPassword string) error {
+	if err := s.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	// Токен только проверяется: он расходуется после всех проверок нового
+	// пароля, чтобы отклоненный пароль не сжигал ссылку сброса
+	claims, err := s.parsePurposeToken(ctx, tokenString, PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	userID, err := claims.userID()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return ErrTokenInvalid
+	}
+	fp := passwordFingerprint(user.GetPasswordHash())
+	if !SecureCompare(fp, claims.PasswordFingerprint) {
+		return ErrTokenAlreadyUsed
+	}
+
+	if err := s.checkPasswordHistory(ctx, user, newPassword); err != nil {
+		return err
+	}
+	// После проверки токена: без него внешний сервис не вызывается
+	if err := s.checkBreached(ctx, newPassword); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	hash, err := s.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if _, err := s.consumePurposeToken(ctx, tokenString, PurposePasswordReset); err != nil {
+		return err
+	}
+	if err := s.storage.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return err
+	}
+	if err := s.recordPasswordHistory(ctx, userID, hash); err != nil {
+		return err
+	}
+	s.audit(ctx, AuditPasswordReset, userID, userID, nil)
+	return nil
+}
+
+// passwordFingerprint - короткий отпечаток хэша пароля для токена сброса.
+func passwordFingerprint(passwordHash string) string {
+	sum := sha256.Sum256([]byte(passwordHash))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+func TestGeneratePasswordResetTokenStorageErrors(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	token, err := svc.GeneratePasswordResetToken(ctx, "nobody@example.com")
+	if err != nil || token != "" {
+		t.Fatalf("неизвестный email: token = %q, err = %v, ожидались пустая строка и nil", token, err)
+	}
+	if token, err := svc.GeneratePasswordResetToken(ctx, testEmail); err != nil || token == "" {
+		t.Fatalf("известный email: token = %q, err = %v", token, err)
+	}
+
+	// Сбой хранилища не должен выглядеть как неизвестный email
+	errDown := errors.New("хранилище недоступно")
+	storage := &failingStorage{InMemoryStorage: memory.NewInMemoryStorage(), err: errDown}
+	broken, err := auth.NewAuthService(storage, testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broken.GeneratePasswordResetToken(ctx, testEmail); !errors.Is(err, errDown) {
+		t.Fatalf("сбой хранилища: err = %v, ожидалась ошибка хранилища", err)
+	}
+}
+
+func TestResetPasswordRejectionKeepsToken(t *testing.T) {
+	backends := []struct {
+		name string
+		opts []auth.Option
+	}{
+		// Без черного списка повтор отклоняет отпечаток прежнего хэша
+		{"Fingerprint", nil},
+		{"Blacklist", []auth.Option{auth.WithTokenBlacklist(newMemBlacklist())}},
+	}
+	for _, bk := range backends {
+		t.Run(bk.name, func(t *testing.T) {
+			checker := newFakeBreachChecker(breachedPassword)
+			opts := append([]auth.Option{
+				auth.WithPasswordHistory(newMemPasswordHistory(), 5),
+				auth.WithBreachChecker(checker.Check, auth.BreachCheckFailClosed),
+			}, bk.opts...)
+			svc, _, _ := newTestService(t, opts...)
+			ctx := context.Background()
+
+			token, err := svc.GeneratePasswordResetToken(ctx, testEmail)
+			if err != nil || token == "" {
+				t.Fatalf("GeneratePasswordResetToken: token = %q, err = %v", token, err)
+			}
+
+			// Отклоненный пароль не должен сжигать ссылку сброса
+			if err := svc.ResetPassword(ctx, token, testPassword); !errors.Is(err, auth.ErrPasswordReused) {
+				t.Fatalf("текущий пароль: err = %v, ожидался ErrPasswordReused", err)
+			}
+			if err := svc.ResetPassword(ctx, token, breachedPassword); !errors.Is(err, auth.ErrPasswordBreached) {
+				t.Fatalf("пароль из утечки: err = %v, ожидался ErrPasswordBreached", err)
+			}
+
+			if err := svc.ResetPassword(ctx, token, freshPassword); err != nil {
+				t.Fatalf("ResetPassword после отказов: %v", err)
+			}
+			if _, err := svc.Login(ctx, testEmail, freshPassword); err != nil {
+				t.Fatalf("Login с новым паролем: %v", err)
+			}
+
+			// Успешный сброс расходует токен
+			if err := svc.ResetPassword(ctx, token, "Another#Passw0rd3"); !errors.Is(err, auth.ErrTokenAlreadyUsed) {
+				t.Fatalf("повторный сброс: err = %v, ожидался ErrTokenAlreadyUsed", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import "context"
+
+// RiskEvaluator решает, нужен ли второй фактор для этого входа
+// (WithRiskEvaluator): например, для администраторов или нового устройства.
+// Вызывается после проверки пароля; meta - сведения из WithRequestMeta.
+type RiskEvaluator func(ctx context.Context, user UserIn, meta RequestMeta) (requireMFA bool, err error)
+
+// MFARequiredError сообщает, что для входа нужен второй фактор.
+// errors.Is(err, ErrMFARequired) == true.
+type MFARequiredError struct {
+	// Enrolled - у пользователя подключен TOTP: клиенту нужно запросить код
+	// и повторить вход через LoginWith2FA. Иначе 2FA нужно сначала подключить.
+	Enrolled bool
+}
+
+func (e *MFARequiredError) Error() string {
+	if e.Enrolled {
+		return ErrMFARequired.Error() + ": введите код подтверждения"
+	}
+	return ErrMFARequired.Error() + ": подключите двухфакторную аутентификацию"
+}
+
+func (e *MFARequiredError) Unwrap() error { return ErrMFARequired }
+
+// secondFactor - предъявленный при входе второй фактор.
+type secondFactor struct {
+	totpCode    string
+	deviceToken string // токен доверенного устройства (IssueDeviceToken)
+}
+
+// checkMFA проверяет второй фактор. Без RiskEvaluator код обязателен для
+// всех пользователей с TOTP; с ним - только когда оценка риска этого требует.
+// Действующий токен доверенного устройства заменяет код. email - ключ
+// счетчика неудач (см. checkTOTP).
+func (s *AuthService) checkMFA(ctx context.Context, user UserIn, email string, factor secondFactor) error {
+	if factor.deviceToken != "" && s.trustedDevice(ctx, user, factor.deviceToken) {
+		return nil
+	}
+
+	code := factor.totpCode
+	if s.riskEvaluator == nil {
+		return s.checkTOTP(ctx, user, email, code)
+	}
+
+	meta, _ := RequestMetaFromContext(ctx)
+	require, err := s.riskEvaluator(ctx, user, meta)
+	if err != nil {
+		return err
+	}
+	if !require {
+		return nil
+	}
+
+	tp, enrolled := user.(TOTPProvider)
+	enrolled = enrolled && tp.GetTOTPSecret() != ""
+	if !enrolled || code == "" {
+		return &MFARequiredError{Enrolled: enrolled}
+	}
+	return s.checkTOTP(ctx, user, email, code)
+}
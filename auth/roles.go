@@ -0,0 +1,37 @@
+package auth
+
+import "net/http"
+
+// HasRole проверяет, есть ли у владельца токена указанная роль.
+func (s *AuthService) HasRole(claims *JWTClaims, role string) bool {
+	if claims == nil {
+		return false
+	}
+	for _, r := range claims.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole (Проверка роли)
+// HTTP-обертка, пропускающая запрос только при наличии роли.
+// Ставится после Middleware: без claims в контексте отвечает 401,
+// без нужной роли - 403.
+func (s *AuthService) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, s.ErrorMessage(ErrMissingToken))
+				return
+			}
+			if !s.HasRole(claims, role) {
+				writeJSONError(w, http.StatusForbidden, s.ErrorMessage(ErrForbidden))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
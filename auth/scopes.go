@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Scopes - набор OAuth-scope токена. По RFC 6749 (п. 3.3) сериализуется
+// в claim "scope" одной строкой через пробел, а не JSON-массивом.
+type Scopes []string
+
+// MarshalJSON записывает scope строкой через пробел.
+func (sc Scopes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(sc, " "))
+}
+
+// UnmarshalJSON читает scope из строки через пробел. Для совместимости
+// принимается и JSON-массив строк.
+func (sc *Scopes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var list []string
+		if errList := json.Unmarshal(data, &list); errList != nil {
+			return err
+		}
+		*sc = list
+		return nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		*sc = nil
+		return nil
+	}
+	*sc = fields
+	return nil
+}
+
+// HasScope проверяет, есть ли у владельца токена указанный scope.
+func (s *AuthService) HasScope(claims *JWTClaims, scope string) bool {
+	if claims == nil {
+		return false
+	}
+	for _, sc := range claims.Scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope (Проверка scope)
+// HTTP-обертка, пропускающая запрос только при наличии всех scope.
+// Ставится после Middleware: без claims в контексте отвечает 401,
+// без нужного scope - 403.
+func (s *AuthService) RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, s.ErrorMessage(ErrMissingToken))
+				return
+			}
+			for _, scope := range scopes {
+				if !s.HasScope(claims, scope) {
+					writeJSONError(w, http.StatusForbidden, s.ErrorMessage(ErrForbidden))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// SessionInfo - сессия пользователя для страницы "Активные устройства".
+type SessionInfo struct {
+	ID        string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	IP        string
+	UserAgent string
+	// Current отмечает сессию, которой принадлежит предъявленный токен.
+	Current bool
+}
+
+// ListSessions (Активные сессии)
+// Возвращает активные сессии пользователя, последние использованные первыми.
+// currentSessionID - sid из claims текущего запроса (JWTClaims.SessionID):
+// соответствующая сессия помечается Current. Пустой currentSessionID
+// ничего не помечает. Сессии только читаются, LastSeen не обновляется.
+func (s *AuthService) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]SessionInfo, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionsUnsupported
+	}
+
+	list, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SessionInfo, 0, len(list))
+	for _, session := range list {
+		infos = append(infos, SessionInfo{
+			ID:        session.ID,
+			CreatedAt: session.CreatedAt,
+			LastSeen:  session.LastSeen,
+			IP:        session.IP,
+			UserAgent: session.UserAgent,
+			Current:   currentSessionID != "" && session.ID == currentSessionID,
+		})
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].LastSeen.After(infos[j].LastSeen) })
+	return infos, nil
+}
+
+// RevokeSession завершает сессию: все ее токены перестают проходить проверку.
+func (s *AuthService) RevokeSession(ctx context.Context, sessionID string) error {
+	if s.sessions == nil {
+		return ErrSessionsUnsupported
+	}
+	return s.sessions.Delete(ctx, sessionID)
+}
+
+// LogoutAll (Выход на всех устройствах)
+// Делает недействительными все токены пользователя: при WithTokenVersioning
+// увеличивает версию токенов (RevokeAllTokens), при WithSessionStore удаляет
+// все его сессии. Refresh-токены пользователя тоже отзываются.
+// Повторный вызов и вызов без активных сессий возвращают nil.
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	if err := s.logoutAll(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(ctx, AuditLogoutAll, 0, userID, nil)
+	return nil
+}
+
+// logoutAll выполняет LogoutAll без записи аудита: при входе
+// с WithSingleSession прежние сессии завершаются в рамках самого входа.
+func (s *AuthService) logoutAll(ctx context.Context, userID int64) error {
+	if !s.tokenVersioning && s.sessions == nil {
+		return ErrLogoutAllUnsupported
+	}
+
+	if s.tokenVersioning {
+		if err := s.revokeAllTokens(ctx, userID); err != nil {
+			return err
+		}
+	} else if s.refresh != nil {
+		if err := s.refresh.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if s.sessions != nil {
+		if err := s.deleteUserSessions(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if err := s.untrackUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	s.emit(ctx, Event{Type: EventLogout, UserID: userID})
+	return nil
+}
+
+// endPreviousSessions завершает прежние сессии пользователя при входе
+// (WithSingleSession). Пользователь перечитывается после увеличения версии
+// токенов, чтобы новый токен получил уже актуальную версию.
+func (s *AuthService) endPreviousSessions(ctx context.Context, user UserIn) (UserIn, error) {
+	if !s.singleSession {
+		return user, nil
+	}
+	if err := s.logoutAll(ctx, user.GetID()); err != nil {
+		return nil, err
+	}
+	if !s.tokenVersioning {
+		return user, nil
+	}
+	return s.storage.GetUserByID(ctx, user.GetID())
+}
+
+// deleteUserSessions удаляет все сессии пользователя.
+func (s *AuthService) deleteUserSessions(ctx context.Context, userID int64) error {
+	list, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range list {
+		if err := s.sessions.Delete(ctx, session.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSession создает сессию при входе и соблюдает лимит maxSessions.
+// Без SessionStore возвращает пустой ID.
+func (s *AuthService) startSession(ctx context.Context, userID int64) (string, error) {
+	if s.sessions == nil {
+		return "", nil
+	}
+
+	sid, err := s.randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+	session := Session{ID: sid, UserID: userID, CreatedAt: now, LastSeen: now}
+	if meta, ok := RequestMetaFromContext(ctx); ok {
+		session.IP, session.UserAgent = meta.IP, meta.UserAgent
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return "", err
+	}
+
+	if s.maxSessions > 0 {
+		if err := s.evictSessions(ctx, userID); err != nil {
+			return "", err
+		}
+	}
+	return sid, nil
+}
+
+// evictSessions завершает самые старые сессии сверх лимита maxSessions.
+func (s *AuthService) evictSessions(ctx context.Context, userID int64) error {
+	list, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(list) <= s.maxSessions {
+		return nil
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	for _, old := range list[:len(list)-s.maxSessions] {
+		if err := s.sessions.Delete(ctx, old.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSession отклоняет токен завершенной сессии и обновляет LastSeen.
+// Токены без sid (выпущенные до подключения SessionStore) пропускаются.
+func (s *AuthService) checkSession(ctx context.Context, claims *JWTClaims) error {
+	if s.sessions == nil || claims.SessionID == "" {
+		return nil
+	}
+
+	if _, err := s.sessions.Get(ctx, claims.SessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	return s.sessions.Touch(ctx, claims.SessionID, s.clock.Now())
+}
@@ -0,0 +1,46 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+)
+
+func TestSingleSessionInvalidatesPreviousLogin(t *testing.T) {
+	backends := []struct {
+		name    string
+		opt     auth.Option
+		wantErr error
+	}{
+		{"SessionStore", auth.WithSessionStore(newMemSessions(time.Hour)), auth.ErrSessionRevoked},
+		{"TokenVersioning", auth.WithTokenVersioning(true), auth.ErrTokenRevoked},
+	}
+	for _, bk := range backends {
+		t.Run(bk.name, func(t *testing.T) {
+			svc, _, _ := newTestService(t, bk.opt, auth.WithSingleSession(true))
+			ctx := context.Background()
+
+			first, err := svc.Login(ctx, testEmail, testPassword)
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			if _, err := svc.ParseAndValidateToken(first); err != nil {
+				t.Fatalf("первый токен до второго входа: %v", err)
+			}
+
+			second, err := svc.Login(ctx, testEmail, testPassword)
+			if err != nil {
+				t.Fatalf("второй Login: %v", err)
+			}
+			if _, err := svc.ParseAndValidateToken(first); !errors.Is(err, bk.wantErr) {
+				t.Fatalf("первый токен после входа на другом устройстве: err = %v, ожидался %v", err, bk.wantErr)
+			}
+			if _, err := svc.ParseAndValidateToken(second); err != nil {
+				t.Fatalf("второй токен: %v", err)
+			}
+		})
+	}
+}
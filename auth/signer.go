@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer подписывает токены вне процесса (AWS KMS, Cloud KMS, HSM), так что
+// закрытый ключ не попадает в память сервиса (WithSigner). signingInput -
+// "base64url(заголовок).base64url(payload)"; подпись возвращается
+// в формате JWS для Alg (для ES256 - r||s, а не DER).
+type Signer interface {
+	Alg() string
+	Sign(signingInput []byte) (signature []byte, err error)
+}
+
+// SignatureVerifier - пара к Signer для проверки подписи вне сервиса
+// (WithSignatureVerifier). Verify возвращает ошибку для неверной подписи.
+type SignatureVerifier interface {
+	Alg() string
+	Verify(signingInput, signature []byte) error
+}
+
+// LocalSigner - Signer и SignatureVerifier поверх алгоритмов jwt
+// (HMAC, RSA, ECDSA, Ed25519) с ключами в памяти процесса: для тестов
+// и для единообразия с внешним Signer.
+type LocalSigner struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+var (
+	_ Signer            = (*LocalSigner)(nil)
+	_ SignatureVerifier = (*LocalSigner)(nil)
+)
+
+// NewLocalSigner создает LocalSigner. Для HMAC оба ключа - один []byte,
+// для асимметричных алгоритмов signKey - закрытый ключ (nil - только
+// проверка), verifyKey - открытый.
+//
+//	signer, _ := auth.NewLocalSigner(jwt.SigningMethodRS256, priv, &priv.PublicKey)
+func NewLocalSigner(method jwt.SigningMethod, signKey, verifyKey interface{}) (*LocalSigner, error) {
+	if method == nil || method.Alg() == jwt.SigningMethodNone.Alg() {
+		return nil, errors.New("недопустимый алгоритм подписи: none")
+	}
+	if verifyKey == nil {
+		return nil, errors.New("не задан ключ проверки подписи")
+	}
+	return &LocalSigner{method: method, signKey: signKey, verifyKey: verifyKey}, nil
+}
+
+// Alg реализует Signer и SignatureVerifier
+func (l *LocalSigner) Alg() string { return l.method.Alg() }
+
+// Sign реализует Signer
+func (l *LocalSigner) Sign(signingInput []byte) ([]byte, error) {
+	if l.signKey == nil {
+		return nil, errors.New("ключ подписи не задан")
+	}
+	return l.method.Sign(string(signingInput), l.signKey)
+}
+
+// Verify реализует SignatureVerifier
+func (l *LocalSigner) Verify(signingInput, signature []byte) error {
+	return l.method.Verify(string(signingInput), signature, l.verifyKey)
+}
+
+// checkExternalSigning проверяет, что алгоритмы WithSigner
+// и WithSignatureVerifier совпадают с алгоритмом сервиса.
+func (s *AuthService) checkExternalSigning() error {
+	if s.signer != nil && s.signer.Alg() != s.signingMethod.Alg() {
+		return fmt.Errorf("алгоритм Signer %s не совпадает с алгоритмом сервиса %s",
+			s.signer.Alg(), s.signingMethod.Alg())
+	}
+	if s.sigVerifier != nil && s.sigVerifier.Alg() != s.signingMethod.Alg() {
+		return fmt.Errorf("алгоритм SignatureVerifier %s не совпадает с алгоритмом сервиса %s",
+			s.sigVerifier.Alg(), s.signingMethod.Alg())
+	}
+	return nil
+}
+
+// signWithSigner подписывает подготовленный токен через Signer
+// (WithSigner) с kid сервиса.
+func (s *AuthService) signWithSigner(token *jwt.Token) (string, error) {
+	if s.keyID != "" {
+		token.Header["kid"] = s.keyID
+	}
+	signingInput, err := token.SigningString()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+	signature, err := s.signer.Sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+	return s.checkTokenBudget(signingInput + "." + token.EncodeSegment(signature))
+}
+
+// parseToken разбирает токен в claims и проверяет подпись: через
+// SignatureVerifier, если он задан, иначе ключом keyFuncFor. Стандартные
+// поля проверяет parser, а при SignatureVerifier - validator
+// (nil - без проверки полей).
+func (s *AuthService) parseToken(ctx context.Context, parser *jwt.Parser, validator *jwt.Validator, tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	if s.sigVerifier == nil {
+		return parser.ParseWithClaims(tokenString, claims, s.keyFuncFor(ctx))
+	}
+
+	token, parts, err := parser.ParseUnverified(tokenString, claims)
+	if err != nil {
+		return token, err
+	}
+	if token.Method.Alg() != s.sigVerifier.Alg() {
+		return token, ErrUnexpectedSigningMethod
+	}
+	if token.Signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return token, fmt.Errorf("%w: подпись не base64url", jwt.ErrTokenMalformed)
+	}
+	if err := s.sigVerifier.Verify([]byte(strings.Join(parts[:2], ".")), token.Signature); err != nil {
+		return token, fmt.Errorf("%w: %w", jwt.ErrTokenSignatureInvalid, err)
+	}
+	if validator != nil {
+		if err := validator.Validate(claims); err != nil {
+			return token, fmt.Errorf("%w: %w", jwt.ErrTokenInvalidClaims, err)
+		}
+	}
+	token.Valid = true
+	return token, nil
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SecretProvider возвращает текущий HMAC-секрет (например, из Vault).
+// Вызывается на каждую операцию подписи и проверки.
+type SecretProvider func(ctx context.Context) ([]byte, error)
+
+// sign подписывает claims алгоритмом и ключом сервиса
+// (или арендатора из контекста, см. WithTenantKeys).
+func (s *AuthService) sign(ctx context.Context, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signingMethod, claims)
+	for name, value := range s.tokenHeaders {
+		token.Header[name] = value
+	}
+	token.Header["typ"] = s.tokenTypeFor(claims)
+
+	if s.signer != nil {
+		return s.signWithSigner(token)
+	}
+
+	var key interface{}
+	var err error
+	if tenantID, ok := TenantFromContext(ctx); ok && s.tenantResolver != nil {
+		key, _, err = s.tenantKeys(ctx, tenantID)
+		if err == nil && key == nil {
+			err = fmt.Errorf("%w: у арендатора %q нет ключа подписи", ErrSigningFailed, tenantID)
+		}
+		token.Header[tenantHeader] = tenantID
+	} else {
+		key, err = s.currentSignKey(ctx)
+		if s.keyID != "" {
+			token.Header["kid"] = s.keyID
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+	return s.checkTokenBudget(tokenString)
+}
+
+// checkTokenBudget отклоняет токен больше WithTokenSizeBudget.
+func (s *AuthService) checkTokenBudget(tokenString string) (string, error) {
+	if s.tokenSizeBudget > 0 && len(tokenString) > s.tokenSizeBudget {
+		return "", fmt.Errorf("%w: размер %d байт превышает %d",
+			ErrTokenOverBudget, len(tokenString), s.tokenSizeBudget)
+	}
+	return tokenString, nil
+}
+
+// currentSignKey возвращает ключ подписи: из SecretProvider или статический.
+func (s *AuthService) currentSignKey(ctx context.Context) (interface{}, error) {
+	if s.secretProvider != nil {
+		return s.providedSecret(ctx)
+	}
+	if s.signKey == nil {
+		return nil, fmt.Errorf("%w: ключ подписи не задан, сервис работает только на проверку", ErrSigningFailed)
+	}
+	return s.signKey, nil
+}
+
+// providedSecret запрашивает секрет у SecretProvider.
+func (s *AuthService) providedSecret(ctx context.Context) (interface{}, error) {
+	key, err := s.secretProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSigningKeyUnavailable, err)
+	}
+	if len(key) == 0 {
+		return nil, ErrSigningKeyUnavailable
+	}
+	return key, nil
+}
+
+// keyFuncFor возвращает jwt.Keyfunc, выбирающий ключ проверки подписи.
+//
+// По умолчанию принимается только алгоритм сервиса. Для миграции
+// (например, с HS256 на RS256) дополнительные алгоритмы разрешаются
+// через WithAllowedMethods, а их ключи задаются через WithMethodKey:
+//
+//	auth.NewAuthServiceRS256(storage, priv, nil, ttl,
+//		auth.WithAllowedMethods("HS256"),
+//		auth.WithMethodKey("HS256", oldSecret),
+//	)
+//
+// Новые токены подписываются RS256, старые HS256-токены проверяются
+// старым секретом, пока не истекут. Каждый алгоритм имеет свой ключ,
+// поэтому HS256-токен, подписанный публичным RSA-ключом, не пройдет.
+// Алгоритм "none" не принимается никогда.
+func (s *AuthService) keyFuncFor(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		alg := token.Method.Alg()
+		// "none" - классическая уязвимость JWT: токен без подписи
+		// отклоняется до выбора ключа, независимо от настроек
+		if alg == jwt.SigningMethodNone.Alg() {
+			return nil, ErrUnexpectedSigningMethod
+		}
+		// Access-токен стороннего издателя - ключом из WithTrustedIssuers;
+		// служебные токены подписывает только сам сервис
+		if _, access := token.Claims.(*JWTClaims); access && s.trustedIssuers != nil {
+			if key, handled, err := s.trustedIssuerKey(token); handled {
+				return key, err
+			}
+		}
+		// Verifier из NewVerifierFromJWKS: ключ и алгоритм определяет JWKS
+		if s.jwks != nil {
+			return s.jwks.keyFor(ctx, token)
+		}
+		if alg != s.signingMethod.Alg() {
+			key, ok := s.methodKeys[alg]
+			if !ok {
+				return nil, ErrUnexpectedSigningMethod
+			}
+			return key, nil
+		}
+
+		// Токен арендатора проверяется только ключом этого арендатора
+		if s.tenantResolver != nil {
+			if tenantID := tokenTenant(token); tenantID != "" {
+				_, key, err := s.tenantKeys(ctx, tenantID)
+				if err != nil {
+					return nil, err
+				}
+				if key == nil {
+					return nil, fmt.Errorf("%w %q", ErrUnknownTenant, tenantID)
+				}
+				return key, nil
+			}
+		}
+
+		// При ротации ключей ключ выбирается по kid из заголовка.
+		// Токены без kid (выпущенные до ротации) проверяются активным ключом.
+		if raw, ok := token.Header["kid"]; ok && len(s.keys) > 0 {
+			kid, _ := raw.(string)
+			key, ok := s.keys[kid]
+			if !ok {
+				return nil, ErrUnknownKeyID
+			}
+			return key, nil
+		}
+
+		key := s.verifyKey
+		if s.secretProvider != nil {
+			provided, err := s.providedSecret(ctx)
+			if err != nil {
+				return nil, err
+			}
+			key = provided
+		}
+
+		// Окно ротации: до previousUntil подходит и прежний секрет
+		if s.previousSecret != nil && s.clock.Now().Before(s.previousUntil) {
+			return jwt.VerificationKeySet{Keys: []jwt.VerificationKey{key, s.previousSecret}}, nil
+		}
+		return key, nil
+	}
+}
@@ -0,0 +1,175 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/authtest"
+	"go_auth_pkg/auth/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestEd25519SigningMethod(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := memory.NewInMemoryStorage()
+	if _, err := storage.SeedUser(testEmail, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := auth.NewAuthService(storage, nil, time.Hour,
+		auth.WithSigningMethod(jwt.SigningMethodEdDSA, priv, pub))
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+
+	token, err := svc.Login(context.Background(), testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	header, err := svc.PeekHeader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header["alg"] != "EdDSA" {
+		t.Fatalf("alg = %v, ожидался EdDSA", header["alg"])
+	}
+	claims, err := svc.ParseAndValidateToken(token)
+	if err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+	if claims.Email != testEmail {
+		t.Fatalf("Email = %q", claims.Email)
+	}
+
+	// Токен другого алгоритма отклоняется, даже если ключ подошел бы
+	hs := authtest.NewToken(auth.JWTClaims{UserID: claims.UserID, Email: testEmail}, testSecret)
+	if _, err := svc.ParseAndValidateToken(hs); !errors.Is(err, auth.ErrUnexpectedSigningMethod) {
+		t.Fatalf("HS256-токен: err = %v, ожидался ErrUnexpectedSigningMethod", err)
+	}
+
+	// Подпись другим ключом Ed25519 не проходит
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessClaims(claims.UserID)).SignedString(otherPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ParseAndValidateToken(forged); !errors.Is(err, auth.ErrTokenInvalid) {
+		t.Fatalf("чужой ключ: err = %v, ожидался ErrTokenInvalid", err)
+	}
+}
+
+// accessClaims - действующие claims access-токена для ручной подписи.
+func accessClaims(userID int64) *auth.JWTClaims {
+	now := time.Now()
+	return &auth.JWTClaims{
+		UserID: userID,
+		Email:  testEmail,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func TestNoneAlgorithmRejected(t *testing.T) {
+	svc, _, userID := newTestService(t)
+
+	token := authtest.NoneAlgToken(auth.JWTClaims{UserID: userID, Email: testEmail})
+	if _, err := svc.ParseAndValidateToken(token); !errors.Is(err, auth.ErrUnexpectedSigningMethod) {
+		t.Fatalf("alg none: err = %v, ожидался ErrUnexpectedSigningMethod", err)
+	}
+
+	if _, err := auth.NewAuthService(memory.NewInMemoryStorage(), testSecret, time.Hour,
+		auth.WithSigningMethod(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.UnsafeAllowNoneSignatureType)); err == nil {
+		t.Fatal("сервис с алгоритмом none создан")
+	}
+}
+
+// Атака подмены алгоритма: сервис ждет RS256, а злоумышленник подписывает
+// токен HS256, используя открытый ключ сервиса как HMAC-секрет.
+func TestHS256WithPublicKeyRejected(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := memory.NewInMemoryStorage()
+	userID, err := storage.SeedUser(testEmail, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := auth.NewAuthServiceRS256(storage, priv, &priv.PublicKey, time.Hour)
+	if err != nil {
+		t.Fatalf("NewAuthServiceRS256: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, secret := range map[string][]byte{
+		"PEM": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		"DER": der,
+	} {
+		forged := authtest.NewToken(auth.JWTClaims{UserID: userID, Email: testEmail}, secret)
+		if _, err := svc.ParseAndValidateToken(forged); !errors.Is(err, auth.ErrUnexpectedSigningMethod) {
+			t.Fatalf("HS256 с открытым ключом (%s): err = %v, ожидался ErrUnexpectedSigningMethod", name, err)
+		}
+	}
+
+	token, err := svc.Login(context.Background(), testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := svc.ParseAndValidateToken(token); err != nil {
+		t.Fatalf("собственный RS256-токен: %v", err)
+	}
+}
+
+func TestTokenHeadersRoundTrip(t *testing.T) {
+	headers := map[string]interface{}{"kid": "gateway-eu", "cty": "JWT", "x-route": "eu-west"}
+	svc, _, _ := newTestService(t, auth.WithTokenHeaders(headers))
+
+	token, err := svc.Login(context.Background(), testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	got, err := svc.PeekHeader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range headers {
+		if got[name] != want {
+			t.Fatalf("заголовок %s = %v, ожидался %v", name, got[name], want)
+		}
+	}
+	if got["alg"] != "HS256" {
+		t.Fatalf("alg = %v", got["alg"])
+	}
+	// Разбор библиотекой видит те же заголовки, а сервис принимает токен
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["x-route"] != "eu-west" {
+		t.Fatalf("x-route после разбора = %v", parsed.Header["x-route"])
+	}
+	if _, err := svc.ParseAndValidateToken(token); err != nil {
+		t.Fatalf("ParseAndValidateToken: %v", err)
+	}
+
+	if _, err := auth.NewAuthService(memory.NewInMemoryStorage(), testSecret, time.Hour,
+		auth.WithTokenHeaders(map[string]interface{}{"alg": "none"})); err == nil {
+		t.Fatal("WithTokenHeaders переопределил alg")
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// UserStatus - состояние аккаунта для UserStatusResolver.
+type UserStatus int
+
+const (
+	UserActive   UserStatus = iota // аккаунт действует
+	UserDisabled                   // заблокирован (бан, приостановка)
+	UserDeleted                    // удален
+)
+
+// UserStatusResolver возвращает текущее состояние аккаунта пользователя
+// (WithUserStatusResolver). Вызывается при каждой проверке токена, поэтому
+// должен быть быстрым; кэширование - на стороне реализации.
+type UserStatusResolver func(ctx context.Context, userID int64) (UserStatus, error)
+
+// checkUserStatus отклоняет токены заблокированных и удаленных
+// пользователей (WithUserStatusResolver).
+func (s *AuthService) checkUserStatus(ctx context.Context, claims *JWTClaims) error {
+	if s.userStatus == nil {
+		return nil
+	}
+
+	status, err := s.userStatus(ctx, claims.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки состояния пользователя: %w", err)
+	}
+	if status == UserDisabled || status == UserDeleted {
+		return ErrUserDisabled
+	}
+	return nil
+}
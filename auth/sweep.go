@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Sweepable - необязательное расширение SessionStore и TokenBlacklist
+// для хранилищ без собственного TTL (SQL, память): Sweep удаляет записи,
+// срок которых истек к now, и возвращает их число. Для черного списка
+// это записи с exp до now; что считать истекшей сессией, решает хранилище
+// (например, LastSeen старше его срока бездействия). Хранилищам, которые
+// удаляют записи сами (Redis EXPIRE), реализовывать его не нужно.
+type Sweepable interface {
+	Sweep(ctx context.Context, now time.Time) (removed int, err error)
+}
+
+// SweepExpired (Очистка истекших записей)
+// Удаляет истекшие записи из SessionStore и TokenBlacklist, если они
+// реализуют Sweepable; остальные хранилища пропускаются, и без таких
+// хранилищ вызов возвращает 0, nil. Предназначен для периодического
+// запуска приложением (например, раз в час по time.Ticker) - сам сервис
+// фоновых горутин не заводит. Ошибка одного хранилища не прерывает
+// очистку другого: removed - сумма по всем, err - объединение ошибок.
+func (s *AuthService) SweepExpired(ctx context.Context) (removed int, err error) {
+	now := s.clock.Now()
+
+	var errs []error
+	for _, store := range []interface{}{s.sessions, s.blacklist} {
+		sw, ok := store.(Sweepable)
+		if !ok {
+			continue
+		}
+		n, err := sw.Sweep(ctx, now)
+		removed += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
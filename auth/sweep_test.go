@@ -0,0 +1,72 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+)
+
+func TestSweepExpired(t *testing.T) {
+	clock := newFakeClock()
+	sessions := newMemSessions(30 * time.Minute)
+	blacklist := newMemBlacklist()
+	svc, _, _ := newTestService(t,
+		auth.WithClock(clock),
+		auth.WithSessionStore(sessions),
+		auth.WithTokenBlacklist(blacklist))
+	ctx := context.Background()
+
+	first, err := svc.Login(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("второй Login: %v", err)
+	}
+	if err := svc.Logout(ctx, first); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	wantSessions := len(sessions.sessions)
+	if wantSessions == 0 {
+		t.Fatal("после входа нет ни одной сессии")
+	}
+	if blacklist.Len() != 1 {
+		t.Fatalf("в черном списке %d записей, ожидалась 1", blacklist.Len())
+	}
+
+	// Пока ничего не истекло, очистка ничего не удаляет
+	removed, err := svc.SweepExpired(ctx)
+	if err != nil || removed != 0 {
+		t.Fatalf("SweepExpired до истечения: removed = %d, err = %v", removed, err)
+	}
+
+	// Сессии простаивают дольше 30 минут, запись черного списка еще живет
+	clock.Advance(45 * time.Minute)
+	removed, err = svc.SweepExpired(ctx)
+	if err != nil || removed != wantSessions {
+		t.Fatalf("SweepExpired после простоя: removed = %d, err = %v, ожидалось %d", removed, err, wantSessions)
+	}
+	if len(sessions.sessions) != 0 || blacklist.Len() != 1 {
+		t.Fatalf("осталось сессий %d, записей черного списка %d", len(sessions.sessions), blacklist.Len())
+	}
+
+	// После exp отозванного токена его запись больше не нужна
+	clock.Advance(time.Hour)
+	removed, err = svc.SweepExpired(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("SweepExpired после exp: removed = %d, err = %v", removed, err)
+	}
+	if blacklist.Len() != 0 {
+		t.Fatalf("в черном списке осталось %d записей", blacklist.Len())
+	}
+}
+
+func TestSweepExpiredWithoutSweepableStores(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	removed, err := svc.SweepExpired(context.Background())
+	if err != nil || removed != 0 {
+		t.Fatalf("SweepExpired: removed = %d, err = %v", removed, err)
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantKeyResolver возвращает ключи подписи и проверки арендатора
+// (WithTenantKeys). Ключи должны подходить к алгоритму сервиса.
+// Для неизвестного арендатора возвращается ошибка.
+type TenantKeyResolver func(ctx context.Context, tenantID string) (signKey, verifyKey interface{}, err error)
+
+// tenantHeader - заголовок JWT с ID арендатора: по нему выбирается ключ
+// проверки до проверки подписи. Дублирует claim tid.
+const tenantHeader = "tid"
+
+// tenantKey - ключ для хранения ID арендатора в context.Context.
+type tenantKey struct{}
+
+// WithTenant прикладывает к контексту ID арендатора: Login подписывает
+// токен ключом арендатора и записывает tid в claims.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext достает ID арендатора, сохраненный WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// tenantKeys запрашивает ключи арендатора у TenantKeyResolver.
+func (s *AuthService) tenantKeys(ctx context.Context, tenantID string) (interface{}, interface{}, error) {
+	signKey, verifyKey, err := s.tenantResolver(ctx, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w %q: %v", ErrUnknownTenant, tenantID, err)
+	}
+	if signKey == nil && verifyKey == nil {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownTenant, tenantID)
+	}
+	return signKey, verifyKey, nil
+}
+
+// tokenTenant возвращает ID арендатора из заголовка, а для старых
+// токенов без заголовка - из claim tid.
+func tokenTenant(token *jwt.Token) string {
+	if tid, ok := token.Header[tenantHeader].(string); ok {
+		return tid
+	}
+	if claims, ok := token.Claims.(*JWTClaims); ok {
+		return claims.TenantID
+	}
+	return ""
+}
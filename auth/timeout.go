@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// timeoutStorage ограничивает время каждого вызова Storage (WithStorageTimeout).
+type timeoutStorage struct {
+	inner   Storage
+	timeout time.Duration
+}
+
+// call выполняет fn с таймаутом, производным от ctx. Истечение собственного
+// таймаута (а не дедлайна вызывающего) возвращается как ErrStorageTimeout.
+func (t *timeoutStorage) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	tctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	err := fn(tctx)
+	if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w (%s): %w", ErrStorageTimeout, t.timeout, err)
+	}
+	return err
+}
+
+func (t *timeoutStorage) GetUserByEmail(ctx context.Context, email string) (user UserIn, err error) {
+	err = t.call(ctx, func(ctx context.Context) error {
+		user, err = t.inner.GetUserByEmail(ctx, email)
+		return err
+	})
+	return user, err
+}
+
+func (t *timeoutStorage) GetUserByID(ctx context.Context, id int64) (user UserIn, err error) {
+	err = t.call(ctx, func(ctx context.Context) error {
+		user, err = t.inner.GetUserByID(ctx, id)
+		return err
+	})
+	return user, err
+}
+
+func (t *timeoutStorage) CreateUser(ctx context.Context, email, passwordHash string) (id int64, err error) {
+	err = t.call(ctx, func(ctx context.Context) error {
+		id, err = t.inner.CreateUser(ctx, email, passwordHash)
+		return err
+	})
+	return id, err
+}
+
+func (t *timeoutStorage) UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error {
+	return t.call(ctx, func(ctx context.Context) error {
+		return t.inner.UpdatePasswordHash(ctx, userID, passwordHash)
+	})
+}
+
+// SetTOTP передает вызов в Storage, если тот реализует TOTPStorage.
+func (t *timeoutStorage) SetTOTP(ctx context.Context, userID int64, secret string, recoveryCodeHashes []string) error {
+	ts, ok := t.inner.(TOTPStorage)
+	if !ok {
+		return ErrTOTPUnsupported
+	}
+	return t.call(ctx, func(ctx context.Context) error {
+		return ts.SetTOTP(ctx, userID, secret, recoveryCodeHashes)
+	})
+}
+
+// UseRecoveryCode передает вызов в Storage, если тот реализует TOTPStorage.
+func (t *timeoutStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (used bool, err error) {
+	ts, ok := t.inner.(TOTPStorage)
+	if !ok {
+		return false, ErrTOTPUnsupported
+	}
+	err = t.call(ctx, func(ctx context.Context) error {
+		used, err = ts.UseRecoveryCode(ctx, userID, codeHash)
+		return err
+	})
+	return used, err
+}
+
+// BumpTokenVersion передает вызов в Storage, если тот реализует TokenVersionStorage.
+func (t *timeoutStorage) BumpTokenVersion(ctx context.Context, userID int64) error {
+	tv, ok := t.inner.(TokenVersionStorage)
+	if !ok {
+		return ErrTokenVersioningUnsupported
+	}
+	return t.call(ctx, func(ctx context.Context) error {
+		return tv.BumpTokenVersion(ctx, userID)
+	})
+}
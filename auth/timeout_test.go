@@ -0,0 +1,54 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+	"go_auth_pkg/auth/memory"
+)
+
+// slowStorage - Storage, отвечающий на GetUserByEmail через delay
+// или по отмене ctx.
+type slowStorage struct {
+	*memory.InMemoryStorage
+	delay time.Duration
+}
+
+func (s *slowStorage) GetUserByEmail(ctx context.Context, email string) (auth.UserIn, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.InMemoryStorage.GetUserByEmail(ctx, email)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestStorageTimeout(t *testing.T) {
+	storage := &slowStorage{InMemoryStorage: memory.NewInMemoryStorage(), delay: 5 * time.Second}
+	if _, err := storage.SeedUser(testEmail, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := auth.NewAuthService(storage, testSecret, time.Hour, auth.WithStorageTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = svc.Login(context.Background(), testEmail, testPassword)
+	if !errors.Is(err, auth.ErrStorageTimeout) {
+		t.Fatalf("Login: err = %v, ожидался ErrStorageTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Login ждал хранилище %s при таймауте 50ms", elapsed)
+	}
+
+	// Дедлайн вызывающего - не таймаут хранилища
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := svc.Login(ctx, testEmail, testPassword); err == nil || errors.Is(err, auth.ErrStorageTimeout) {
+		t.Fatalf("Login с истекшим ctx: err = %v, ожидалась ошибка контекста", err)
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TokenLimitMode - что делать при выпуске токена сверх
+// WithMaxActiveTokensPerUser.
+type TokenLimitMode int
+
+const (
+	// TokenLimitReject - новый токен не выдается (ErrTooManyTokens), пока
+	// прежние не истекут или не будут отозваны Logout/LogoutAll. Токены,
+	// отозванные иначе (RevokeSession), учитываются до своего exp.
+	TokenLimitReject TokenLimitMode = iota
+	// TokenLimitEvictOldest - самые старые токены отзываются через черный
+	// список, чтобы освободить место для нового.
+	TokenLimitEvictOldest
+)
+
+// reserveTokenSlot проверяет лимит активных токенов пользователя перед
+// выпуском нового: отклоняет выпуск или отзывает самые старые токены.
+func (s *AuthService) reserveTokenSlot(ctx context.Context, userID int64) error {
+	if s.activeTokens == nil || s.maxActiveTokens <= 0 {
+		return nil
+	}
+
+	list, err := s.activeTokens.ListActive(ctx, userID, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	excess := len(list) - s.maxActiveTokens + 1
+	if excess <= 0 {
+		return nil
+	}
+	if s.tokenLimitMode != TokenLimitEvictOldest {
+		return ErrTooManyTokens
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].IssuedAt.Before(list[j].IssuedAt) })
+	for _, old := range list[:excess] {
+		if err := s.blacklist.Add(ctx, old.ID, old.ExpiresAt); err != nil {
+			return err
+		}
+		if err := s.activeTokens.Remove(ctx, userID, old.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackToken записывает выпущенный токен для WithMaxActiveTokensPerUser.
+func (s *AuthService) trackToken(ctx context.Context, userID int64, jti string, issuedAt, expiresAt time.Time) error {
+	if s.activeTokens == nil || s.maxActiveTokens <= 0 {
+		return nil
+	}
+	return s.activeTokens.Track(ctx, userID, ActiveToken{ID: jti, IssuedAt: issuedAt, ExpiresAt: expiresAt})
+}
+
+// untrackToken удаляет запись токена после Logout.
+func (s *AuthService) untrackToken(ctx context.Context, claims *JWTClaims) error {
+	if s.activeTokens == nil || claims.ID == "" {
+		return nil
+	}
+	return s.activeTokens.Remove(ctx, claims.UserID, claims.ID)
+}
+
+// untrackUserTokens удаляет записи всех токенов пользователя
+// после RevokeAllTokens и LogoutAll.
+func (s *AuthService) untrackUserTokens(ctx context.Context, userID int64) error {
+	if s.activeTokens == nil {
+		return nil
+	}
+	return s.activeTokens.RemoveAll(ctx, userID)
+}
@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+)
+
+func TestMaxActiveTokensEvictOldest(t *testing.T) {
+	clock := newFakeClock()
+	svc, _, _ := newTestService(t,
+		auth.WithClock(clock),
+		auth.WithTokenBlacklist(newMemBlacklist()),
+		auth.WithMaxActiveTokensPerUser(newMemActiveTokens(), 2, auth.TokenLimitEvictOldest))
+	ctx := context.Background()
+
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		token, err := svc.Login(ctx, testEmail, testPassword)
+		if err != nil {
+			t.Fatalf("Login #%d: %v", i+1, err)
+		}
+		tokens = append(tokens, token)
+		clock.Advance(time.Second)
+	}
+
+	if _, err := svc.ParseAndValidateToken(tokens[0]); !errors.Is(err, auth.ErrTokenRevoked) {
+		t.Fatalf("самый старый токен: err = %v, ожидался ErrTokenRevoked", err)
+	}
+	for i, token := range tokens[1:] {
+		if _, err := svc.ParseAndValidateToken(token); err != nil {
+			t.Fatalf("токен #%d: %v", i+2, err)
+		}
+	}
+}
+
+func TestMaxActiveTokensReject(t *testing.T) {
+	svc, _, _ := newTestService(t,
+		auth.WithTokenBlacklist(newMemBlacklist()),
+		auth.WithMaxActiveTokensPerUser(newMemActiveTokens(), 2, auth.TokenLimitReject))
+	ctx := context.Background()
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		token, err := svc.Login(ctx, testEmail, testPassword)
+		if err != nil {
+			t.Fatalf("Login #%d: %v", i+1, err)
+		}
+		tokens = append(tokens, token)
+	}
+	if _, err := svc.Login(ctx, testEmail, testPassword); !errors.Is(err, auth.ErrTooManyTokens) {
+		t.Fatalf("Login сверх лимита: err = %v, ожидался ErrTooManyTokens", err)
+	}
+	for i, token := range tokens {
+		if _, err := svc.ParseAndValidateToken(token); err != nil {
+			t.Fatalf("токен #%d после отказа: %v", i+1, err)
+		}
+	}
+
+	// Logout отзывает токен и освобождает место под новый
+	if err := svc.Logout(ctx, tokens[0]); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := svc.Login(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("Login после Logout: %v", err)
+	}
+}
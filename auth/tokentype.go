@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Типы токенов (заголовок typ). Каждый путь проверки принимает только
+// свой тип, поэтому, например, токен сброса пароля нельзя предъявить
+// как access-токен.
+const (
+	TokenTypeAccess        = "at+jwt" // RFC 9068
+	TokenTypeVerifyEmail   = "verify-email+jwt"
+	TokenTypePasswordReset = "pw-reset+jwt"
+	TokenTypeDeviceTrust   = "device+jwt"
+	TokenTypeAction        = "action+jwt" // IssueActionToken
+	TokenTypeMagicLink     = "magic-link+jwt"
+)
+
+// tokenTypeFor возвращает typ для выпускаемого токена.
+func (s *AuthService) tokenTypeFor(claims jwt.Claims) string {
+	if c, ok := claims.(purposeClaims); ok {
+		return purposeTokenType(c.Purpose)
+	}
+	return s.accessTokenType()
+}
+
+// accessTokenType - typ access-токенов: из WithTokenHeaders, если задан там.
+func (s *AuthService) accessTokenType() string {
+	if typ, ok := s.tokenHeaders["typ"].(string); ok && typ != "" {
+		return typ
+	}
+	return TokenTypeAccess
+}
+
+// purposeTokenType - typ служебного токена с назначением purpose.
+func purposeTokenType(purpose string) string {
+	switch purpose {
+	case PurposeVerifyEmail:
+		return TokenTypeVerifyEmail
+	case PurposePasswordReset:
+		return TokenTypePasswordReset
+	case PurposeDeviceTrust:
+		return TokenTypeDeviceTrust
+	case PurposeMagicLink:
+		return TokenTypeMagicLink
+	default:
+		return TokenTypeAction
+	}
+}
+
+// checkTokenType сверяет typ проверенного токена с ожидаемым. Тип
+// сравнивается без учета регистра и префикса "application/" (RFC 8725).
+// С WithLegacyTokenType принимаются и токены прежних версий (typ "JWT" или без typ).
+func (s *AuthService) checkTokenType(token *jwt.Token, want string) error {
+	typ, _ := token.Header["typ"].(string)
+	if len(typ) > len("application/") && strings.EqualFold(typ[:len("application/")], "application/") {
+		typ = typ[len("application/"):]
+	}
+	if strings.EqualFold(typ, want) {
+		return nil
+	}
+	if s.legacyTokenType && (typ == "" || strings.EqualFold(typ, "JWT")) {
+		return nil
+	}
+	return ErrWrongTokenType
+}
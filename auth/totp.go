@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) - значения по умолчанию у приложений-аутентификаторов.
+const (
+	totpPeriod    = 30 * time.Second
+	totpDigits    = 6
+	totpSkew      = 1 // допустимое отклонение в шагах (±30 секунд)
+	totpSecretLen = 20
+)
+
+// totpEncoding - base32 без паддинга, как в otpauth:// ссылках.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// LoginWith2FA (Логин с 2FA)
+// Как Login, но после проверки пароля сверяет TOTP-код с секретом
+// пользователя (TOTPProvider). Если у пользователя 2FA не включена,
+// код игнорируется и выполняется обычный вход по паролю.
+// deviceToken - необязательный токен доверенного устройства
+// (IssueDeviceToken): если он действителен для этого пользователя,
+// код не запрашивается. Недействительный токен устройства игнорируется.
+func (s *AuthService) LoginWith2FA(ctx context.Context, email, password, totpCode, deviceToken string) (string, error) {
+	user, err := s.authenticate(ctx, email, password,
+		secondFactor{totpCode: totpCode, deviceToken: deviceToken})
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueAccessToken(ctx, user, TokenOptions{authTime: s.clock.Now()})
+}
+
+// GenerateTOTPSecret создает секрет для подключения 2FA и ссылку
+// otpauth:// (обычно показывается пользователю как QR-код).
+// issuer - название сервиса (может быть пустым), accountName - обычно
+// email пользователя.
+func GenerateTOTPSecret(issuer, accountName string) (string, string, error) {
+	return generateTOTPSecret(rand.Reader, issuer, accountName)
+}
+
+// generateTOTPSecret - GenerateTOTPSecret с заданным источником случайности.
+func generateTOTPSecret(random io.Reader, issuer, accountName string) (string, string, error) {
+	b := make([]byte, totpSecretLen)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return "", "", err
+	}
+	secret := totpEncoding.EncodeToString(b)
+
+	label := url.PathEscape(accountName)
+	q := url.Values{}
+	q.Set("secret", secret)
+	if issuer != "" {
+		label = url.PathEscape(issuer + ":" + accountName)
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	return secret, "otpauth://totp/" + label + "?" + q.Encode(), nil
+}
+
+// checkTOTP проверяет второй фактор, если он включен у пользователя.
+// Неверный код учитывается как неудачная попытка входа по email -
+// нормализованному email входа, тому же ключу, что у checkLockout.
+func (s *AuthService) checkTOTP(ctx context.Context, user UserIn, email, code string) error {
+	tp, ok := user.(TOTPProvider)
+	if !ok || tp.GetTOTPSecret() == "" {
+		return nil
+	}
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	if !validateTOTP(tp.GetTOTPSecret(), code, s.clock.Now()) {
+		if err := s.recordLoginFailure(ctx, email); err != nil {
+			return err
+		}
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// validateTOTP сверяет код с секретом с учетом отклонения ±totpSkew шагов.
+func validateTOTP(secret, code string, now time.Time) bool {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != totpDigits {
+		return false
+	}
+
+	counter := now.Unix() / int64(totpPeriod.Seconds())
+	for i := -totpSkew; i <= totpSkew; i++ {
+		expected := totpCode(key, uint64(counter+int64(i)))
+		if SecureCompare(expected, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// totpCode вычисляет HOTP-код (RFC 4226) для значения счетчика.
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// Коды восстановления: recoveryCodeCount кодов по recoveryCodeBytes
+// случайных байт, в виде групп hex через дефис (например, 1f2e-3d4c-5b6a-7988).
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 8
+)
+
+// EnrollTOTP (Подключение 2FA)
+// Генерирует новый TOTP-секрет и одноразовые коды восстановления
+// и сохраняет их через TOTPStorage (коды - только SHA-256 хэшами).
+// Возвращает секрет, ссылку otpauth:// для QR-кода и коды в открытом
+// виде - их нужно один раз показать пользователю. Повторный вызов
+// заменяет секрет (потерянный аутентификатор) и аннулирует старые коды.
+// Название сервиса в приложении - WithTOTPIssuer.
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID int64) (string, string, []string, error) {
+	ts, ok := s.storage.(TOTPStorage)
+	if !ok {
+		return "", "", nil, ErrTOTPUnsupported
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	secret, otpauthURL, err := generateTOTPSecret(s.randReader, s.totpIssuer(), user.GetEmail())
+	if err != nil {
+		return "", "", nil, fmt.Errorf("ошибка генерации TOTP-секрета: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := s.randomHex(recoveryCodeBytes)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("ошибка генерации кода восстановления: %w", err)
+		}
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := ts.SetTOTP(ctx, userID, secret, hashes); err != nil {
+		return "", "", nil, err
+	}
+	s.audit(ctx, AuditTOTPEnroll, 0, userID, nil)
+	return secret, otpauthURL, codes, nil
+}
+
+// ConsumeRecoveryCode (Код восстановления)
+// Проверяет код восстановления пользователя, потерявшего аутентификатор,
+// и гасит его: каждый код срабатывает один раз. Регистр, пробелы и дефисы
+// не важны. false без ошибки - код неверный или уже использован.
+func (s *AuthService) ConsumeRecoveryCode(ctx context.Context, userID int64, code string) (bool, error) {
+	ts, ok := s.storage.(TOTPStorage)
+	if !ok {
+		return false, ErrTOTPUnsupported
+	}
+	if normalizeRecoveryCode(code) == "" {
+		return false, nil
+	}
+	return ts.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+}
+
+// totpIssuer - название сервиса в otpauth:// (WithTOTPIssuer или WithIssuer).
+func (s *AuthService) totpIssuer() string {
+	if s.totpIssuerName != "" {
+		return s.totpIssuerName
+	}
+	return s.issuer
+}
+
+// normalizeRecoveryCode убирает пробелы и дефисы и приводит код к нижнему регистру.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
+
+// hashRecoveryCode - SHA-256 нормализованного кода в hex. Коды случайные
+// (64 бита), поэтому медленный хэш не нужен, а поиск по хэшу остается точным.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
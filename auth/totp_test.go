@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret - ключ SHA1 из RFC 6238, Appendix B ("12345678901234567890").
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// Appendix B дает 8 цифр; 6-значный код - их последние 6 цифр
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"}, // ведущий ноль: проверка %0*d
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		if got := totpCode(rfc6238Secret, uint64(v.unix/30)); got != v.want {
+			t.Errorf("T=%d: код %s, ожидался %s", v.unix, got, v.want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfc6238Secret)
+	at := time.Unix(1111111109, 0)
+	const code = "081804"
+
+	tests := []struct {
+		offset time.Duration
+		want   bool
+	}{
+		{0, true},
+		{30 * time.Second, true},
+		{-30 * time.Second, true},
+		{60 * time.Second, false},
+		{-60 * time.Second, false},
+	}
+	for _, tt := range tests {
+		if got := validateTOTP(secret, code, at.Add(tt.offset)); got != tt.want {
+			t.Errorf("смещение %s: %v, ожидалось %v", tt.offset, got, tt.want)
+		}
+	}
+
+	// Секрет в нижнем регистре и с пробелами, как его вводят вручную
+	if !validateTOTP(" "+strings.ToLower(secret)+" ", code, at) {
+		t.Error("секрет в нижнем регистре с пробелами не принят")
+	}
+	for _, bad := range []string{"", "81804", "0818040", "abcdef"} {
+		if validateTOTP(secret, bad, at) {
+			t.Errorf("код %q принят", bad)
+		}
+	}
+}
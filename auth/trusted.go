@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IssuerConfig - параметры проверки токенов доверенного издателя
+// (WithTrustedIssuers).
+type IssuerConfig struct {
+	// Key - ключ проверки подписи ([]byte, *rsa.PublicKey, *ecdsa.PublicKey,
+	// ed25519.PublicKey).
+	Key interface{}
+	// Method - алгоритм подписи издателя; nil - по типу Key, как в NewVerifier.
+	Method jwt.SigningMethod
+	// Audience - допустимые aud токенов издателя; пусто - как WithAudience.
+	Audience []string
+}
+
+// trustedIssuer - проверенный IssuerConfig.
+type trustedIssuer struct {
+	key      interface{}
+	method   jwt.SigningMethod
+	audience []string
+}
+
+// initTrustedIssuers проверяет конфигурации WithTrustedIssuers.
+func (s *AuthService) initTrustedIssuers(configs map[string]IssuerConfig) error {
+	if configs == nil {
+		return nil
+	}
+	if s.sigVerifier != nil {
+		return errors.New("WithTrustedIssuers несовместим с WithSignatureVerifier")
+	}
+
+	s.trustedIssuers = make(map[string]trustedIssuer, len(configs))
+	for iss, cfg := range configs {
+		if iss == "" {
+			return errors.New("WithTrustedIssuers: пустой издатель")
+		}
+		if cfg.Key == nil {
+			return fmt.Errorf("WithTrustedIssuers: не задан ключ издателя %q", iss)
+		}
+		method := cfg.Method
+		if method == nil {
+			var err error
+			if method, err = methodForKey(cfg.Key); err != nil {
+				return fmt.Errorf("WithTrustedIssuers: издатель %q: %w", iss, err)
+			}
+		}
+		if method.Alg() == jwt.SigningMethodNone.Alg() {
+			return fmt.Errorf("WithTrustedIssuers: недопустимый алгоритм подписи издателя %q: none", iss)
+		}
+		s.trustedIssuers[iss] = trustedIssuer{
+			key:      cfg.Key,
+			method:   method,
+			audience: slices.Clone(cfg.Audience),
+		}
+	}
+	return nil
+}
+
+// trustedIssuerKey выбирает ключ проверки access-токена по iss.
+// handled == false - токен выпущен самим сервисом (iss == WithIssuer)
+// и проверяется его ключами.
+func (s *AuthService) trustedIssuerKey(token *jwt.Token) (key interface{}, handled bool, err error) {
+	iss, _ := token.Claims.GetIssuer()
+	issuer, ok := s.trustedIssuers[iss]
+	if !ok {
+		if s.issuer != "" && iss == s.issuer {
+			return nil, false, nil
+		}
+		return nil, true, fmt.Errorf("%w: издатель %q не в списке доверенных", ErrInvalidIssuer, iss)
+	}
+	if token.Method.Alg() != issuer.method.Alg() {
+		return nil, true, ErrUnexpectedSigningMethod
+	}
+	return issuer.key, true, nil
+}
+
+// checkTrustedAudience проверяет aud токена по IssuerConfig.Audience его
+// издателя, а для собственных токенов - по WithAudience.
+func (s *AuthService) checkTrustedAudience(claims *JWTClaims) error {
+	if s.trustedIssuers == nil {
+		return nil
+	}
+	want := s.audience
+	if issuer, ok := s.trustedIssuers[claims.Issuer]; ok && len(issuer.audience) > 0 {
+		want = issuer.audience
+	}
+	if len(want) == 0 {
+		return nil
+	}
+	for _, aud := range claims.Audience {
+		if slices.Contains(want, aud) {
+			return nil
+		}
+	}
+	return ErrInvalidAudience
+}
@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_auth_pkg/auth"
+)
+
+// rfc6238Secret - ключ "12345678901234567890" из RFC 6238, Appendix B, в base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfc6238Time и rfc6238Code - момент из Appendix B и 6-значный код для него.
+var rfc6238Time = time.Unix(1111111109, 0)
+
+const rfc6238Code = "081804"
+
+func TestLoginWith2FA(t *testing.T) {
+	clock := &fakeClock{now: rfc6238Time}
+	svc, storage, userID := newTestService(t, auth.WithClock(clock))
+	ctx := context.Background()
+	if err := storage.SetTOTP(ctx, userID, rfc6238Secret, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.LoginWith2FA(ctx, testEmail, testPassword, "", ""); !errors.Is(err, auth.ErrTOTPRequired) {
+		t.Fatalf("без кода: err = %v, ожидался ErrTOTPRequired", err)
+	}
+	if _, err := svc.Login(ctx, testEmail, testPassword); !errors.Is(err, auth.ErrTOTPRequired) {
+		t.Fatalf("Login без второго фактора: err = %v, ожидался ErrTOTPRequired", err)
+	}
+	if _, err := svc.LoginWith2FA(ctx, testEmail, testPassword, "000000", ""); !errors.Is(err, auth.ErrInvalidTOTPCode) {
+		t.Fatalf("неверный код: err = %v, ожидался ErrInvalidTOTPCode", err)
+	}
+	// Код не спасает неверный пароль
+	if _, err := svc.LoginWith2FA(ctx, testEmail, "wrong-password", rfc6238Code, ""); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("неверный пароль: err = %v, ожидался ErrInvalidCredentials", err)
+	}
+
+	// Код соседнего шага принимается, через шаг - уже нет
+	tests := []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{0, true},
+		{30 * time.Second, true},
+		{-30 * time.Second, true},
+		{60 * time.Second, false},
+		{-60 * time.Second, false},
+	}
+	for _, tt := range tests {
+		clock.mu.Lock()
+		clock.now = rfc6238Time.Add(tt.offset)
+		clock.mu.Unlock()
+
+		_, err := svc.LoginWith2FA(ctx, testEmail, testPassword, rfc6238Code, "")
+		if tt.ok && err != nil {
+			t.Errorf("смещение %s: %v", tt.offset, err)
+		}
+		if !tt.ok && !errors.Is(err, auth.ErrInvalidTOTPCode) {
+			t.Errorf("смещение %s: err = %v, ожидался ErrInvalidTOTPCode", tt.offset, err)
+		}
+	}
+}
+
+func TestLoginWith2FAWithoutSecret(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	// Без секрета 2FA не включена: код игнорируется, вход по паролю
+	for _, code := range []string{"", "123456", "garbage"} {
+		token, err := svc.LoginWith2FA(ctx, testEmail, testPassword, code, "")
+		if err != nil {
+			t.Fatalf("код %q: %v", code, err)
+		}
+		if _, err := svc.ParseAndValidateToken(token); err != nil {
+			t.Fatalf("токен входа: %v", err)
+		}
+	}
+	if _, err := svc.LoginWith2FA(ctx, testEmail, "wrong-password", "", ""); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("неверный пароль: err = %v, ожидался ErrInvalidCredentials", err)
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, otpauthURL, err := auth.GenerateTOTPSecret("Example", "user@example.com")
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	// 20 байт в base32 без дополнения
+	if len(secret) != 32 {
+		t.Fatalf("длина секрета %d, ожидалось 32", len(secret))
+	}
+	want := "otpauth://totp/Example:user@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=" + secret
+	if otpauthURL != want {
+		t.Fatalf("ссылка %q, ожидалась %q", otpauthURL, want)
+	}
+	if other, _, _ := auth.GenerateTOTPSecret("Example", "user@example.com"); other == secret {
+		t.Fatal("два секрета совпали")
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TypedClaims - ограничение для ParseTyped: указатель на собственную
+// структуру claims вызывающего, встраивающую jwt.RegisteredClaims.
+type TypedClaims[T any] interface {
+	*T
+	jwt.Claims
+}
+
+// ParseTyped (Типизированные claims)
+// Проверяет токен так же, как ParseAndValidateToken (подпись, сроки, aud, iss,
+// отзыв, сессия), и разбирает payload в структуру T вызывающего.
+// Избавляет от приведения типов при работе с JWTClaims.Extra:
+//
+//	type AppClaims struct {
+//		UserID   int64  `json:"user_id"`
+//		TenantID string `json:"tenant_id"`
+//		Plan     string `json:"plan"`
+//		jwt.RegisteredClaims
+//	}
+//
+//	claims, err := auth.ParseTyped[AppClaims](service, tokenString)
+//	if err != nil { ... }
+//	fmt.Println(claims.TenantID, claims.Plan)
+func ParseTyped[T any, P TypedClaims[T]](s *AuthService, tokenString string) (*T, error) {
+	return ParseTypedContext[T, P](context.Background(), s, tokenString)
+}
+
+// ParseTypedContext - ParseTyped с контекстом для хранилищ.
+// Непрозрачные токены (WithOpaqueTokens) не поддерживаются.
+func ParseTypedContext[T any, P TypedClaims[T]](ctx context.Context, s *AuthService, tokenString string) (*T, error) {
+	tokenString, err := s.DecodeToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.validate(ctx, tokenString); err != nil {
+		return nil, err
+	}
+
+	// Подпись и claims уже проверены выше, осталось разобрать payload в T
+	claims := P(new(T))
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return nil, mapParseError(err)
+	}
+	return (*T)(claims), nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verifier - проверяющая сторона без права выпуска токенов.
+// Нужна сервисам, которые только принимают токены: ей не требуются
+// Storage и ключ подписи.
+type Verifier struct {
+	service *AuthService
+}
+
+// NewVerifier создает Verifier для ключа проверки. Алгоритм определяется
+// по типу ключа: []byte - HS256, *rsa.PublicKey - RS256,
+// *ecdsa.PublicKey - ES256/ES384/ES512 по кривой, ed25519.PublicKey - EdDSA.
+// Принимаются опции проверки (WithAudience, WithIssuer, WithLeeway,
+// WithTokenBlacklist, WithKeySet и т.д.); опции входа не действуют.
+func NewVerifier(verifyKey interface{}, opts ...Option) (*Verifier, error) {
+	method, err := methodForKey(verifyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newAuthService(nil, method, nil, verifyKey, 0, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Ключ подписи мог прийти из опций (WithSigningMethod) - не храним его
+	s.signKey = nil
+
+	return &Verifier{service: s}, nil
+}
+
+// methodForKey подбирает алгоритм подписи по типу ключа проверки.
+func methodForKey(key interface{}) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case []byte:
+		return jwt.SigningMethodHS256, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	}
+	return nil, fmt.Errorf("неподдерживаемый тип ключа проверки: %T", key)
+}
+
+// ParseAndValidateToken проверяет токен (см. AuthService.ParseAndValidateToken).
+func (v *Verifier) ParseAndValidateToken(tokenString string) (*JWTClaims, error) {
+	return v.service.ParseAndValidateToken(tokenString)
+}
+
+// ParseAndValidateTokenContext - ParseAndValidateToken с контекстом.
+func (v *Verifier) ParseAndValidateTokenContext(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	return v.service.ParseAndValidateTokenContext(ctx, tokenString)
+}
+
+// Introspect сообщает состояние токена (см. AuthService.Introspect).
+func (v *Verifier) Introspect(tokenString string) (TokenInfo, error) {
+	return v.service.Introspect(tokenString)
+}
+
+// IntrospectContext - Introspect с контекстом.
+func (v *Verifier) IntrospectContext(ctx context.Context, tokenString string) (TokenInfo, error) {
+	return v.service.IntrospectContext(ctx, tokenString)
+}
+
+// ValidateRequest проверяет токен HTTP-запроса (см. AuthService.ValidateRequest).
+func (v *Verifier) ValidateRequest(r *http.Request) (*JWTClaims, error) {
+	return v.service.ValidateRequest(r)
+}
+
+// Middleware - HTTP-обертка (см. AuthService.Middleware).
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return v.service.Middleware(next)
+}
+
+// RequireRole - проверка роли (см. AuthService.RequireRole).
+func (v *Verifier) RequireRole(role string) func(http.Handler) http.Handler {
+	return v.service.RequireRole(role)
+}
+
+// RequireScope - проверка scope (см. AuthService.RequireScope).
+func (v *Verifier) RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return v.service.RequireScope(scopes...)
+}
+
+// PeekHeader - заголовок токена без проверки (см. AuthService.PeekHeader).
+func (v *Verifier) PeekHeader(tokenString string) (map[string]interface{}, error) {
+	return v.service.PeekHeader(tokenString)
+}
+
+// PeekClaims - payload токена без проверки (см. AuthService.PeekClaims).
+func (v *Verifier) PeekClaims(tokenString string) (map[string]interface{}, error) {
+	return v.service.PeekClaims(tokenString)
+}
+
+// InspectToken - проверка токена для журналов без авторизации (см. AuthService.InspectToken).
+func (v *Verifier) InspectToken(tokenString string) (*JWTClaims, bool, error) {
+	return v.service.InspectToken(tokenString)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RevokeAllTokens (Отзыв всех токенов пользователя)
+// Увеличивает версию токенов пользователя: все выданные ему access-токены
+// перестают проходить проверку. Refresh-токены пользователя тоже отзываются.
+// Требует WithTokenVersioning.
+func (s *AuthService) RevokeAllTokens(ctx context.Context, userID int64) error {
+	if err := s.revokeAllTokens(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(ctx, AuditTokensRevoke, 0, userID, nil)
+	return nil
+}
+
+// revokeAllTokens выполняет RevokeAllTokens без записи аудита.
+func (s *AuthService) revokeAllTokens(ctx context.Context, userID int64) error {
+	if !s.tokenVersioning {
+		return ErrTokenVersioningUnsupported
+	}
+
+	if err := s.storage.(TokenVersionStorage).BumpTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.untrackUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if s.refresh != nil {
+		if err := s.refresh.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTokenVersion отклоняет токен, версия которого ниже текущей версии
+// пользователя. Токены без версии считаются выпущенными с версией 0.
+func (s *AuthService) checkTokenVersion(ctx context.Context, claims *JWTClaims) error {
+	if !s.tokenVersioning {
+		return nil
+	}
+
+	user, err := s.storage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrTokenRevoked
+		}
+		return fmt.Errorf("ошибка проверки версии токена: %w", err)
+	}
+
+	var current int64
+	if vp, ok := user.(TokenVersionProvider); ok {
+		current = vp.GetTokenVersion()
+	}
+	if claims.TokenVersion < current {
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// WebAuthnStore - хранилище passkey-ключей пользователей и данных
+// незавершенных церемоний (регистрации и входа).
+type WebAuthnStore interface {
+	// SaveCredential сохраняет новый ключ пользователя.
+	SaveCredential(ctx context.Context, userID int64, credential webauthn.Credential) error
+	// Credentials возвращает все ключи пользователя (пустой список, если их нет).
+	Credentials(ctx context.Context, userID int64) ([]webauthn.Credential, error)
+	// UpdateCredential обновляет ключ с тем же ID после входа
+	// (счетчик подписей, флаги).
+	UpdateCredential(ctx context.Context, userID int64, credential webauthn.Credential) error
+	// SaveCeremony сохраняет данные начатой церемонии под ceremonyID.
+	SaveCeremony(ctx context.Context, ceremonyID string, data webauthn.SessionData) error
+	// TakeCeremony возвращает и удаляет данные церемонии: каждая церемония
+	// завершается не более одного раза. Для неизвестного ID - ErrCeremonyNotFound.
+	TakeCeremony(ctx context.Context, ceremonyID string) (webauthn.SessionData, error)
+}
+
+// webauthnUser адаптирует UserIn к webauthn.User.
+type webauthnUser struct {
+	user        UserIn
+	credentials []webauthn.Credential
+}
+
+func (u webauthnUser) WebAuthnID() []byte                         { return webauthnUserID(u.user.GetID()) }
+func (u webauthnUser) WebAuthnName() string                       { return u.user.GetEmail() }
+func (u webauthnUser) WebAuthnDisplayName() string                { return u.user.GetEmail() }
+func (u webauthnUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// webauthnUserID кодирует ID пользователя в user handle WebAuthn.
+func webauthnUserID(userID int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+// BeginRegistration (Регистрация passkey)
+// Начинает добавление passkey уже вошедшему пользователю. Возвращает
+// параметры для navigator.credentials.create() и ID церемонии, который
+// клиент передает в FinishRegistration. Уже добавленные ключи исключаются.
+func (s *AuthService) BeginRegistration(ctx context.Context, userID int64) (*protocol.CredentialCreation, string, error) {
+	if s.webauthn == nil {
+		return nil, "", ErrWebAuthnUnsupported
+	}
+
+	user, err := s.webauthnUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exclusions := webauthn.Credentials(user.credentials).CredentialDescriptors()
+	creation, data, err := s.webauthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка начала регистрации passkey: %w", err)
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, ceremonyID, nil
+}
+
+// FinishRegistration проверяет ответ navigator.credentials.create()
+// (JSON-тело запроса клиента, например r.Body) и сохраняет новый ключ.
+// Церемония должна принадлежать тому же пользователю.
+func (s *AuthService) FinishRegistration(ctx context.Context, userID int64, ceremonyID string, response io.Reader) error {
+	if s.webauthn == nil {
+		return ErrWebAuthnUnsupported
+	}
+
+	data, err := s.webauthnStore.TakeCeremony(ctx, ceremonyID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.webauthnUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if response == nil {
+		return ErrPasskeyInvalid
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(response)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPasskeyInvalid, err)
+	}
+	credential, err := s.webauthn.CreateCredential(user, data, parsed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPasskeyInvalid, err)
+	}
+
+	return s.webauthnStore.SaveCredential(ctx, userID, *credential)
+}
+
+// BeginLogin (Вход по passkey)
+// Начинает вход по passkey: возвращает параметры для
+// navigator.credentials.get() и ID церемонии для FinishLogin.
+// Неизвестный email и пользователь без ключей дают ErrInvalidCredentials;
+// ограничение частоты и блокировка действуют как при входе по паролю.
+func (s *AuthService) BeginLogin(ctx context.Context, email string) (*protocol.CredentialAssertion, string, error) {
+	if s.webauthn == nil {
+		return nil, "", ErrWebAuthnUnsupported
+	}
+
+	email = s.normalizeEmail(email)
+	if err := s.checkRateLimit(ctx, email); err != nil {
+		return nil, "", err
+	}
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, "", err
+	}
+
+	stored, err := s.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrStorageTimeout) {
+			return nil, "", err
+		}
+		return nil, "", ErrInvalidCredentials
+	}
+	user, err := s.webauthnUser(ctx, stored.GetID())
+	if err != nil {
+		return nil, "", err
+	}
+	if len(user.credentials) == 0 {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	assertion, data, err := s.webauthn.BeginLogin(user)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка начала входа по passkey: %w", err)
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, ceremonyID, nil
+}
+
+// FinishLogin проверяет ответ navigator.credentials.get() и выдает
+// access-токен - такой же, как при входе по паролю (роли, сессия,
+// события и метрики). Ключ с признаком клонирования (счетчик подписей
+// не вырос) отклоняется.
+func (s *AuthService) FinishLogin(ctx context.Context, ceremonyID string, response io.Reader) (string, error) {
+	if s.webauthn == nil {
+		return "", ErrWebAuthnUnsupported
+	}
+
+	user, err := s.verifyPasskey(ctx, ceremonyID, response)
+	if err != nil {
+		s.metrics.IncLoginFailure(loginFailureReason(err))
+		email := ""
+		if user != nil {
+			email = user.GetEmail()
+		}
+		s.emitLoginFailure(ctx, email, err)
+		return "", err
+	}
+
+	s.metrics.IncLoginSuccess()
+	s.emit(ctx, Event{Type: EventLoginSuccess, UserID: user.GetID(), Email: user.GetEmail()})
+	return s.issueAccessToken(ctx, user, TokenOptions{authTime: s.clock.Now()})
+}
+
+// verifyPasskey выполняет проверки FinishLogin без отправки событий.
+// При ошибке после определения пользователя он тоже возвращается
+// (для события неудачного входа).
+func (s *AuthService) verifyPasskey(ctx context.Context, ceremonyID string, response io.Reader) (UserIn, error) {
+	data, err := s.webauthnStore.TakeCeremony(ctx, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(data.UserID) != 8 {
+		return nil, ErrCeremonyNotFound
+	}
+
+	user, err := s.webauthnUser(ctx, int64(binary.BigEndian.Uint64(data.UserID)))
+	if err != nil {
+		return nil, err
+	}
+	email := s.normalizeEmail(user.user.GetEmail())
+
+	if response == nil {
+		return user.user, ErrPasskeyInvalid
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(response)
+	if err != nil {
+		return user.user, fmt.Errorf("%w: %v", ErrPasskeyInvalid, err)
+	}
+	credential, err := s.webauthn.ValidateLogin(user, data, parsed)
+	if err != nil || credential.Authenticator.CloneWarning {
+		return user.user, s.failLogin(ctx, email)
+	}
+
+	if err := s.webauthnStore.UpdateCredential(ctx, user.user.GetID(), *credential); err != nil {
+		return user.user, err
+	}
+	if err := s.checkVerified(user.user); err != nil {
+		return user.user, err
+	}
+	if err := s.resetLoginFailures(ctx, email); err != nil {
+		return user.user, err
+	}
+	current, err := s.endPreviousSessions(ctx, user.user)
+	if err != nil {
+		return user.user, err
+	}
+	return current, nil
+}
+
+// webauthnUser загружает пользователя вместе с его ключами.
+func (s *AuthService) webauthnUser(ctx context.Context, userID int64) (webauthnUser, error) {
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return webauthnUser{}, err
+	}
+	credentials, err := s.webauthnStore.Credentials(ctx, userID)
+	if err != nil {
+		return webauthnUser{}, fmt.Errorf("ошибка хранилища passkey: %w", err)
+	}
+	return webauthnUser{user: user, credentials: credentials}, nil
+}
+
+// saveCeremony сохраняет данные церемонии под случайным ID.
+func (s *AuthService) saveCeremony(ctx context.Context, data *webauthn.SessionData) (string, error) {
+	ceremonyID, err := s.randomHex(16)
+	if err != nil {
+		return "", errors.New("ошибка генерации ID церемонии WebAuthn")
+	}
+	if err := s.webauthnStore.SaveCeremony(ctx, ceremonyID, *data); err != nil {
+		return "", err
+	}
+	return ceremonyID, nil
+}